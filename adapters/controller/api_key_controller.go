@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"coin-api/common"
+	"coin-api/database"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type ApiKeyOutputFactory func(*gin.Context) ports.ApiKeyOutputPort
+type ApiKeyInputFactory func(ports.ApiKeyOutputPort, repository.IApiKeyRepository, repository.IUserRepository) ports.ApiKeyInputPort
+type ApiKeyRepositoryFactory func(*gorm.DB) repository.IApiKeyRepository
+
+type ApiKeyController struct {
+	OutputFactory           ApiKeyOutputFactory
+	InputFactory            ApiKeyInputFactory
+	ApiKeyRepositoryFactory ApiKeyRepositoryFactory
+	UserRepositoryFactory   UserRepositoryFactory
+	ClientFactory           *database.PostgreSQLConnector
+}
+
+func NewApiKeyController(outputFactory ApiKeyOutputFactory, inputFactory ApiKeyInputFactory, apiKeyRepositoryFactory ApiKeyRepositoryFactory, userRepositoryFactory UserRepositoryFactory, clientFactory *database.PostgreSQLConnector) *ApiKeyController {
+	return &ApiKeyController{
+		OutputFactory:           outputFactory,
+		InputFactory:            inputFactory,
+		ApiKeyRepositoryFactory: apiKeyRepositoryFactory,
+		UserRepositoryFactory:   userRepositoryFactory,
+		ClientFactory:           clientFactory,
+	}
+}
+
+func (a *ApiKeyController) CreateApiKey() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報をformにマッピング
+		var form model.ApiKeyAddForm
+		if err := ctx.ShouldBind(&form); err != nil {
+			log.Log().Msg(fmt.Sprintf("バインドエラー ApiKeyAddForm : %s", common.CreateJsonString(&form)))
+			log.Error().Err(err).Send()
+		}
+
+		// APIキー発行処理
+		if err := a.newInputPort(ctx).CreateApiKey(&form); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (a *ApiKeyController) GetApiKeys() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// APIキー一覧取得処理
+		if err := a.newInputPort(ctx).SelectApiKeys(); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (a *ApiKeyController) RevokeApiKey() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報からAPIキーIDを取得
+		id := ctx.Param("id")
+
+		// APIキー失効処理
+		if err := a.newInputPort(ctx).RevokeApiKey(id); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (a *ApiKeyController) newInputPort(c *gin.Context) ports.ApiKeyInputPort {
+	op := a.OutputFactory(c)
+	conn := readConn(a.ClientFactory, c)
+	ar := a.ApiKeyRepositoryFactory(conn)
+	ur := a.UserRepositoryFactory(conn)
+	return a.InputFactory(op, ar, ur)
+}
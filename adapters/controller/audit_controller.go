@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"coin-api/common"
+	"coin-api/database"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type AuditOutputFactory func(*gin.Context) ports.AuditOutputPort
+type AuditInputFactory func(ports.AuditOutputPort, repository.IAuditRepository) ports.AuditInputPort
+type AuditRepositoryFactory func(*gorm.DB) repository.IAuditRepository
+
+type AuditController struct {
+	OutputFactory          AuditOutputFactory
+	InputFactory           AuditInputFactory
+	AuditRepositoryFactory AuditRepositoryFactory
+	ClientFactory          *database.PostgreSQLConnector
+}
+
+func NewAuditController(outputFactory AuditOutputFactory, inputFactory AuditInputFactory, auditRepositoryFactory AuditRepositoryFactory, clientFactory *database.PostgreSQLConnector) *AuditController {
+	return &AuditController{
+		OutputFactory:          outputFactory,
+		InputFactory:           inputFactory,
+		AuditRepositoryFactory: auditRepositoryFactory,
+		ClientFactory:          clientFactory,
+	}
+}
+
+func (a *AuditController) GetAuditLogs() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報(クエリ)をformにマッピング
+		var form model.AuditSearchForm
+		if err := ctx.ShouldBindQuery(&form); err != nil {
+			log.Log().Msg(fmt.Sprintf("バインドエラー AuditSearchForm : %s", common.CreateJsonString(&form)))
+			log.Error().Err(err).Send()
+		}
+
+		// 監査ログ取得処理
+		if err := a.newInputPort(ctx).SelectAuditLogs(&form); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (a *AuditController) newInputPort(ctx *gin.Context) ports.AuditInputPort {
+	op := a.OutputFactory(ctx)
+	conn := readConn(a.ClientFactory, ctx)
+	ar := a.AuditRepositoryFactory(conn)
+	return a.InputFactory(op, ar)
+}
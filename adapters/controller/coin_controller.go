@@ -14,27 +14,35 @@ import (
 )
 
 type CoinOutputFactory func(*gin.Context) ports.CoinOutputPort
-type CoinInputFactory func(ports.CoinOutputPort, repository.ICoinRepository, repository.IUserRepository, repository.ITxRepository) ports.CoinInputPort
+type CoinInputFactory func(ports.CoinOutputPort, repository.ICoinRepository, repository.IUserRepository, repository.ITransferRepository, repository.IOutboxRepository, repository.INotificationRepository, repository.ITxRepository) ports.CoinInputPort
 type CoinRepositoryFactory func(*gorm.DB) repository.ICoinRepository
+type TransferRepositoryFactory func(*gorm.DB) repository.ITransferRepository
 type TxRepositoryFactory func(*gorm.DB) repository.ITxRepository
+type NotificationRepositoryFactory func(*gorm.DB) repository.INotificationRepository
 
 type CoinController struct {
-	OutputFactory         CoinOutputFactory
-	InputFactory          CoinInputFactory
-	CoinRepositoryFactory CoinRepositoryFactory
-	UserRepositoryFactory UserRepositoryFactory
-	TxRepositoryFactory   TxRepositoryFactory
-	ClientFactory         *database.PostgreSQLConnector
+	OutputFactory                 CoinOutputFactory
+	InputFactory                  CoinInputFactory
+	CoinRepositoryFactory         CoinRepositoryFactory
+	UserRepositoryFactory         UserRepositoryFactory
+	TransferRepositoryFactory     TransferRepositoryFactory
+	OutboxRepositoryFactory       OutboxRepositoryFactory
+	NotificationRepositoryFactory NotificationRepositoryFactory
+	TxRepositoryFactory           TxRepositoryFactory
+	ClientFactory                 *database.PostgreSQLConnector
 }
 
-func NewCoinController(outputFactory CoinOutputFactory, inputFactory CoinInputFactory, coinRepositoryFactory CoinRepositoryFactory, userRepositoryFactory UserRepositoryFactory, txRepositoryFactory TxRepositoryFactory, clientFactory *database.PostgreSQLConnector) *CoinController {
+func NewCoinController(outputFactory CoinOutputFactory, inputFactory CoinInputFactory, coinRepositoryFactory CoinRepositoryFactory, userRepositoryFactory UserRepositoryFactory, transferRepositoryFactory TransferRepositoryFactory, outboxRepositoryFactory OutboxRepositoryFactory, notificationRepositoryFactory NotificationRepositoryFactory, txRepositoryFactory TxRepositoryFactory, clientFactory *database.PostgreSQLConnector) *CoinController {
 	return &CoinController{
-		OutputFactory:         outputFactory,
-		InputFactory:          inputFactory,
-		CoinRepositoryFactory: coinRepositoryFactory,
-		UserRepositoryFactory: userRepositoryFactory,
-		TxRepositoryFactory:   txRepositoryFactory,
-		ClientFactory:         clientFactory,
+		OutputFactory:                 outputFactory,
+		InputFactory:                  inputFactory,
+		CoinRepositoryFactory:         coinRepositoryFactory,
+		UserRepositoryFactory:         userRepositoryFactory,
+		TransferRepositoryFactory:     transferRepositoryFactory,
+		OutboxRepositoryFactory:       outboxRepositoryFactory,
+		NotificationRepositoryFactory: notificationRepositoryFactory,
+		TxRepositoryFactory:           txRepositoryFactory,
+		ClientFactory:                 clientFactory,
 	}
 }
 
@@ -50,7 +58,7 @@ func (c *CoinController) AddUseCoin(dbCtx context.Context) gin.HandlerFunc {
 		}
 
 		// コイン追加消費処理
-		if err := c.newInputPort(ctx).AddUseCoin(dbCtx, &form); err != nil {
+		if err := c.newInputPort(ctx).AddUseCoin(txContext(dbCtx, ctx), &form); err != nil {
 			log.Error().Stack().Err(err).Send()
 		}
 	}
@@ -66,7 +74,55 @@ func (c *CoinController) SendCoin(dbCtx context.Context) gin.HandlerFunc {
 		}
 
 		// コイン送金処理
-		if err := c.newInputPort(ctx).SendCoin(dbCtx, &form); err != nil {
+		if err := c.newInputPort(ctx).SendCoin(txContext(dbCtx, ctx), &form); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (c *CoinController) AcceptTransfer(dbCtx context.Context) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報をformにマッピング
+		var form model.TransferResolveForm
+		if err := ctx.ShouldBind(&form); err != nil {
+			log.Log().Msg(fmt.Sprintf("バインドエラー TransferResolveForm : %s", common.CreateJsonString(&form)))
+			log.Error().Err(err).Send()
+		}
+
+		// 承認待ち送金の承認処理
+		if err := c.newInputPort(ctx).AcceptTransfer(txContext(dbCtx, ctx), ctx.Param("id"), &form); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (c *CoinController) RejectTransfer(dbCtx context.Context) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報をformにマッピング
+		var form model.TransferResolveForm
+		if err := ctx.ShouldBind(&form); err != nil {
+			log.Log().Msg(fmt.Sprintf("バインドエラー TransferResolveForm : %s", common.CreateJsonString(&form)))
+			log.Error().Err(err).Send()
+		}
+
+		// 承認待ち送金の拒否処理
+		if err := c.newInputPort(ctx).RejectTransfer(txContext(dbCtx, ctx), ctx.Param("id"), &form); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (c *CoinController) ReverseHistory(dbCtx context.Context) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報をformにマッピング
+		var form model.CoinReverseForm
+		if err := ctx.ShouldBind(&form); err != nil {
+			log.Log().Msg(fmt.Sprintf("バインドエラー CoinReverseForm : %s", common.CreateJsonString(&form)))
+			log.Error().Err(err).Send()
+		}
+
+		// コイン履歴取消処理
+		if err := c.newInputPort(ctx).ReverseHistory(txContext(dbCtx, ctx), ctx.Param("id"), &form); err != nil {
 			log.Error().Stack().Err(err).Send()
 		}
 	}
@@ -78,7 +134,19 @@ func (c *CoinController) GetHistoryByUserId() gin.HandlerFunc {
 		uid := ctx.Param("userid")
 
 		// コイン履歴取得処理
-		if err := c.newInputPort(ctx).SelectHistoriesByUserId(uid); err != nil {
+		if err := c.newInputPort(ctx).SelectHistoriesByUserId(ctx.Request.Context(), uid); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (c *CoinController) VerifyHistoryChain() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報からユーザーIDを取得
+		uid := ctx.Param("userid")
+
+		// コイン履歴ハッシュチェーン検証処理
+		if err := c.newInputPort(ctx).VerifyHistoryChain(ctx.Request.Context(), uid); err != nil {
 			log.Error().Stack().Err(err).Send()
 		}
 	}
@@ -86,8 +154,12 @@ func (c *CoinController) GetHistoryByUserId() gin.HandlerFunc {
 
 func (c *CoinController) newInputPort(ctx *gin.Context) ports.CoinInputPort {
 	op := c.OutputFactory(ctx)
-	cr := c.CoinRepositoryFactory(c.ClientFactory.Conn)
-	ur := c.UserRepositoryFactory(c.ClientFactory.Conn)
-	tr := c.TxRepositoryFactory(c.ClientFactory.Conn)
-	return c.InputFactory(op, cr, ur, tr)
+	conn := readConn(c.ClientFactory, ctx)
+	cr := c.CoinRepositoryFactory(conn)
+	ur := c.UserRepositoryFactory(conn)
+	tfr := c.TransferRepositoryFactory(conn)
+	obr := c.OutboxRepositoryFactory(conn)
+	nr := c.NotificationRepositoryFactory(conn)
+	tr := c.TxRepositoryFactory(tenantConn(c.ClientFactory.Conn, ctx))
+	return c.InputFactory(op, cr, ur, tfr, obr, nr, tr)
 }
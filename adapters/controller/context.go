@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"coin-api/common/apikey"
+	"coin-api/common/audit"
+	"coin-api/common/tenant"
+	"coin-api/database"
+	"context"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+// txContext DB処理用のcontextへリクエストの監査ログ、APIキーの主体、テナントを引き継ぐ
+func txContext(dbCtx context.Context, c *gin.Context) context.Context {
+	ctx := dbCtx
+	if entry := audit.FromContext(c.Request.Context()); entry != nil {
+		ctx = audit.WithEntry(ctx, entry)
+	}
+	if p := apikey.FromContext(c.Request.Context()); p != nil {
+		ctx = apikey.WithPrincipal(ctx, p)
+	}
+	if id := tenant.FromContext(c.Request.Context()); id != "" {
+		ctx = tenant.WithTenant(ctx, id)
+	}
+	return ctx
+}
+
+// tenantConn リクエストのテナントを設定したDB接続(rdbのリポジトリはDB接続のテナントで検索条件を絞り込む)
+func tenantConn(conn *gorm.DB, c *gin.Context) *gorm.DB {
+	return conn.WithContext(tenant.WithTenant(context.Background(), tenant.FromContext(c.Request.Context())))
+}
+
+// readConn リポジトリ用のDB接続(参照系のリクエストはレプリカ、それ以外はプライマリ、トランザクションは常にプライマリ)
+func readConn(connector *database.PostgreSQLConnector, c *gin.Context) *gorm.DB {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead:
+		return tenantConn(connector.Reader(database.ReadAfterFromContext(c.Request.Context())), c)
+	}
+	return tenantConn(connector.Conn, c)
+}
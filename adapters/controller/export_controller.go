@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"coin-api/common"
+	"coin-api/database"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type ExportOutputFactory func(*gin.Context) ports.ExportOutputPort
+type ExportInputFactory func(ports.ExportOutputPort, repository.ICoinRepository, repository.IUserRepository) ports.ExportInputPort
+
+type ExportController struct {
+	OutputFactory         ExportOutputFactory
+	InputFactory          ExportInputFactory
+	CoinRepositoryFactory CoinRepositoryFactory
+	UserRepositoryFactory UserRepositoryFactory
+	ClientFactory         *database.PostgreSQLConnector
+}
+
+func NewExportController(outputFactory ExportOutputFactory, inputFactory ExportInputFactory, coinRepositoryFactory CoinRepositoryFactory, userRepositoryFactory UserRepositoryFactory, clientFactory *database.PostgreSQLConnector) *ExportController {
+	return &ExportController{
+		OutputFactory:         outputFactory,
+		InputFactory:          inputFactory,
+		CoinRepositoryFactory: coinRepositoryFactory,
+		UserRepositoryFactory: userRepositoryFactory,
+		ClientFactory:         clientFactory,
+	}
+}
+
+func (e *ExportController) ExportUserHistories() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報(クエリ)をformにマッピング
+		var form model.ExportForm
+		if err := ctx.ShouldBindQuery(&form); err != nil {
+			log.Log().Msg(fmt.Sprintf("バインドエラー ExportForm : %s", common.CreateJsonString(&form)))
+			log.Error().Err(err).Send()
+		}
+
+		// ユーザーのコイン履歴エクスポート(クライアント切断時は中断)
+		if err := e.newInputPort(ctx).ExportUserHistories(ctx.Request.Context(), ctx.Param("userid"), &form); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (e *ExportController) ExportAllHistories() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報(クエリ)をformにマッピング
+		var form model.ExportForm
+		if err := ctx.ShouldBindQuery(&form); err != nil {
+			log.Log().Msg(fmt.Sprintf("バインドエラー ExportForm : %s", common.CreateJsonString(&form)))
+			log.Error().Err(err).Send()
+		}
+
+		// 全ユーザーのコイン履歴エクスポート(クライアント切断時は中断)
+		if err := e.newInputPort(ctx).ExportAllHistories(ctx.Request.Context(), &form); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (e *ExportController) newInputPort(ctx *gin.Context) ports.ExportInputPort {
+	op := e.OutputFactory(ctx)
+	conn := readConn(e.ClientFactory, ctx)
+	cr := e.CoinRepositoryFactory(conn)
+	ur := e.UserRepositoryFactory(conn)
+	return e.InputFactory(op, cr, ur)
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"coin-api/common"
+	"coin-api/database"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type ScheduleOutputFactory func(*gin.Context) ports.ScheduleOutputPort
+type ScheduleInputFactory func(ports.ScheduleOutputPort, repository.IScheduleRepository, repository.IUserRepository, repository.IOutboxRepository, repository.ITxRepository) ports.ScheduleInputPort
+type ScheduleRepositoryFactory func(*gorm.DB) repository.IScheduleRepository
+
+type ScheduleController struct {
+	OutputFactory             ScheduleOutputFactory
+	InputFactory              ScheduleInputFactory
+	ScheduleRepositoryFactory ScheduleRepositoryFactory
+	UserRepositoryFactory     UserRepositoryFactory
+	OutboxRepositoryFactory   OutboxRepositoryFactory
+	TxRepositoryFactory       TxRepositoryFactory
+	ClientFactory             *database.PostgreSQLConnector
+}
+
+func NewScheduleController(outputFactory ScheduleOutputFactory, inputFactory ScheduleInputFactory, scheduleRepositoryFactory ScheduleRepositoryFactory, userRepositoryFactory UserRepositoryFactory, outboxRepositoryFactory OutboxRepositoryFactory, txRepositoryFactory TxRepositoryFactory, clientFactory *database.PostgreSQLConnector) *ScheduleController {
+	return &ScheduleController{
+		OutputFactory:             outputFactory,
+		InputFactory:              inputFactory,
+		ScheduleRepositoryFactory: scheduleRepositoryFactory,
+		UserRepositoryFactory:     userRepositoryFactory,
+		OutboxRepositoryFactory:   outboxRepositoryFactory,
+		TxRepositoryFactory:       txRepositoryFactory,
+		ClientFactory:             clientFactory,
+	}
+}
+
+func (s *ScheduleController) CreateSchedule() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報をformにマッピング
+		var form model.ScheduleAddForm
+		if err := ctx.ShouldBind(&form); err != nil {
+			log.Log().Msg(fmt.Sprintf("バインドエラー ScheduleAddForm : %s", common.CreateJsonString(&form)))
+			log.Error().Err(err).Send()
+		}
+
+		// スケジュール登録処理
+		if err := s.newInputPort(ctx).CreateSchedule(ctx.Request.Context(), &form); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (s *ScheduleController) GetSchedulesByUserId() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報からユーザーIDを取得
+		uid := ctx.Query("userid")
+
+		// スケジュール一覧取得処理
+		if err := s.newInputPort(ctx).SelectSchedulesByUserId(ctx.Request.Context(), uid); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (s *ScheduleController) GetExecutionsByScheduleId() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報からスケジュールIDを取得
+		id := ctx.Param("id")
+
+		// スケジュール実行履歴取得処理
+		if err := s.newInputPort(ctx).SelectExecutionsByScheduleId(id); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (s *ScheduleController) CancelSchedule() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報からスケジュールID、ユーザーIDを取得
+		id := ctx.Param("id")
+		uid := ctx.Query("userid")
+
+		// スケジュール取消処理
+		if err := s.newInputPort(ctx).CancelSchedule(ctx.Request.Context(), id, uid); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (s *ScheduleController) newInputPort(ctx *gin.Context) ports.ScheduleInputPort {
+	op := s.OutputFactory(ctx)
+	conn := readConn(s.ClientFactory, ctx)
+	sr := s.ScheduleRepositoryFactory(conn)
+	ur := s.UserRepositoryFactory(conn)
+	obr := s.OutboxRepositoryFactory(tenantConn(s.ClientFactory.Conn, ctx))
+	tr := s.TxRepositoryFactory(tenantConn(s.ClientFactory.Conn, ctx))
+	return s.InputFactory(op, sr, ur, obr, tr)
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"coin-api/common"
+	"coin-api/database"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type SnapshotOutputFactory func(*gin.Context) ports.SnapshotOutputPort
+type SnapshotInputFactory func(ports.SnapshotOutputPort, repository.ISnapshotRepository) ports.SnapshotInputPort
+type SnapshotRepositoryFactory func(*gorm.DB) repository.ISnapshotRepository
+
+type SnapshotController struct {
+	OutputFactory             SnapshotOutputFactory
+	InputFactory              SnapshotInputFactory
+	SnapshotRepositoryFactory SnapshotRepositoryFactory
+	ClientFactory             *database.PostgreSQLConnector
+}
+
+func NewSnapshotController(outputFactory SnapshotOutputFactory, inputFactory SnapshotInputFactory, snapshotRepositoryFactory SnapshotRepositoryFactory, clientFactory *database.PostgreSQLConnector) *SnapshotController {
+	return &SnapshotController{
+		OutputFactory:             outputFactory,
+		InputFactory:              inputFactory,
+		SnapshotRepositoryFactory: snapshotRepositoryFactory,
+		ClientFactory:             clientFactory,
+	}
+}
+
+func (s *SnapshotController) VerifySnapshots() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報(クエリ)をformにマッピング
+		var form model.SnapshotVerifyForm
+		if err := ctx.ShouldBindQuery(&form); err != nil {
+			log.Log().Msg(fmt.Sprintf("バインドエラー SnapshotVerifyForm : %s", common.CreateJsonString(&form)))
+			log.Error().Err(err).Send()
+		}
+
+		// スナップショット検証処理
+		if err := s.newInputPort(ctx).VerifySnapshots(&form); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (s *SnapshotController) newInputPort(ctx *gin.Context) ports.SnapshotInputPort {
+	op := s.OutputFactory(ctx)
+	conn := readConn(s.ClientFactory, ctx)
+	sr := s.SnapshotRepositoryFactory(conn)
+	return s.InputFactory(op, sr)
+}
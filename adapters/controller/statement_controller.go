@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"coin-api/common"
+	"coin-api/database"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type StatementOutputFactory func(*gin.Context) ports.StatementOutputPort
+type StatementInputFactory func(ports.StatementOutputPort, repository.ICoinRepository, repository.IUserRepository) ports.StatementInputPort
+
+type StatementController struct {
+	OutputFactory         StatementOutputFactory
+	InputFactory          StatementInputFactory
+	CoinRepositoryFactory CoinRepositoryFactory
+	UserRepositoryFactory UserRepositoryFactory
+	ClientFactory         *database.PostgreSQLConnector
+}
+
+func NewStatementController(outputFactory StatementOutputFactory, inputFactory StatementInputFactory, coinRepositoryFactory CoinRepositoryFactory, userRepositoryFactory UserRepositoryFactory, clientFactory *database.PostgreSQLConnector) *StatementController {
+	return &StatementController{
+		OutputFactory:         outputFactory,
+		InputFactory:          inputFactory,
+		CoinRepositoryFactory: coinRepositoryFactory,
+		UserRepositoryFactory: userRepositoryFactory,
+		ClientFactory:         clientFactory,
+	}
+}
+
+func (s *StatementController) GetBalanceAt() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報(クエリ)をformにマッピング
+		var form model.BalanceAtForm
+		if err := ctx.ShouldBindQuery(&form); err != nil {
+			log.Log().Msg(fmt.Sprintf("バインドエラー BalanceAtForm : %s", common.CreateJsonString(&form)))
+			log.Error().Err(err).Send()
+		}
+
+		// 時点残高取得処理
+		if err := s.newInputPort(ctx).GetBalanceAt(ctx.Request.Context(), ctx.Param("userid"), &form); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (s *StatementController) GetStatement() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報(クエリ)をformにマッピング
+		var form model.StatementForm
+		if err := ctx.ShouldBindQuery(&form); err != nil {
+			log.Log().Msg(fmt.Sprintf("バインドエラー StatementForm : %s", common.CreateJsonString(&form)))
+			log.Error().Err(err).Send()
+		}
+
+		// 取引明細取得処理
+		if err := s.newInputPort(ctx).GetStatement(ctx.Request.Context(), ctx.Param("userid"), &form); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (s *StatementController) newInputPort(ctx *gin.Context) ports.StatementInputPort {
+	op := s.OutputFactory(ctx)
+	conn := readConn(s.ClientFactory, ctx)
+	cr := s.CoinRepositoryFactory(conn)
+	ur := s.UserRepositoryFactory(conn)
+	return s.InputFactory(op, cr, ur)
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"coin-api/common"
+	"coin-api/database"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type StatsOutputFactory func(*gin.Context) ports.StatsOutputPort
+type StatsInputFactory func(ports.StatsOutputPort, repository.IStatsRepository) ports.StatsInputPort
+type StatsRepositoryFactory func(*gorm.DB) repository.IStatsRepository
+
+type StatsController struct {
+	OutputFactory          StatsOutputFactory
+	InputFactory           StatsInputFactory
+	StatsRepositoryFactory StatsRepositoryFactory
+	ClientFactory          *database.PostgreSQLConnector
+}
+
+func NewStatsController(outputFactory StatsOutputFactory, inputFactory StatsInputFactory, statsRepositoryFactory StatsRepositoryFactory, clientFactory *database.PostgreSQLConnector) *StatsController {
+	return &StatsController{
+		OutputFactory:          outputFactory,
+		InputFactory:           inputFactory,
+		StatsRepositoryFactory: statsRepositoryFactory,
+		ClientFactory:          clientFactory,
+	}
+}
+
+func (s *StatsController) GetCirculation() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// 流通量取得処理
+		if err := s.newInputPort(ctx).GetCirculation(ctx.Request.Context()); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (s *StatsController) GetSupply() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報(クエリ)をformにマッピング
+		form := s.bindStatsForm(ctx)
+
+		// 発行量・消費量取得処理
+		if err := s.newInputPort(ctx).GetSupply(ctx.Request.Context(), form); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (s *StatsController) GetTransferVolume() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報(クエリ)をformにマッピング
+		form := s.bindStatsForm(ctx)
+
+		// 送金量取得処理
+		if err := s.newInputPort(ctx).GetTransferVolume(ctx.Request.Context(), form); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (s *StatsController) GetActiveUsers() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報(クエリ)をformにマッピング
+		form := s.bindStatsForm(ctx)
+
+		// アクティブユーザー数取得処理
+		if err := s.newInputPort(ctx).GetActiveUsers(ctx.Request.Context(), form); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (s *StatsController) GetTopUsers() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報(クエリ)をformにマッピング
+		var form model.TopUsersForm
+		if err := ctx.ShouldBindQuery(&form); err != nil {
+			log.Log().Msg(fmt.Sprintf("バインドエラー TopUsersForm : %s", common.CreateJsonString(&form)))
+			log.Error().Err(err).Send()
+		}
+
+		// 送金・受取ランキング取得処理
+		if err := s.newInputPort(ctx).GetTopUsers(ctx.Request.Context(), &form); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (s *StatsController) bindStatsForm(ctx *gin.Context) *model.StatsForm {
+	var form model.StatsForm
+	if err := ctx.ShouldBindQuery(&form); err != nil {
+		log.Log().Msg(fmt.Sprintf("バインドエラー StatsForm : %s", common.CreateJsonString(&form)))
+		log.Error().Err(err).Send()
+	}
+	return &form
+}
+
+func (s *StatsController) newInputPort(ctx *gin.Context) ports.StatsInputPort {
+	op := s.OutputFactory(ctx)
+	conn := readConn(s.ClientFactory, ctx)
+	sr := s.StatsRepositoryFactory(conn)
+	return s.InputFactory(op, sr)
+}
@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"coin-api/usecase/port"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type TenantOutputFactory func(*gin.Context) ports.TenantOutputPort
+type TenantInputFactory func(ports.TenantOutputPort) ports.TenantInputPort
+
+type TenantController struct {
+	OutputFactory TenantOutputFactory
+	InputFactory  TenantInputFactory
+}
+
+func NewTenantController(outputFactory TenantOutputFactory, inputFactory TenantInputFactory) *TenantController {
+	return &TenantController{
+		OutputFactory: outputFactory,
+		InputFactory:  inputFactory,
+	}
+}
+
+func (t *TenantController) GetTenant() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// リクエストのテナントの設定取得処理
+		if err := t.newInputPort(ctx).GetTenant(ctx.Request.Context()); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (t *TenantController) newInputPort(c *gin.Context) ports.TenantInputPort {
+	op := t.OutputFactory(c)
+	return t.InputFactory(op)
+}
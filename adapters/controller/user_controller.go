@@ -6,6 +6,7 @@ import (
 	"coin-api/domain/repository"
 	"coin-api/usecase/model"
 	"coin-api/usecase/port"
+	"context"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
@@ -13,26 +14,38 @@ import (
 )
 
 type UserOutputFactory func(*gin.Context) ports.UserOutputPort
-type UserInputFactory func(ports.UserOutputPort, repository.IUserRepository) ports.UserInputPort
+type UserInputFactory func(ports.UserOutputPort, repository.IUserRepository, repository.IOutboxRepository, repository.ITxRepository, repository.IPasswordResetRepository, ports.BalanceSubscriber, ports.PasswordResetNotifier) ports.UserInputPort
 type UserRepositoryFactory func(*gorm.DB) repository.IUserRepository
+type OutboxRepositoryFactory func(*gorm.DB) repository.IOutboxRepository
+type PasswordResetRepositoryFactory func(*gorm.DB) repository.IPasswordResetRepository
 
 type UserController struct {
-	OutputFactory         UserOutputFactory
-	InputFactory          UserInputFactory
-	UserRepositoryFactory UserRepositoryFactory
-	ClientFactory         *database.PostgreSQLConnector
+	OutputFactory                  UserOutputFactory
+	InputFactory                   UserInputFactory
+	UserRepositoryFactory          UserRepositoryFactory
+	OutboxRepositoryFactory        OutboxRepositoryFactory
+	TxRepositoryFactory            TxRepositoryFactory
+	PasswordResetRepositoryFactory PasswordResetRepositoryFactory
+	BalanceSubscriber              ports.BalanceSubscriber
+	PasswordResetNotifier          ports.PasswordResetNotifier
+	ClientFactory                  *database.PostgreSQLConnector
 }
 
-func NewUserController(outputFactory UserOutputFactory, inputFactory UserInputFactory, userRepositoryFactory UserRepositoryFactory, clientFactory *database.PostgreSQLConnector) *UserController {
+func NewUserController(outputFactory UserOutputFactory, inputFactory UserInputFactory, userRepositoryFactory UserRepositoryFactory, outboxRepositoryFactory OutboxRepositoryFactory, txRepositoryFactory TxRepositoryFactory, passwordResetRepositoryFactory PasswordResetRepositoryFactory, balanceSubscriber ports.BalanceSubscriber, passwordResetNotifier ports.PasswordResetNotifier, clientFactory *database.PostgreSQLConnector) *UserController {
 	return &UserController{
-		OutputFactory:         outputFactory,
-		InputFactory:          inputFactory,
-		UserRepositoryFactory: userRepositoryFactory,
-		ClientFactory:         clientFactory,
+		OutputFactory:                  outputFactory,
+		InputFactory:                   inputFactory,
+		UserRepositoryFactory:          userRepositoryFactory,
+		OutboxRepositoryFactory:        outboxRepositoryFactory,
+		TxRepositoryFactory:            txRepositoryFactory,
+		PasswordResetRepositoryFactory: passwordResetRepositoryFactory,
+		BalanceSubscriber:              balanceSubscriber,
+		PasswordResetNotifier:          passwordResetNotifier,
+		ClientFactory:                  clientFactory,
 	}
 }
 
-func (u *UserController) CreateUser() gin.HandlerFunc {
+func (u *UserController) CreateUser(dbCtx context.Context) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// request情報をformにマッピング
 		var form model.UserAddForm
@@ -44,7 +57,7 @@ func (u *UserController) CreateUser() gin.HandlerFunc {
 		}
 
 		// ユーザー登録処理実行
-		if err := u.newInputPort(c).RegisterUser(&form); err != nil {
+		if err := u.newInputPort(c).RegisterUser(txContext(dbCtx, c), &form); err != nil {
 			log.Error().Stack().Err(err).Send()
 		}
 	}
@@ -62,8 +75,115 @@ func (u *UserController) GetBalanceById() gin.HandlerFunc {
 	}
 }
 
+func (u *UserController) LookupUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// request情報をformにマッピング
+		var form model.UserLookupForm
+
+		if err := c.ShouldBindQuery(&form); err != nil {
+			// エラーの場合、ログを出力
+			log.Log().Msg(fmt.Sprintf("バインドエラー UserLookupForm : %s", common.CreateJsonString(&form)))
+			log.Error().Stack().Err(err).Send()
+		}
+
+		// ユーザー検索処理実行
+		if err := u.newInputPort(c).LookupUser(&form); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (u *UserController) UpdateUser(dbCtx context.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// request情報からユーザーIDを取得し、formにマッピング
+		uid := c.Param("userid")
+		var form model.UserUpdateForm
+
+		if err := c.ShouldBind(&form); err != nil {
+			// エラーの場合、ログを出力(パスワードを含むためユーザーIDのみ)
+			log.Log().Msg(fmt.Sprintf("バインドエラー UserUpdateForm ユーザーID : %s", uid))
+			log.Error().Stack().Err(err).Send()
+		}
+
+		// ユーザー名・パスワード変更処理実行
+		if err := u.newInputPort(c).UpdateUser(txContext(dbCtx, c), uid, &form); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (u *UserController) Login(dbCtx context.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// request情報をformにマッピング
+		var form model.UserLoginForm
+
+		if err := c.ShouldBind(&form); err != nil {
+			// エラーの場合、ログを出力(パスワードを含むためユーザー名のみ)
+			log.Log().Msg(fmt.Sprintf("バインドエラー UserLoginForm ユーザー名 : %s", form.UserName))
+			log.Error().Stack().Err(err).Send()
+		}
+
+		// ログイン処理実行
+		if err := u.newInputPort(c).Login(txContext(dbCtx, c), &form); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (u *UserController) RequestPasswordReset(dbCtx context.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// request情報をformにマッピング
+		var form model.PasswordResetRequestForm
+
+		if err := c.ShouldBind(&form); err != nil {
+			// エラーの場合、ログを出力
+			log.Log().Msg(fmt.Sprintf("バインドエラー PasswordResetRequestForm : %s", common.CreateJsonString(&form)))
+			log.Error().Stack().Err(err).Send()
+		}
+
+		// パスワード再設定トークン発行処理実行
+		if err := u.newInputPort(c).RequestPasswordReset(txContext(dbCtx, c), &form); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (u *UserController) ResetPassword(dbCtx context.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// request情報をformにマッピング
+		var form model.PasswordResetConfirmForm
+
+		if err := c.ShouldBind(&form); err != nil {
+			// エラーの場合、ログを出力(トークン・パスワードは出力しない)
+			log.Log().Msg("バインドエラー PasswordResetConfirmForm")
+			log.Error().Stack().Err(err).Send()
+		}
+
+		// パスワード再設定処理実行
+		if err := u.newInputPort(c).ResetPassword(txContext(dbCtx, c), &form); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (u *UserController) StreamEvents() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// request情報からユーザーIDを取得
+		uid := c.Param("userid")
+
+		// 残高変更ストリーム配信(クライアント切断まで継続)
+		if err := u.newInputPort(c).StreamEvents(c.Request.Context(), uid); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
 func (u *UserController) newInputPort(c *gin.Context) ports.UserInputPort {
 	op := u.OutputFactory(c)
-	ur := u.UserRepositoryFactory(u.ClientFactory.Conn)
-	return u.InputFactory(op, ur)
+	conn := readConn(u.ClientFactory, c)
+	ur := u.UserRepositoryFactory(conn)
+	obr := u.OutboxRepositoryFactory(conn)
+	tr := u.TxRepositoryFactory(tenantConn(u.ClientFactory.Conn, c))
+	prr := u.PasswordResetRepositoryFactory(conn)
+	return u.InputFactory(op, ur, obr, tr, prr, u.BalanceSubscriber, u.PasswordResetNotifier)
 }
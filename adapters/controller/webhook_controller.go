@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"coin-api/common"
+	"coin-api/database"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type WebhookOutputFactory func(*gin.Context) ports.WebhookOutputPort
+type WebhookInputFactory func(ports.WebhookOutputPort, repository.IWebhookRepository, repository.IOutboxRepository, repository.ITxRepository, ports.WebhookSender) ports.WebhookInputPort
+type WebhookRepositoryFactory func(*gorm.DB) repository.IWebhookRepository
+
+type WebhookController struct {
+	OutputFactory            WebhookOutputFactory
+	InputFactory             WebhookInputFactory
+	WebhookRepositoryFactory WebhookRepositoryFactory
+	OutboxRepositoryFactory  OutboxRepositoryFactory
+	TxRepositoryFactory      TxRepositoryFactory
+	Sender                   ports.WebhookSender
+	ClientFactory            *database.PostgreSQLConnector
+}
+
+func NewWebhookController(outputFactory WebhookOutputFactory, inputFactory WebhookInputFactory, webhookRepositoryFactory WebhookRepositoryFactory, outboxRepositoryFactory OutboxRepositoryFactory, txRepositoryFactory TxRepositoryFactory, sender ports.WebhookSender, clientFactory *database.PostgreSQLConnector) *WebhookController {
+	return &WebhookController{
+		OutputFactory:            outputFactory,
+		InputFactory:             inputFactory,
+		WebhookRepositoryFactory: webhookRepositoryFactory,
+		OutboxRepositoryFactory:  outboxRepositoryFactory,
+		TxRepositoryFactory:      txRepositoryFactory,
+		Sender:                   sender,
+		ClientFactory:            clientFactory,
+	}
+}
+
+func (w *WebhookController) CreateSubscription() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報をformにマッピング
+		var form model.WebhookAddForm
+		if err := ctx.ShouldBind(&form); err != nil {
+			log.Log().Msg(fmt.Sprintf("バインドエラー WebhookAddForm : %s", common.CreateJsonString(form.EventTypes)))
+			log.Error().Err(err).Send()
+		}
+
+		// Webhook購読登録処理
+		if err := w.newInputPort(ctx).CreateSubscription(&form); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (w *WebhookController) GetSubscriptions() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Webhook購読一覧取得処理
+		if err := w.newInputPort(ctx).SelectSubscriptions(); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (w *WebhookController) DeleteSubscription() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報から購読IDを取得
+		id := ctx.Param("id")
+
+		// Webhook購読解除処理
+		if err := w.newInputPort(ctx).DeleteSubscription(id); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (w *WebhookController) GetDeliveries() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request情報から購読IDを取得
+		id := ctx.Param("id")
+
+		// Webhook配信履歴取得処理
+		if err := w.newInputPort(ctx).SelectDeliveriesBySubscriptionId(id); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func (w *WebhookController) newInputPort(ctx *gin.Context) ports.WebhookInputPort {
+	op := w.OutputFactory(ctx)
+	conn := readConn(w.ClientFactory, ctx)
+	wr := w.WebhookRepositoryFactory(conn)
+	obr := w.OutboxRepositoryFactory(conn)
+	tr := w.TxRepositoryFactory(tenantConn(w.ClientFactory.Conn, ctx))
+	return w.InputFactory(op, wr, obr, tr, w.Sender)
+}
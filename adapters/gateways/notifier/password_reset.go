@@ -0,0 +1,78 @@
+package notifier
+
+import (
+	"bytes"
+	"coin-api/adapters/gateways/webhook"
+	"coin-api/config"
+	"coin-api/usecase/port"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	headerTimestamp = "X-Coin-Timestamp"
+	headerSignature = "X-Coin-Signature"
+)
+
+// HTTPPasswordResetNotifier パスワード再設定トークンを通知先(メール送信サービス等)へPOST
+type HTTPPasswordResetNotifier struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+// NewPasswordResetNotifier 通知先の設定に応じた通知処理(URLが空の場合は送信しない)
+func NewPasswordResetNotifier(conf *config.PasswordResetNotifyInfo) ports.PasswordResetNotifier {
+	if conf.URL == "" {
+		return &disabledNotifier{}
+	}
+	return &HTTPPasswordResetNotifier{
+		URL:    conf.URL,
+		Secret: conf.Secret,
+		Client: &http.Client{Timeout: conf.Timeout},
+	}
+}
+
+func (n *HTTPPasswordResetNotifier) NotifyPasswordReset(ctx context.Context, notification *ports.PasswordResetNotification) error {
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	// Webhookと同じ形式で署名(署名対象は「タイムスタンプ.ペイロード」)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(headerTimestamp, timestamp)
+	req.Header.Set(headerSignature, "sha256="+webhook.Sign(n.Secret, timestamp, payload))
+
+	res, err := n.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	// 2xx以外は失敗扱い
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("パスワード再設定の通知先がステータス%dを返却", res.StatusCode)
+	}
+	return nil
+}
+
+// disabledNotifier 通知先未設定の場合の通知処理(トークンはログにも出力しない)
+type disabledNotifier struct{}
+
+func (n *disabledNotifier) NotifyPasswordReset(ctx context.Context, notification *ports.PasswordResetNotification) error {
+	log.Warn().Msg(fmt.Sprintf("パスワード再設定の通知先が未設定のため通知しません ユーザーID : %d", notification.UserId))
+	return nil
+}
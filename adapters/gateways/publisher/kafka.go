@@ -0,0 +1,37 @@
+package publisher
+
+import (
+	"coin-api/usecase/port"
+	"context"
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaPublisher 単一topicへユーザーIDをキーとして発行(同一ユーザーのイベント順序を保証)
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaPublisher(brokers []string, topic string) ports.EventPublisher {
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			// リレーは1件ずつ同期で発行するため、バッチの蓄積(BatchTimeout)を待たずに送信
+			BatchSize: 1,
+		},
+	}
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, msg *ports.EventMessage) error {
+	return p.writer.WriteMessages(ctx, kafka.Message{
+		Key:     []byte(msg.Key),
+		Value:   msg.Body,
+		Headers: []kafka.Header{{Key: "event-name", Value: []byte(msg.Name)}},
+	})
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
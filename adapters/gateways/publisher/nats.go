@@ -0,0 +1,49 @@
+package publisher
+
+import (
+	"coin-api/usecase/port"
+	"context"
+	"github.com/nats-io/nats.go"
+	"time"
+)
+
+// natsFlushTimeout 期限のないcontextで発行した場合のサーバーへの到達確認の待ち時間
+const natsFlushTimeout = 5 * time.Second
+
+// NatsPublisher イベント名ごとのsubject(prefix.イベント名)へ発行
+type NatsPublisher struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+func NewNatsPublisher(url string, prefix string) (ports.EventPublisher, error) {
+	conn, err := nats.Connect(url, nats.Name("coin-api"))
+	if err != nil {
+		return nil, err
+	}
+	return &NatsPublisher{
+		conn:   conn,
+		prefix: prefix,
+	}, nil
+}
+
+func (p *NatsPublisher) Publish(ctx context.Context, msg *ports.EventMessage) error {
+	m := nats.NewMsg(p.prefix + "." + msg.Name)
+	m.Header.Set("Event-Key", msg.Key)
+	m.Data = msg.Body
+	if err := p.conn.PublishMsg(m); err != nil {
+		return err
+	}
+
+	// サーバーへの到達を確認してから発行済みとする(FlushWithContextは期限のないcontextではエラーとなるため待ち時間を設定)
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, natsFlushTimeout)
+		defer cancel()
+	}
+	return p.conn.FlushWithContext(ctx)
+}
+
+func (p *NatsPublisher) Close() error {
+	return p.conn.Drain()
+}
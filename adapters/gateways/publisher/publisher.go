@@ -0,0 +1,25 @@
+package publisher
+
+import (
+	"coin-api/config"
+	"coin-api/usecase/port"
+	"fmt"
+)
+
+// NewEventPublisher 設定に応じた発行先を生成(発行しない設定の場合はnil)
+func NewEventPublisher(conf *config.EventRelayInfo) (ports.EventPublisher, error) {
+	switch conf.Publisher {
+	case "":
+		return nil, nil
+	case "stdout":
+		return NewStdoutPublisher(), nil
+	case "file":
+		return NewFilePublisher(conf.FilePath)
+	case "nats":
+		return NewNatsPublisher(conf.NatsURL, conf.SubjectPrefix)
+	case "kafka":
+		return NewKafkaPublisher(conf.KafkaBrokers, conf.KafkaTopic), nil
+	default:
+		return nil, fmt.Errorf("未定義のイベント発行先です : %s", conf.Publisher)
+	}
+}
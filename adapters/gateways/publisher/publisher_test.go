@@ -0,0 +1,235 @@
+package publisher
+
+import (
+	"bytes"
+	"coin-api/usecase/port"
+	"context"
+	"errors"
+	"github.com/nats-io/nats-server/v2/server"
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	metadataAPI "github.com/segmentio/kafka-go/protocol/metadata"
+	produceAPI "github.com/segmentio/kafka-go/protocol/produce"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testMessages = []*ports.EventMessage{
+	{Name: "CoinAdded", Key: "1", Body: []byte(`{"event_id":1}`)},
+	{Name: "CoinTransferred", Key: "2", Body: []byte(`{"event_id":2}`)},
+}
+
+func TestWriterPublisher(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewWriterPublisher(&buf)
+	for _, msg := range testMessages {
+		if err := p.Publish(context.Background(), msg); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// 1行1JSON(JSONL)で発行順に書き込み
+	want := "{\"event_id\":1}\n{\"event_id\":2}\n"
+	if buf.String() != want {
+		t.Errorf("written = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestNatsPublisher(t *testing.T) {
+	opts := natsserver.DefaultTestOptions
+	opts.Port = server.RANDOM_PORT
+	s := natsserver.RunServer(&opts)
+	defer s.Shutdown()
+
+	// 発行前に購読
+	sub, err := nats.Connect(s.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	received := make(chan *nats.Msg, len(testMessages))
+	if _, err := sub.ChanSubscribe("coin.>", received); err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewNatsPublisher(s.ClientURL(), "coin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range testMessages {
+		if err := p.Publish(context.Background(), msg); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	// イベント名ごとのsubjectへキーをヘッダーに付与して発行順に到達
+	for _, want := range testMessages {
+		select {
+		case m := <-received:
+			if m.Subject != "coin."+want.Name || m.Header.Get("Event-Key") != want.Key || string(m.Data) != string(want.Body) {
+				t.Errorf("received subject = %s, key = %s, data = %s, want %s", m.Subject, m.Header.Get("Event-Key"), m.Data, want.Name)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("message %s was not received", want.Name)
+		}
+	}
+	if err := p.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
+
+func TestNatsPublisherFailsWhenServerIsDown(t *testing.T) {
+	opts := natsserver.DefaultTestOptions
+	opts.Port = server.RANDOM_PORT
+	s := natsserver.RunServer(&opts)
+
+	p, err := NewNatsPublisher(s.ClientURL(), "coin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Shutdown()
+
+	// 到達を確認できない場合は発行済みとしない
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.Publish(ctx, testMessages[0]); err == nil {
+		t.Error("Publish() error = nil, want error after server shutdown")
+	}
+}
+
+// fakeKafkaRecord ブローカーが受信したレコード
+type fakeKafkaRecord struct {
+	topic     string
+	partition int32
+	key       string
+	value     string
+	headers   map[string]string
+}
+
+// fakeKafkaBroker メタデータと発行要求に応答するインメモリのブローカー
+type fakeKafkaBroker struct {
+	partitions int
+	failWith   int16
+
+	mu      sync.Mutex
+	records []fakeKafkaRecord
+}
+
+func (b *fakeKafkaBroker) RoundTrip(ctx context.Context, addr net.Addr, req protocol.Message) (protocol.Message, error) {
+	switch r := req.(type) {
+	case *metadataAPI.Request:
+		res := &metadataAPI.Response{Brokers: []metadataAPI.ResponseBroker{{NodeID: 1, Host: "localhost", Port: 9092}}}
+		for _, name := range r.TopicNames {
+			topic := metadataAPI.ResponseTopic{Name: name}
+			for i := 0; i < b.partitions; i++ {
+				topic.Partitions = append(topic.Partitions, metadataAPI.ResponsePartition{PartitionIndex: int32(i), LeaderID: 1})
+			}
+			res.Topics = append(res.Topics, topic)
+		}
+		return res, nil
+	case *produceAPI.Request:
+		res := &produceAPI.Response{}
+		for _, t := range r.Topics {
+			rt := produceAPI.ResponseTopic{Topic: t.Topic}
+			for _, p := range t.Partitions {
+				rt.Partitions = append(rt.Partitions, produceAPI.ResponsePartition{Partition: p.Partition, ErrorCode: b.failWith})
+				if b.failWith != 0 {
+					continue
+				}
+				if err := b.store(t.Topic, p.Partition, p.RecordSet.Records); err != nil {
+					return nil, err
+				}
+			}
+			res.Topics = append(res.Topics, rt)
+		}
+		return res, nil
+	}
+	return nil, errors.New("unsupported request")
+}
+
+func (b *fakeKafkaBroker) store(topic string, partition int32, records protocol.RecordReader) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for {
+		rec, err := records.ReadRecord()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		key, _ := protocol.ReadAll(rec.Key)
+		value, _ := protocol.ReadAll(rec.Value)
+		headers := make(map[string]string)
+		for _, h := range rec.Headers {
+			headers[h.Key] = string(h.Value)
+		}
+		b.records = append(b.records, fakeKafkaRecord{topic: topic, partition: partition, key: string(key), value: string(value), headers: headers})
+	}
+}
+
+func newFakeKafkaPublisher(broker *fakeKafkaBroker) *KafkaPublisher {
+	p := NewKafkaPublisher([]string{"localhost:9092"}, "coin-events").(*KafkaPublisher)
+	p.writer.Transport = broker
+	p.writer.MaxAttempts = 1
+	return p
+}
+
+func TestKafkaPublisher(t *testing.T) {
+	broker := &fakeKafkaBroker{partitions: 3}
+	p := newFakeKafkaPublisher(broker)
+
+	// 1件ずつ同期で発行するためバッチの蓄積を待たない
+	start := time.Now()
+	for _, msg := range append(testMessages, &ports.EventMessage{Name: "CoinUsed", Key: "1", Body: []byte(`{"event_id":3}`)}) {
+		if err := p.Publish(context.Background(), msg); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Publish() took %s, want no batch wait", elapsed)
+	}
+	if err := p.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+
+	// ユーザーIDをキーとしてイベント名をヘッダーに付与
+	if len(broker.records) != 3 {
+		t.Fatalf("records = %d, want 3", len(broker.records))
+	}
+	for i, want := range testMessages {
+		got := broker.records[i]
+		if got.topic != "coin-events" || got.key != want.Key || got.value != string(want.Body) || got.headers["event-name"] != want.Name {
+			t.Errorf("record %d = %+v, want %s", i, got, want.Name)
+		}
+	}
+
+	// 同一キーは同一パーティション(同一ユーザーのイベント順序を保証)
+	if broker.records[0].partition != broker.records[2].partition {
+		t.Errorf("partitions for key 1 = %d, %d, want same", broker.records[0].partition, broker.records[2].partition)
+	}
+}
+
+func TestKafkaPublisherReturnsBrokerError(t *testing.T) {
+	broker := &fakeKafkaBroker{partitions: 1, failWith: int16(kafka.NotEnoughReplicas)}
+	p := newFakeKafkaPublisher(broker)
+	defer p.Close()
+
+	if err := p.Publish(context.Background(), testMessages[0]); err == nil {
+		t.Error("Publish() error = nil, want broker error")
+	}
+	if len(broker.records) != 0 {
+		t.Errorf("records = %d, want 0", len(broker.records))
+	}
+}
@@ -0,0 +1,55 @@
+package publisher
+
+import (
+	"coin-api/usecase/port"
+	"context"
+	"io"
+	"os"
+	"sync"
+)
+
+// WriterPublisher イベントを1行1JSON(JSONL)で書き込む
+type WriterPublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+	c  io.Closer
+}
+
+func NewWriterPublisher(w io.Writer) ports.EventPublisher {
+	return &WriterPublisher{
+		w: w,
+	}
+}
+
+func NewStdoutPublisher() ports.EventPublisher {
+	return NewWriterPublisher(os.Stdout)
+}
+
+func NewFilePublisher(path string) (ports.EventPublisher, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &WriterPublisher{
+		w: f,
+		c: f,
+	}, nil
+}
+
+func (p *WriterPublisher) Publish(ctx context.Context, msg *ports.EventMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	line := make([]byte, 0, len(msg.Body)+1)
+	line = append(line, msg.Body...)
+	line = append(line, '\n')
+	_, err := p.w.Write(line)
+	return err
+}
+
+func (p *WriterPublisher) Close() error {
+	if p.c == nil {
+		return nil
+	}
+	return p.c.Close()
+}
@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"coin-api/config"
+	"coin-api/usecase/port"
+	"fmt"
+	"time"
+)
+
+// NewRateLimitStore 設定に応じた保存先を生成
+func NewRateLimitStore(conf *config.RateLimitInfo) (ports.RateLimitStore, error) {
+	switch conf.Store {
+	case "memory":
+		return NewMemoryStore(), nil
+	case "redis":
+		return NewRedisStore(conf.RedisAddr), nil
+	default:
+		return nil, fmt.Errorf("未定義のレート制限の保存先です : %s", conf.Store)
+	}
+}
+
+// bucketState トークンバケットの状態
+type bucketState struct {
+	tokens float64
+	last   time.Time
+}
+
+// take トークンを補充した上で1つ取得(memory,redisで同じ計算を行う)
+func take(b *bucketState, limit ports.RateLimit, now time.Time) *ports.RateLimitResult {
+	perToken := time.Minute / time.Duration(limit.Rate)
+
+	// 経過時間分を補充(容量が上限)
+	elapsed := now.Sub(b.last)
+	if elapsed > 0 {
+		b.tokens += float64(elapsed) / float64(perToken)
+		if b.tokens > float64(limit.Burst) {
+			b.tokens = float64(limit.Burst)
+		}
+	}
+	b.last = now
+
+	res := &ports.RateLimitResult{}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		// 1トークン貯まるまでの待ち時間
+		res.RetryAfter = time.Duration((1 - b.tokens) * float64(perToken))
+	}
+	res.Remaining = int(b.tokens)
+	// 満タンになるまでの時間
+	res.Reset = time.Duration((float64(limit.Burst) - b.tokens) * float64(perToken))
+	return res
+}
@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"coin-api/usecase/port"
+	"context"
+	"sync"
+	"time"
+)
+
+// idleTimeout 使用されていないバケットを破棄するまでの時間
+const idleTimeout = 10 * time.Minute
+
+// MemoryStore プロセス内のトークンバケット(単一インスタンス用)
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucketState
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucketState),
+	}
+}
+
+var _ ports.RateLimitStore = (*MemoryStore)(nil)
+
+func (m *MemoryStore) Take(_ context.Context, key string, limit ports.RateLimit, now time.Time) (*ports.RateLimitResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// 一定間隔で放置されたバケットを破棄
+	if now.Sub(m.lastSweep) > idleTimeout {
+		for k, b := range m.buckets {
+			if now.Sub(b.last) > idleTimeout {
+				delete(m.buckets, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucketState{tokens: float64(limit.Burst), last: now}
+		m.buckets[key] = b
+	}
+	return take(b, limit, now), nil
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"coin-api/usecase/port"
+	"context"
+	"github.com/go-redis/redis/v8"
+	"strconv"
+	"time"
+)
+
+// takeScript トークンバケットをRedis上で原子的に更新(KEYS[1]:キー ARGV:補充間隔ms,容量,現在時刻ms)
+var takeScript = redis.NewScript(`
+local per = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call("HMGET", KEYS[1], "tokens", "last")
+local tokens = tonumber(state[1]) or burst
+local last = tonumber(state[2]) or now
+if now > last then
+  tokens = math.min(burst, tokens + (now - last) / per)
+  last = now
+end
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "last", last)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst * per) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisStore Redis互換サーバー上のトークンバケット(複数インスタンスで共有)
+type RedisStore struct {
+	client redis.Scripter
+}
+
+func NewRedisStore(addr string) *RedisStore {
+	return &RedisStore{
+		client: redis.NewClient(&redis.Options{Addr: addr}),
+	}
+}
+
+// NewRedisStoreWithClient 生成済みのクライアントを使用
+func NewRedisStoreWithClient(client redis.Scripter) *RedisStore {
+	return &RedisStore{
+		client: client,
+	}
+}
+
+var _ ports.RateLimitStore = (*RedisStore)(nil)
+
+func (r *RedisStore) Take(ctx context.Context, key string, limit ports.RateLimit, now time.Time) (*ports.RateLimitResult, error) {
+	perToken := time.Minute / time.Duration(limit.Rate)
+	res, err := takeScript.Run(ctx, r.client, []string{key}, perToken.Milliseconds(), limit.Burst, now.UnixMilli()).Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	// 取得結果から残数・待ち時間を算出
+	allowed, _ := res[0].(int64)
+	s, _ := res[1].(string)
+	tokens, _ := strconv.ParseFloat(s, 64)
+	result := &ports.RateLimitResult{
+		Allowed:   allowed == 1,
+		Remaining: int(tokens),
+		Reset:     time.Duration((float64(limit.Burst) - tokens) * float64(perToken)),
+	}
+	if !result.Allowed {
+		result.RetryAfter = time.Duration((1 - tokens) * float64(perToken))
+	}
+	return result, nil
+}
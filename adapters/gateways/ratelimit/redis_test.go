@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"coin-api/usecase/port"
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"testing"
+	"time"
+)
+
+func newTestRedisStore(t *testing.T) *RedisStore {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisStoreWithClient(client)
+}
+
+func TestRedisStoreTake(t *testing.T) {
+	store := newTestRedisStore(t)
+	ctx := context.Background()
+	// 1分あたり60回(1秒ごとに1トークン補充)、容量2
+	limit := ports.RateLimit{Rate: 60, Burst: 2}
+	now := time.Unix(1700000000, 0)
+
+	// 容量分は連続で取得可能
+	for i, want := range []int{1, 0} {
+		res, err := store.Take(ctx, "user:1", limit, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Allowed || res.Remaining != want {
+			t.Fatalf("Take() #%d = %+v, want allowed with remaining %d", i, res, want)
+		}
+	}
+
+	// 容量を超えると拒否され、1トークン貯まるまでの待ち時間を返す
+	res, err := store.Take(ctx, "user:1", limit, now.Add(500*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Allowed || res.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("Take() over burst = %+v, want denied with retry after 500ms", res)
+	}
+
+	// 別のキーのバケットは独立
+	if res, _ := store.Take(ctx, "user:2", limit, now); !res.Allowed {
+		t.Fatalf("Take() other key = %+v, want allowed", res)
+	}
+
+	// 経過時間分が補充される
+	if res, _ := store.Take(ctx, "user:1", limit, now.Add(time.Second)); !res.Allowed {
+		t.Fatalf("Take() after refill = %+v, want allowed", res)
+	}
+}
+
+func TestRedisStoreMatchesMemoryStore(t *testing.T) {
+	redisStore := newTestRedisStore(t)
+	memoryStore := NewMemoryStore()
+	ctx := context.Background()
+	limit := ports.RateLimit{Rate: 120, Burst: 3}
+	start := time.Unix(1700000000, 0)
+
+	// memoryと同じ計算になること
+	for i, offset := range []time.Duration{0, 0, 100, 200, 300, 400, 900, 2000, 2000, 2100, 10000} {
+		now := start.Add(offset * time.Millisecond)
+		want, _ := memoryStore.Take(ctx, "key", limit, now)
+		got, err := redisStore.Take(ctx, "key", limit, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *got != *want {
+			t.Errorf("Take() #%d at +%dms = %+v, want %+v", i, offset, got, want)
+		}
+	}
+}
+
+func TestRedisStoreExpire(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	store := NewRedisStoreWithClient(client)
+
+	// 満タンになるまでの時間+1秒で破棄される
+	limit := ports.RateLimit{Rate: 60, Burst: 5}
+	if _, err := store.Take(context.Background(), "user:1", limit, time.Unix(1700000000, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := mr.TTL("user:1"); ttl != 6*time.Second {
+		t.Errorf("TTL = %v, want 6s", ttl)
+	}
+}
@@ -0,0 +1,108 @@
+package rdb
+
+import (
+	"coin-api/domain/model"
+	"coin-api/domain/repository"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"time"
+)
+
+type ApiKeyRepository struct {
+	DB *gorm.DB
+}
+
+func NewApiKeyRepository(db *gorm.DB) repository.IApiKeyRepository {
+	return &ApiKeyRepository{
+		DB: db,
+	}
+}
+
+func (ar *ApiKeyRepository) SelectById(id uint) (*model.ApiKey, error) {
+	// 取得用モデル定義
+	key := model.ApiKey{}
+
+	// id検索でのAPIキー取得処理
+	result := ar.DB.Scopes(tenantScope("tenant_id")).First(&key, "id=?", id)
+	if result.Error != nil {
+		// エラーまたはレコードを取得できない場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("APIキー取得処理でエラー発生 APIキーID : %d", id))
+		return nil, result.Error
+	}
+
+	return &key, result.Error
+}
+
+func (ar *ApiKeyRepository) SelectAll() ([]model.ApiKey, error) {
+	// 取得用モデル定義
+	var keys []model.ApiKey
+
+	// テナントの全APIキー取得
+	result := ar.DB.Scopes(tenantScope("tenant_id")).Order("id").Find(&keys)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg("APIキー一覧取得処理でエラー発生")
+		return nil, result.Error
+	}
+
+	return keys, result.Error
+}
+
+func (ar *ApiKeyRepository) SelectActiveByHash(keyHash string) (*model.ApiKey, error) {
+	// 取得用モデル定義
+	key := model.ApiKey{}
+
+	// ハッシュ検索での失効していないAPIキー取得処理(テナントはAPIキーから決まるため全テナントが対象)
+	result := ar.DB.First(&key, "key_hash=? AND revoked_at IS NULL", keyHash)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg("APIキー認証処理でエラー発生")
+		return nil, result.Error
+	}
+
+	return &key, result.Error
+}
+
+func (ar *ApiKeyRepository) Insert(key *model.ApiKey) (*model.ApiKey, error) {
+	// リクエストのテナントでAPIキー登録処理
+	if key.TenantId == "" {
+		key.TenantId = tenantOf(ar.DB)
+	}
+	result := ar.DB.Create(key)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("APIキー登録処理でエラー発生 プレフィックス : %s", key.Prefix))
+		return nil, result.Error
+	}
+
+	return key, result.Error
+}
+
+func (ar *ApiKeyRepository) Revoke(key *model.ApiKey, now time.Time) (*model.ApiKey, error) {
+	// 失効日時の更新(失効済みの場合は更新しない)
+	result := ar.DB.Model(key).Scopes(tenantScope("tenant_id")).Where("revoked_at IS NULL").Update("revoked_at", now)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("APIキー失効処理でエラー発生 APIキーID : %d", key.ID))
+		return nil, result.Error
+	}
+
+	return key, result.Error
+}
+
+func (ar *ApiKeyRepository) UpdateLastUsed(id uint, now time.Time) error {
+	// 最終利用日時の更新(updated_atは変更しない)
+	result := ar.DB.Model(&model.ApiKey{}).Where("id=?", id).UpdateColumn("last_used_at", now)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("APIキー最終利用日時更新処理でエラー発生 APIキーID : %d", id))
+		return result.Error
+	}
+
+	return result.Error
+}
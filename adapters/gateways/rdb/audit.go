@@ -0,0 +1,78 @@
+package rdb
+
+import (
+	"coin-api/common"
+	"coin-api/domain/model"
+	"coin-api/domain/repository"
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type AuditRepository struct {
+	DB *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) repository.IAuditRepository {
+	return &AuditRepository{
+		DB: db,
+	}
+}
+
+func (ar *AuditRepository) Select(filter *repository.AuditFilter) ([]model.AuditLog, error) {
+	// 取得用モデル定義
+	var entries []model.AuditLog
+
+	// 検索条件の組み立て
+	query := ar.DB.Model(&model.AuditLog{}).Scopes(tenantScope("tenant_id"))
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.Endpoint != "" {
+		query = query.Where("endpoint = ?", filter.Endpoint)
+	}
+	if filter.Result != "" {
+		query = query.Where("result = ?", filter.Result)
+	}
+	if filter.RequestId != "" {
+		query = query.Where("request_id = ?", filter.RequestId)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	// 新しい順に取得
+	result := query.Order("id DESC").Limit(filter.Limit).Find(&entries)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("監査ログ取得処理でエラー発生 条件 : %s", common.CreateJsonString(filter)))
+		return nil, result.Error
+	}
+
+	return entries, result.Error
+}
+
+func (ar *AuditRepository) Insert(ctx context.Context, entry *model.AuditLog) (*model.AuditLog, error) {
+	// トランザクション取得
+	tx, ok := GetTx(ctx)
+	if !ok {
+		tx = ar.DB
+	}
+
+	// 監査ログ登録処理(テナント未設定の場合はリクエストのテナント)
+	if entry.TenantId == "" {
+		entry.TenantId = tenantOf(tx)
+	}
+	result := tx.Create(entry)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("監査ログ登録処理でエラー発生 リクエストID : %s", entry.RequestId))
+		return nil, result.Error
+	}
+
+	return entry, result.Error
+}
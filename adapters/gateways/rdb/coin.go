@@ -2,12 +2,17 @@ package rdb
 
 import (
 	"coin-api/common"
+	"coin-api/common/audit"
+	"coin-api/config"
 	"coin-api/domain/model"
 	"coin-api/domain/repository"
 	"context"
 	"fmt"
 	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sort"
+	"time"
 )
 
 type CoinRepository struct {
@@ -20,12 +25,27 @@ func NewCoinRepository(db *gorm.DB) repository.ICoinRepository {
 	}
 }
 
+func (cr *CoinRepository) SelectById(id uint) (*model.CoinHistory, error) {
+	// 取得用モデル定義
+	history := model.CoinHistory{}
+
+	// id検索での履歴取得処理
+	result := cr.DB.Scopes(tenantScope("tenant_id")).First(&history, "id=?", id)
+	if result.Error != nil {
+		// エラーまたはレコードを取得できない場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("履歴取得処理でエラー発生 履歴ID : %d", id))
+		return nil, result.Error
+	}
+
+	return &history, result.Error
+}
+
 func (cr *CoinRepository) SelectHistoriesByUserId(uid uint) ([]model.CoinHistory, error) {
 	// 取得用モデル定義
 	var histories []model.CoinHistory
 
 	// idに紐づく全履歴取得
-	result := cr.DB.Find(&histories, "userid=?", uid)
+	result := cr.DB.Scopes(tenantScope("tenant_id")).Find(&histories, "userid=?", uid)
 	if result.Error != nil {
 		// エラーまたはレコードを取得できない場合、ログを出力
 		log.Error().Msg(fmt.Sprintf("履歴取得処理でエラー発生 ユーザーID : %d", uid))
@@ -35,6 +55,202 @@ func (cr *CoinRepository) SelectHistoriesByUserId(uid uint) ([]model.CoinHistory
 	return histories, result.Error
 }
 
+func (cr *CoinRepository) SelectChainByUserId(uid uint) ([]model.CoinHistory, error) {
+	// 取得用モデル定義
+	var histories []model.CoinHistory
+
+	// 論理削除済みを含む全履歴をチェーン順(id昇順)に取得
+	result := cr.DB.Unscoped().Scopes(tenantScope("tenant_id")).Order("id").Find(&histories, "userid=?", uid)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("ハッシュチェーン取得処理でエラー発生 ユーザーID : %d", uid))
+		return nil, result.Error
+	}
+
+	return histories, result.Error
+}
+
+func (cr *CoinRepository) SelectHistoryUserIds() ([]uint, error) {
+	// 取得用モデル定義
+	var uids []uint
+
+	// 履歴が存在するユーザーIDを取得
+	result := cr.DB.Unscoped().Model(&model.CoinHistory{}).Scopes(tenantScope("tenant_id")).Distinct("userid").Order("userid").Pluck("userid", &uids)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg("履歴ユーザーID取得処理でエラー発生")
+		return nil, result.Error
+	}
+
+	return uids, result.Error
+}
+
+func (cr *CoinRepository) SelectCounterpartLeg(history *model.CoinHistory, operation string) (*model.CoinHistory, error) {
+	// 取得用モデル定義
+	leg := model.CoinHistory{}
+
+	// 同時刻・逆符号の相手側履歴を取得(相手ユーザー未設定の旧履歴も対象)
+	query := cr.DB.Scopes(tenantScope("tenant_id")).Where("operation=? AND operation_timestamp=? AND amount=? AND (counterparty=? OR counterparty IS NULL)",
+		operation, history.OperationTimestamp, -history.Amount, history.UserId)
+	if history.Counterparty != nil {
+		query = query.Where("userid=?", *history.Counterparty)
+	}
+	result := query.First(&leg)
+	if result.Error != nil {
+		// エラーまたはレコードを取得できない場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("相手側履歴取得処理でエラー発生 履歴ID : %d", history.ID))
+		return nil, result.Error
+	}
+
+	return &leg, result.Error
+}
+
+func (cr *CoinRepository) ExistsReversalOf(ctx context.Context, ids ...uint) (bool, error) {
+	// トランザクション取得
+	tr, ok := GetTx(ctx)
+	if !ok {
+		tr = cr.DB
+	}
+
+	// 集計結果格納用
+	var count int64
+
+	// 対象履歴を打ち消す履歴の件数取得
+	result := tr.Model(&model.CoinHistory{}).Scopes(tenantScope("tenant_id")).Where("reversal_of IN ?", ids).Count(&count)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("取消履歴確認処理でエラー発生 履歴ID : %v", ids))
+		return false, result.Error
+	}
+
+	return count > 0, result.Error
+}
+
+func (cr *CoinRepository) SelectHistoriesByUserIdBetween(uid uint, from time.Time, to time.Time) ([]model.CoinHistory, error) {
+	// 取得用モデル定義
+	var histories []model.CoinHistory
+
+	// 期間内(両端を含む)の履歴を操作日時順に取得
+	result := cr.DB.Scopes(tenantScope("tenant_id")).Order("operation_timestamp, id").
+		Find(&histories, "userid=? AND operation_timestamp BETWEEN ? AND ?", uid, from, to)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("期間指定の履歴取得処理でエラー発生 ユーザーID : %d", uid))
+		return nil, result.Error
+	}
+
+	return histories, result.Error
+}
+
+func (cr *CoinRepository) SumAmountByUserIdUntil(uid uint, until time.Time) (int, error) {
+	// 集計結果格納用
+	var sum int
+
+	// 指定日時以前(指定日時を含む)の全区分の合計金額(=指定日時時点の残高)取得
+	// 直近の日次スナップショットがある場合は、スナップショットの残高にそれ以降の履歴のみを加算
+	// (スナップショットは履歴と同じくリクエストのテナントのユーザーのもののみ)
+	snapshot := cr.DB.Table("balance_snapshots").Select("as_of, balance").Scopes(tenantUserScope("userid")).
+		Where("userid=? AND as_of<=?", uid, until).Order("as_of DESC").Limit(1)
+	result := cr.DB.Raw(`WITH s AS (?)
+SELECT COALESCE((SELECT balance FROM s), 0) + COALESCE(SUM(amount), 0) FROM coin_histories
+WHERE userid=? AND operation_timestamp<=? AND operation_timestamp>=COALESCE((SELECT as_of FROM s), '-infinity') AND deleted_at IS NULL
+AND (?='' OR tenant_id=?)`,
+		snapshot, uid, until, tenantOf(cr.DB), tenantOf(cr.DB)).Scan(&sum)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("時点残高取得処理でエラー発生 ユーザーID : %d", uid))
+		return 0, result.Error
+	}
+
+	return sum, result.Error
+}
+
+func (cr *CoinRepository) ExportHistories(ctx context.Context, filter *repository.ExportFilter, fn func(row *model.HistoryExportRow) error) error {
+	// 取引後残高は期間外を含むユーザーごとの累計のため、ウィンドウ関数で集計後に期間で絞り込み
+	histories := cr.DB.Model(&model.CoinHistory{}).
+		Select("coin_histories.*, SUM(amount) OVER (PARTITION BY userid ORDER BY operation_timestamp, id) AS balance_after").
+		Scopes(tenantScope("tenant_id"))
+	if filter.UserId != nil {
+		histories = histories.Where("userid=?", *filter.UserId)
+	}
+	query := cr.DB.WithContext(ctx).Table("(?) AS h", histories).Order("operation_timestamp, id")
+	if filter.From != nil {
+		query = query.Where("operation_timestamp>=?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("operation_timestamp<=?", *filter.To)
+	}
+
+	// 全件をメモリに載せないよう1行ずつ読み出して処理
+	rows, err := query.Rows()
+	if err != nil {
+		log.Error().Msg(fmt.Sprintf("履歴エクスポート処理でエラー発生 条件 : %s", common.CreateJsonString(filter)))
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var row model.HistoryExportRow
+		if err := cr.DB.ScanRows(rows, &row); err != nil {
+			log.Error().Msg(fmt.Sprintf("履歴エクスポート処理でエラー発生 条件 : %s", common.CreateJsonString(filter)))
+			return err
+		}
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (cr *CoinRepository) SumAmountByUserIdSince(ctx context.Context, uid uint, since time.Time, operations ...string) (int, error) {
+	// トランザクション取得
+	tr, ok := GetTx(ctx)
+	if !ok {
+		tr = cr.DB
+	}
+
+	// 集計結果格納用
+	var sum int
+
+	// 指定日時以降の対象区分(複数可)の合計金額取得
+	result := tr.Model(&model.CoinHistory{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Scopes(tenantScope("tenant_id")).
+		Where("userid=? AND operation IN ? AND operation_timestamp>=?", uid, operations, since).
+		Scan(&sum)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("履歴合計取得処理でエラー発生 ユーザーID : %d", uid))
+		return 0, result.Error
+	}
+
+	return sum, result.Error
+}
+
+func (cr *CoinRepository) CountByUserIdSince(ctx context.Context, uid uint, since time.Time, operations ...string) (int, error) {
+	// トランザクション取得
+	tr, ok := GetTx(ctx)
+	if !ok {
+		tr = cr.DB
+	}
+
+	// 集計結果格納用
+	var count int64
+
+	// 指定日時以降の対象区分(複数可)の件数取得
+	result := tr.Model(&model.CoinHistory{}).
+		Scopes(tenantScope("tenant_id")).
+		Where("userid=? AND operation IN ? AND operation_timestamp>=?", uid, operations, since).
+		Count(&count)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("履歴件数取得処理でエラー発生 ユーザーID : %d", uid))
+		return 0, result.Error
+	}
+
+	return int(count), result.Error
+}
+
 func (cr *CoinRepository) Insert(ctx context.Context, history *model.CoinHistory) (*model.CoinHistory, error) {
 	// トランザクション取得
 	tr, ok := GetTx(ctx)
@@ -42,6 +258,15 @@ func (cr *CoinRepository) Insert(ctx context.Context, history *model.CoinHistory
 		tr = cr.DB
 	}
 
+	// 実行者設定
+	stampActor(ctx, []*model.CoinHistory{history})
+
+	// テナント・ハッシュチェーン設定
+	if err := chainHistories(tr, []*model.CoinHistory{history}); err != nil {
+		log.Error().Msg(fmt.Sprintf("ハッシュチェーン設定処理でエラー発生 ユーザーID : %d", history.UserId))
+		return nil, err
+	}
+
 	// 履歴登録処理
 	result := tr.Create(history)
 	if result.Error != nil {
@@ -60,8 +285,22 @@ func (cr *CoinRepository) BatchInsert(ctx context.Context, histories []*model.Co
 		tr = cr.DB
 	}
 
+	// 実行者設定
+	stampActor(ctx, histories)
+
+	// テナント・ハッシュチェーン設定
+	if err := chainHistories(tr, histories); err != nil {
+		log.Error().Msg(fmt.Sprintf("ハッシュチェーン設定処理でエラー発生 履歴 : %s", common.CreateJsonString(histories)))
+		return nil, err
+	}
+
 	// 履歴登録処理
 	results := tr.Create(histories)
+	if isUniqueViolation(results.Error, reversalOfIndex) {
+		// 並行する取消で同じ履歴の打消し履歴が登録済み
+		log.Log().Msg(fmt.Sprintf("取消済みの履歴の取消 履歴 : %s", common.CreateJsonString(histories)))
+		return nil, repository.ErrAlreadyReversed
+	}
 	if results.Error != nil {
 		// エラーの場合、ログを出力
 		log.Error().Msg(fmt.Sprintf("履歴一括登録処理でエラー発生 履歴 : %s", common.CreateJsonString(histories)))
@@ -70,3 +309,70 @@ func (cr *CoinRepository) BatchInsert(ctx context.Context, histories []*model.Co
 
 	return histories, results.Error
 }
+
+// stampActor リクエストの監査ログと同じ実行者を履歴に設定(ワーカーによる登録など監査ログがない場合は未設定)
+func stampActor(ctx context.Context, histories []*model.CoinHistory) {
+	entry := audit.FromContext(ctx)
+	if entry == nil {
+		return
+	}
+	for _, h := range histories {
+		if h.ActorType == "" {
+			h.ActorType = entry.ActorType
+			h.Actor = entry.Actor
+		}
+	}
+}
+
+// chainHistories ユーザーのテナントを設定し、登録順にユーザーごとの直前の履歴のハッシュを連結してハッシュを設定
+func chainHistories(tr *gorm.DB, histories []*model.CoinHistory) error {
+	// 対象ユーザーをid順に取得(ロック順を固定)
+	uids := make([]uint, 0)
+	byUser := make(map[uint][]*model.CoinHistory)
+	for _, h := range histories {
+		if _, ok := byUser[h.UserId]; !ok {
+			uids = append(uids, h.UserId)
+		}
+		byUser[h.UserId] = append(byUser[h.UserId], h)
+	}
+	sort.Slice(uids, func(i, j int) bool { return uids[i] < uids[j] })
+
+	for _, uid := range uids {
+		// 同一ユーザーの同時登録でチェーンが分岐しないようユーザー行をロックしてテナントを取得
+		// (リクエストのテナントに属さないユーザーの場合はエラー)
+		var tenantIds []string
+		if err := tr.Unscoped().Model(&model.User{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Scopes(tenantScope("tenant_id")).
+			Where("id = ?", uid).Pluck("tenant_id", &tenantIds).Error; err != nil {
+			return err
+		}
+		if len(tenantIds) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		// 直前の履歴のハッシュ取得
+		var prev []string
+		if err := tr.Unscoped().Model(&model.CoinHistory{}).Where("userid = ?", uid).Order("id DESC").Limit(1).Pluck("hash", &prev).Error; err != nil {
+			return err
+		}
+		prevHash := ""
+		if len(prev) > 0 {
+			prevHash = prev[0]
+		}
+
+		// ユーザーのテナントで扱うコインの桁数でハッシュを算出
+		precision := config.LoadConfig().CoinOf(tenantIds[0]).Precision
+		for _, h := range byUser[uid] {
+			// トランザクションの再試行時にロールバック済みのIDを再利用しない(チェーンはid順に辿るため)
+			h.ID = 0
+			h.TenantId = tenantIds[0]
+
+			// DBの精度(マイクロ秒)に合わせてからハッシュを算出
+			h.OperationTimestamp = h.OperationTimestamp.Truncate(time.Microsecond)
+			h.PrevHash = prevHash
+			h.Hash = h.ChainHash(prevHash, precision)
+			prevHash = h.Hash
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package rdb
+
+import (
+	"coin-api/common/tenant"
+	"context"
+	"database/sql"
+	"errors"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"strings"
+	"testing"
+	"time"
+)
+
+// queryPool 実行したクエリと引数を記録するConnPool(結果は返さない)
+type queryPool struct {
+	fakePool
+	query string
+	args  []interface{}
+}
+
+func (p *queryPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	p.query = query
+	p.args = args
+	return nil, errors.New("not supported")
+}
+
+func newQueryCoinRepository(t *testing.T, tenantId string) (*CoinRepository, *queryPool) {
+	t.Helper()
+	pool := &queryPool{}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: pool}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &CoinRepository{DB: db.WithContext(tenant.WithTenant(context.Background(), tenantId))}, pool
+}
+
+func TestSumAmountByUserIdUntilScopesSnapshotsByTenant(t *testing.T) {
+	until := time.Date(2023, 4, 15, 0, 0, 0, 0, time.UTC)
+
+	// テナント指定時はスナップショットもテナントのユーザーのもののみ参照
+	cr, pool := newQueryCoinRepository(t, "shop")
+	cr.SumAmountByUserIdUntil(1, until)
+	snapshot, histories, ok := strings.Cut(pool.query, "SELECT COALESCE")
+	if !ok || !strings.Contains(snapshot, "balance_snapshots") {
+		t.Fatalf("query = %s", pool.query)
+	}
+	if !strings.Contains(snapshot, "userid IN (SELECT id FROM users WHERE tenant_id = $3)") {
+		t.Errorf("snapshot subquery is not scoped by tenant : %s", snapshot)
+	}
+	if !strings.Contains(histories, "tenant_id=$") {
+		t.Errorf("histories are not scoped by tenant : %s", histories)
+	}
+	if len(pool.args) != 7 || pool.args[2] != "shop" || pool.args[6] != "shop" {
+		t.Errorf("args = %v, want tenant shop", pool.args)
+	}
+
+	// テナント未指定の場合は全テナントが対象
+	cr, pool = newQueryCoinRepository(t, "")
+	cr.SumAmountByUserIdUntil(1, until)
+	if strings.Contains(pool.query, "FROM users") {
+		t.Errorf("query without tenant = %s", pool.query)
+	}
+}
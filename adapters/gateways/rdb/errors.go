@@ -0,0 +1,43 @@
+package rdb
+
+import (
+	"coin-api/domain/repository"
+	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	// uniqueViolation 一意制約違反のSQLSTATE
+	uniqueViolation = "23505"
+	// serializationFailure 直列化失敗のSQLSTATE
+	serializationFailure = "40001"
+	// deadlockDetected デッドロック検出のSQLSTATE
+	deadlockDetected = "40P01"
+	// usernameIndex テナント内のユーザー名(大文字小文字を区別しない)の一意インデックス
+	usernameIndex = "idx_users_tenant_username_lower"
+	// reversalOfIndex 打消し対象の履歴(1履歴につき1件まで)の一意インデックス
+	reversalOfIndex = "idx_coin_histories_reversal_of"
+)
+
+// isUniqueViolation 指定した制約の一意制約違反か判定
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == constraint
+}
+
+// isRetryableTxError トランザクションの再試行で解消しうるエラー(直列化失敗・デッドロック)か判定
+func isRetryableTxError(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == serializationFailure || pgErr.Code == deadlockDetected)
+}
+
+// retryableTxError 入れ子のトランザクションで発生した直列化失敗・デッドロック(errors.Isでrepository.ErrTxRetryableと判定可能)
+type retryableTxError struct {
+	err error
+}
+
+func (e *retryableTxError) Error() string { return e.err.Error() }
+
+func (e *retryableTxError) Unwrap() error { return e.err }
+
+func (e *retryableTxError) Is(target error) bool { return target == repository.ErrTxRetryable }
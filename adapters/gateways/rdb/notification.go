@@ -0,0 +1,42 @@
+package rdb
+
+import (
+	"coin-api/common"
+	"coin-api/domain/event"
+	"coin-api/domain/repository"
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// BalanceChannel 残高変更通知のLISTEN/NOTIFYチャネル
+const BalanceChannel = "coin_balance_changed"
+
+type NotificationRepository struct {
+	DB *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) repository.INotificationRepository {
+	return &NotificationRepository{
+		DB: db,
+	}
+}
+
+func (nr *NotificationRepository) NotifyBalanceChanged(ctx context.Context, e *event.BalanceChanged) error {
+	// トランザクション取得(NOTIFYはcommit時に配信される)
+	tx, ok := GetTx(ctx)
+	if !ok {
+		tx = nr.DB
+	}
+
+	// 残高変更通知
+	result := tx.Exec("SELECT pg_notify(?, ?)", BalanceChannel, common.CreateJsonString(e))
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("残高変更通知処理でエラー発生 ユーザーID : %d", e.UserId))
+		return result.Error
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package rdb
+
+import (
+	"coin-api/common"
+	"coin-api/domain/model"
+	"coin-api/domain/repository"
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"time"
+)
+
+type OutboxRepository struct {
+	DB *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) repository.IOutboxRepository {
+	return &OutboxRepository{
+		DB: db,
+	}
+}
+
+func (or *OutboxRepository) SelectUndispatched(limit int) ([]model.OutboxEvent, error) {
+	// 取得用モデル定義
+	var events []model.OutboxEvent
+
+	// 未配信イベントを登録順に取得
+	result := or.DB.Order("id").Limit(limit).Find(&events, "dispatched_at IS NULL")
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg("未配信イベント取得処理でエラー発生")
+		return nil, result.Error
+	}
+
+	return events, result.Error
+}
+
+func (or *OutboxRepository) SelectUnpublished(limit int) ([]model.OutboxEvent, error) {
+	// 取得用モデル定義
+	var events []model.OutboxEvent
+
+	// 未発行イベントを登録順に取得
+	result := or.DB.Order("id").Limit(limit).Find(&events, "published_at IS NULL")
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg("未発行イベント取得処理でエラー発生")
+		return nil, result.Error
+	}
+
+	return events, result.Error
+}
+
+func (or *OutboxRepository) BatchInsert(ctx context.Context, events []*model.OutboxEvent) ([]*model.OutboxEvent, error) {
+	// トランザクション取得
+	tx, ok := GetTx(ctx)
+	if !ok {
+		tx = or.DB
+	}
+
+	// イベントの集約(ユーザー)のテナント設定
+	if err := stampEventTenants(tx, events); err != nil {
+		log.Error().Msg(fmt.Sprintf("イベントのテナント取得処理でエラー発生 イベント : %s", common.CreateJsonString(events)))
+		return nil, err
+	}
+
+	// トランザクションの再試行時にロールバック済みのIDを再利用しない(リレーはid順に発行するため)
+	for _, e := range events {
+		e.ID = 0
+	}
+
+	// イベント一括登録処理
+	result := tx.Create(events)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("イベント一括登録処理でエラー発生 イベント : %s", common.CreateJsonString(events)))
+		return nil, result.Error
+	}
+
+	return events, result.Error
+}
+
+func (or *OutboxRepository) MarkDispatched(ctx context.Context, event *model.OutboxEvent, dispatchedAt time.Time) (*model.OutboxEvent, error) {
+	// トランザクション取得
+	tx, ok := GetTx(ctx)
+	if !ok {
+		tx = or.DB
+	}
+
+	// 配信済み日時更新処理
+	result := tx.Model(event).Update("dispatched_at", dispatchedAt)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("イベント配信済み更新処理でエラー発生 イベントID : %d", event.ID))
+		return nil, result.Error
+	}
+
+	event.DispatchedAt = &dispatchedAt
+	return event, result.Error
+}
+
+func (or *OutboxRepository) MarkPublished(event *model.OutboxEvent, publishedAt time.Time) (*model.OutboxEvent, error) {
+	// 発行済み日時更新処理
+	result := or.DB.Model(event).Update("published_at", publishedAt)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("イベント発行済み更新処理でエラー発生 イベントID : %d", event.ID))
+		return nil, result.Error
+	}
+
+	event.PublishedAt = &publishedAt
+	return event, result.Error
+}
+
+// stampEventTenants イベントの集約(ユーザー)が属するテナントを設定
+func stampEventTenants(tx *gorm.DB, events []*model.OutboxEvent) error {
+	uids := make([]uint, 0, len(events))
+	for _, e := range events {
+		uids = append(uids, e.UserId)
+	}
+	var users []model.User
+	if err := tx.Unscoped().Select("id", "tenant_id").Find(&users, "id IN ?", uids).Error; err != nil {
+		return err
+	}
+	tenantIds := make(map[uint]string, len(users))
+	for _, u := range users {
+		tenantIds[u.ID] = u.TenantId
+	}
+	for _, e := range events {
+		if id, ok := tenantIds[e.UserId]; ok && e.TenantId == "" {
+			e.TenantId = id
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package rdb
+
+import (
+	"coin-api/domain/model"
+	"coin-api/domain/repository"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type PasswordResetRepository struct {
+	DB *gorm.DB
+}
+
+func NewPasswordResetRepository(db *gorm.DB) repository.IPasswordResetRepository {
+	return &PasswordResetRepository{
+		DB: db,
+	}
+}
+
+func (pr *PasswordResetRepository) Insert(ctx context.Context, token *model.PasswordResetToken) (*model.PasswordResetToken, error) {
+	// トランザクション取得
+	tr, ok := GetTx(ctx)
+	if !ok {
+		tr = pr.DB
+	}
+
+	// トークン登録処理
+	result := tr.Create(token)
+
+	if result.Error != nil {
+		// エラーの場合、ログを出力(トークンのハッシュは出力しない)
+		log.Error().Msg(fmt.Sprintf("パスワード再設定トークン登録処理でエラー発生 ユーザーID : %d", token.UserId))
+		return nil, result.Error
+	}
+
+	return token, result.Error
+}
+
+func (pr *PasswordResetRepository) SelectValidByHash(ctx context.Context, tokenHash string, now time.Time) (*model.PasswordResetToken, error) {
+	// トランザクション取得
+	tr, ok := GetTx(ctx)
+	if !ok {
+		tr = pr.DB
+	}
+
+	// 取得用モデル定義
+	token := model.PasswordResetToken{}
+
+	// 同一トークンの同時使用を防ぐため更新ロックして取得
+	result := tr.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Scopes(tenantUserScope("userid")).
+		First(&token, "token_hash=? AND used_at IS NULL AND expires_at>?", tokenHash, now)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg("パスワード再設定トークン取得処理でエラー発生")
+		return nil, result.Error
+	}
+
+	return &token, result.Error
+}
+
+func (pr *PasswordResetRepository) MarkUsedByUserId(ctx context.Context, uid uint, now time.Time) (int64, error) {
+	// トランザクション取得
+	tr, ok := GetTx(ctx)
+	if !ok {
+		tr = pr.DB
+	}
+
+	// 未使用のトークンをすべて使用済みに更新
+	result := tr.Model(&model.PasswordResetToken{}).
+		Scopes(tenantUserScope("userid")).
+		Where("userid=? AND used_at IS NULL", uid).
+		Update("used_at", now)
+
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("パスワード再設定トークン更新処理でエラー発生 ユーザーID : %d", uid))
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,164 @@
+package rdb
+
+import (
+	"coin-api/common"
+	"coin-api/common/enum"
+	"coin-api/domain/model"
+	"coin-api/domain/repository"
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"time"
+)
+
+type ScheduleRepository struct {
+	DB *gorm.DB
+}
+
+func NewScheduleRepository(db *gorm.DB) repository.IScheduleRepository {
+	return &ScheduleRepository{
+		DB: db,
+	}
+}
+
+func (sr *ScheduleRepository) SelectById(id uint) (*model.Schedule, error) {
+	// 取得用モデル定義
+	schedule := model.Schedule{}
+
+	// id検索でのスケジュール取得処理
+	result := sr.DB.Scopes(tenantUserScope("sender")).First(&schedule, "id=?", id)
+	if result.Error != nil {
+		// エラーまたはレコードを取得できない場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("スケジュール取得処理でエラー発生 スケジュールID : %d", id))
+		return nil, result.Error
+	}
+
+	return &schedule, result.Error
+}
+
+func (sr *ScheduleRepository) SelectBySender(uid uint) ([]model.Schedule, error) {
+	// 取得用モデル定義
+	var schedules []model.Schedule
+
+	// 送金者に紐づく全スケジュール取得
+	result := sr.DB.Scopes(tenantUserScope("sender")).Order("id").Find(&schedules, "sender=?", uid)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("スケジュール取得処理でエラー発生 ユーザーID : %d", uid))
+		return nil, result.Error
+	}
+
+	return schedules, result.Error
+}
+
+func (sr *ScheduleRepository) SelectDue(now time.Time) ([]model.Schedule, error) {
+	// 取得用モデル定義
+	var schedules []model.Schedule
+
+	// 実行予定日時を過ぎた有効なスケジュール取得
+	result := sr.DB.Scopes(tenantUserScope("sender")).Order("next_run_at").Find(&schedules, "status=? AND next_run_at<=?", string(enum.ACTIVE), now)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("実行対象スケジュール取得処理でエラー発生 基準日時 : %s", now))
+		return nil, result.Error
+	}
+
+	return schedules, result.Error
+}
+
+func (sr *ScheduleRepository) SelectExecutionsByScheduleId(id uint) ([]model.ScheduleExecution, error) {
+	// 取得用モデル定義
+	var executions []model.ScheduleExecution
+
+	// スケジュールに紐づく全実行履歴取得
+	result := sr.DB.Scopes(tenantScheduleScope).Order("id").Find(&executions, "schedule_id=?", id)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("スケジュール実行履歴取得処理でエラー発生 スケジュールID : %d", id))
+		return nil, result.Error
+	}
+
+	return executions, result.Error
+}
+
+func (sr *ScheduleRepository) Insert(schedule *model.Schedule) (*model.Schedule, error) {
+	// スケジュール登録処理
+	result := sr.DB.Create(schedule)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("スケジュール登録処理でエラー発生 スケジュール : %s", common.CreateJsonString(schedule)))
+		return nil, result.Error
+	}
+
+	return schedule, result.Error
+}
+
+func (sr *ScheduleRepository) Update(ctx context.Context, schedule *model.Schedule) (*model.Schedule, error) {
+	// トランザクション取得
+	tx, ok := GetTx(ctx)
+	if !ok {
+		tx = sr.DB
+	}
+
+	// スケジュール更新処理
+	result := tx.Scopes(tenantUserScope("sender")).Updates(schedule)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("スケジュール更新処理でエラー発生 スケジュール : %s", common.CreateJsonString(schedule)))
+		return nil, result.Error
+	}
+
+	return schedule, result.Error
+}
+
+func (sr *ScheduleRepository) ClaimNextRun(ctx context.Context, schedule *model.Schedule, next time.Time) (*model.Schedule, error) {
+	// トランザクション取得
+	tx, ok := GetTx(ctx)
+	if !ok {
+		tx = sr.DB
+	}
+
+	// 実行予定日時が取得時点から変わっていない場合のみ次回日時へ更新(多重実行防止)
+	result := tx.Model(schedule).
+		Scopes(tenantUserScope("sender")).
+		Where("next_run_at=? AND status=?", schedule.NextRunAt, string(enum.ACTIVE)).
+		Update("next_run_at", next)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("スケジュール実行日時更新処理でエラー発生 スケジュール : %s", common.CreateJsonString(schedule)))
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, repository.ErrScheduleConflict
+	}
+
+	schedule.NextRunAt = next
+	return schedule, nil
+}
+
+func (sr *ScheduleRepository) InsertExecution(ctx context.Context, execution *model.ScheduleExecution) (*model.ScheduleExecution, error) {
+	// トランザクション取得
+	tx, ok := GetTx(ctx)
+	if !ok {
+		tx = sr.DB
+	}
+
+	// 実行履歴登録処理
+	result := tx.Create(execution)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("スケジュール実行履歴登録処理でエラー発生 実行履歴 : %s", common.CreateJsonString(execution)))
+		return nil, result.Error
+	}
+
+	return execution, result.Error
+}
+
+// tenantScheduleScope スケジュールの送金者がテナントに属する実行履歴で絞り込むscope
+func tenantScheduleScope(db *gorm.DB) *gorm.DB {
+	if id := tenantOf(db); id != "" {
+		return db.Where("schedule_id IN (SELECT s.id FROM schedules s JOIN users u ON u.id = s.sender WHERE u.tenant_id = ?)", id)
+	}
+	return db
+}
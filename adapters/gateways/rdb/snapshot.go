@@ -0,0 +1,146 @@
+package rdb
+
+import (
+	"coin-api/common"
+	"coin-api/domain/model"
+	"coin-api/domain/repository"
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// snapshotStateId スナップショット状態の行ID
+const snapshotStateId = 1
+
+type SnapshotRepository struct {
+	DB *gorm.DB
+}
+
+func NewSnapshotRepository(db *gorm.DB) repository.ISnapshotRepository {
+	return &SnapshotRepository{
+		DB: db,
+	}
+}
+
+func (sr *SnapshotRepository) SelectLastAsOf() (*time.Time, error) {
+	// 取得用モデル定義
+	state := model.SnapshotState{}
+
+	// 作成済みの日付の境界取得
+	result := sr.DB.First(&state, snapshotStateId)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg("スナップショット状態取得処理でエラー発生")
+		return nil, result.Error
+	}
+
+	return state.LastAsOf, result.Error
+}
+
+func (sr *SnapshotRepository) SelectFirstHistoryTimestamp() (*time.Time, error) {
+	// 取得結果格納用
+	var first *time.Time
+
+	// 最も古い履歴の操作日時取得(履歴がない場合はnil)
+	result := sr.DB.Model(&model.CoinHistory{}).Select("MIN(operation_timestamp)").Scan(&first)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg("最古履歴取得処理でエラー発生")
+		return nil, result.Error
+	}
+
+	return first, result.Error
+}
+
+func (sr *SnapshotRepository) InsertDailySnapshots(ctx context.Context, from time.Time, asOf time.Time) (int64, error) {
+	var rows int64
+	err := sr.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 状態行をロックして多重実行を防止
+		state := model.SnapshotState{}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&state, snapshotStateId).Error; err != nil {
+			return err
+		}
+		if state.LastAsOf != nil && !state.LastAsOf.Before(asOf) {
+			return nil
+		}
+
+		// 当日に履歴のあるユーザーのみ、直前のスナップショットに当日の合計を加算して作成
+		// (日付順に作成するため、直前のスナップショット以降で当日より前の履歴は存在しない)
+		result := tx.Exec(`INSERT INTO balance_snapshots (userid, as_of, balance, created_at)
+SELECT d.userid, ?, COALESCE((SELECT s.balance FROM balance_snapshots s WHERE s.userid=d.userid AND s.as_of<? ORDER BY s.as_of DESC LIMIT 1), 0) + d.delta, now()
+FROM (SELECT userid, SUM(amount) AS delta FROM coin_histories
+      WHERE operation_timestamp>=? AND operation_timestamp<? AND deleted_at IS NULL GROUP BY userid) d
+ON CONFLICT (userid, as_of) DO NOTHING`,
+			asOf, asOf, from, asOf)
+		if result.Error != nil {
+			return result.Error
+		}
+		rows = result.RowsAffected
+
+		// 作成済みの日付の境界を更新
+		return tx.Model(&state).Updates(map[string]interface{}{"last_as_of": asOf, "updated_at": time.Now()}).Error
+	})
+	if err != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("スナップショット作成処理でエラー発生 日時 : %s", asOf.Format(time.RFC3339)))
+		return 0, err
+	}
+
+	return rows, nil
+}
+
+func (sr *SnapshotRepository) Count(filter *repository.SnapshotFilter) (int64, error) {
+	// 集計結果格納用
+	var count int64
+
+	// 条件に一致するスナップショット件数
+	result := sr.where(sr.DB.Model(&model.BalanceSnapshot{}), filter, "").Count(&count)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("スナップショット件数取得処理でエラー発生 条件 : %s", common.CreateJsonString(filter)))
+		return 0, result.Error
+	}
+
+	return count, result.Error
+}
+
+func (sr *SnapshotRepository) SelectMismatches(filter *repository.SnapshotFilter) ([]model.SnapshotMismatch, error) {
+	// 取得用モデル定義
+	var mismatches []model.SnapshotMismatch
+
+	// スナップショットごとに履歴から残高を算出し、一致しないものを取得(金額の表記のためユーザーのテナントを含む)
+	query := sr.DB.Table("balance_snapshots AS s").
+		Select("s.userid, u.tenant_id, s.as_of, s.balance, COALESCE(SUM(h.amount), 0) AS actual").
+		Joins("JOIN users u ON u.id=s.userid").
+		Joins("LEFT JOIN coin_histories h ON h.userid=s.userid AND h.operation_timestamp<s.as_of AND h.deleted_at IS NULL")
+	result := sr.where(query, filter, "s.").
+		Group("s.userid, u.tenant_id, s.as_of, s.balance").
+		Having("s.balance<>COALESCE(SUM(h.amount), 0)").
+		Order("s.userid, s.as_of").
+		Scan(&mismatches)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("スナップショット検証処理でエラー発生 条件 : %s", common.CreateJsonString(filter)))
+		return nil, result.Error
+	}
+
+	return mismatches, result.Error
+}
+
+// where 検索条件(prefixはテーブルの別名)
+func (sr *SnapshotRepository) where(query *gorm.DB, filter *repository.SnapshotFilter, prefix string) *gorm.DB {
+	query = query.Scopes(tenantUserScope(prefix + "userid"))
+	if filter.UserId != nil {
+		query = query.Where(prefix+"userid=?", *filter.UserId)
+	}
+	if filter.From != nil {
+		query = query.Where(prefix+"as_of>=?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where(prefix+"as_of<=?", *filter.To)
+	}
+	return query
+}
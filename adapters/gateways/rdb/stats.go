@@ -0,0 +1,168 @@
+package rdb
+
+import (
+	"coin-api/common"
+	"coin-api/domain/model"
+	"coin-api/domain/repository"
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// rollupStateId ロールアップ状態の行ID
+const rollupStateId = 1
+
+// statsBucket 1時間単位の集計を指定タイムゾーンの時間区切りへ丸める式(引数 : 区切り、タイムゾーン、タイムゾーン)
+const statsBucket = "date_trunc(?, s.bucket AT TIME ZONE ?) AT TIME ZONE ?"
+
+type StatsRepository struct {
+	DB *gorm.DB
+}
+
+func NewStatsRepository(db *gorm.DB) repository.IStatsRepository {
+	return &StatsRepository{
+		DB: db,
+	}
+}
+
+func (sr *StatsRepository) SelectCirculation() (*model.Circulation, error) {
+	// 集計結果格納用
+	var circulation model.Circulation
+
+	// テナントの全ユーザーの残高・保留残高の合計
+	result := sr.DB.Model(&model.User{}).
+		Scopes(tenantScope("tenant_id")).
+		Select("COUNT(*) AS users, COALESCE(SUM(coinbalance), 0) AS available, COALESCE(SUM(heldbalance), 0) AS held").
+		Scan(&circulation)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg("流通量集計処理でエラー発生")
+		return nil, result.Error
+	}
+
+	return &circulation, result.Error
+}
+
+func (sr *StatsRepository) SelectOperationBuckets(filter *repository.StatsFilter, operations ...string) ([]model.OperationBucket, error) {
+	// 集計結果格納用
+	var buckets []model.OperationBucket
+
+	// 時間区切り・区分ごとの合計金額と件数
+	query := sr.period(sr.DB.Table("(?) AS s", sr.source()), filter).
+		Select(statsBucket+" AS bucket, s.operation, SUM(s.amount) AS amount, SUM(s.count) AS count", filter.Interval, filter.TimeZone, filter.TimeZone).
+		Where("s.operation IN ?", operations)
+	result := query.Group("1, 2").Order("1, 2").Scan(&buckets)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("区分別集計処理でエラー発生 条件 : %s", common.CreateJsonString(filter)))
+		return nil, result.Error
+	}
+
+	return buckets, result.Error
+}
+
+func (sr *StatsRepository) SelectActiveUsers(filter *repository.StatsFilter) ([]model.ActiveUsersBucket, error) {
+	// 集計結果格納用
+	var buckets []model.ActiveUsersBucket
+
+	// 時間区切りごとの操作のあったユーザー数
+	query := sr.period(sr.DB.Table("(?) AS s", sr.source()), filter).
+		Select(statsBucket+" AS bucket, COUNT(DISTINCT s.userid) AS users", filter.Interval, filter.TimeZone, filter.TimeZone)
+	// Group("1")は列名として引用符で囲まれるため、位置指定のままの句で指定
+	result := query.Clauses(clause.GroupBy{Columns: []clause.Column{{Name: "1", Raw: true}}}).Order("1").Scan(&buckets)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("アクティブユーザー集計処理でエラー発生 条件 : %s", common.CreateJsonString(filter)))
+		return nil, result.Error
+	}
+
+	return buckets, result.Error
+}
+
+func (sr *StatsRepository) SelectTopUsers(filter *repository.StatsFilter, sign int, limit int, operations ...string) ([]model.UserTotal, error) {
+	// 集計結果格納用
+	var totals []model.UserTotal
+
+	// 対象区分の合計金額(signで符号を揃える)が大きい順のユーザー
+	query := sr.period(sr.DB.Table("(?) AS s", sr.source()), filter).
+		Select("s.userid, ? * SUM(s.amount) AS amount", sign).
+		Where("s.operation IN ?", operations)
+	result := query.Group("s.userid").Order("amount DESC, s.userid").Limit(limit).Scan(&totals)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("ユーザー別集計処理でエラー発生 条件 : %s", common.CreateJsonString(filter)))
+		return nil, result.Error
+	}
+
+	return totals, result.Error
+}
+
+func (sr *StatsRepository) RefreshRollups(ctx context.Context, before time.Time) (int64, error) {
+	var rows int64
+	err := sr.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 状態行をロックして多重実行を防止
+		state := model.RollupState{}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&state, rollupStateId).Error; err != nil {
+			return err
+		}
+
+		// 指定日時より前に作成された履歴までを対象とする(コミット前の履歴の取りこぼし防止)
+		var upper uint
+		if err := tx.Model(&model.CoinHistory{}).Unscoped().
+			Select("COALESCE(MAX(id), 0)").
+			Where("id>? AND created_at<?", state.LastHistoryId, before).
+			Scan(&upper).Error; err != nil {
+			return err
+		}
+		if upper <= state.LastHistoryId {
+			return nil
+		}
+
+		// 未集計の履歴を1時間単位で加算
+		result := tx.Exec(`INSERT INTO coin_history_rollups (bucket, userid, operation, amount, count)
+SELECT date_trunc('hour', operation_timestamp), userid, operation, SUM(amount), COUNT(*)
+FROM coin_histories WHERE id>? AND id<=? AND deleted_at IS NULL GROUP BY 1, 2, 3
+ON CONFLICT (bucket, userid, operation) DO UPDATE
+SET amount = coin_history_rollups.amount + EXCLUDED.amount, count = coin_history_rollups.count + EXCLUDED.count`,
+			state.LastHistoryId, upper)
+		if result.Error != nil {
+			return result.Error
+		}
+		rows = result.RowsAffected
+
+		// 集計済みの位置を更新
+		return tx.Model(&state).Updates(map[string]interface{}{"last_history_id": upper, "updated_at": time.Now()}).Error
+	})
+	if err != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg("ロールアップ更新処理でエラー発生")
+		return 0, err
+	}
+
+	return rows, nil
+}
+
+// source ロールアップ済みの集計と未集計の履歴を合わせた1時間単位の集計
+func (sr *StatsRepository) source() *gorm.DB {
+	return sr.DB.Raw(`SELECT bucket, userid, operation, amount, count FROM coin_history_rollups
+UNION ALL
+SELECT date_trunc('hour', operation_timestamp), userid, operation, SUM(amount), COUNT(*)
+FROM coin_histories
+WHERE id>(SELECT COALESCE(MAX(last_history_id), 0) FROM rollup_states) AND deleted_at IS NULL
+GROUP BY 1, 2, 3`)
+}
+
+// period テナントと期間の条件(1時間単位の区切りの開始日時で判定)
+func (sr *StatsRepository) period(query *gorm.DB, filter *repository.StatsFilter) *gorm.DB {
+	query = query.Scopes(tenantUserScope("s.userid"))
+	if filter.From != nil {
+		query = query.Where("s.bucket>=?", filter.From.Truncate(time.Hour))
+	}
+	if filter.To != nil {
+		query = query.Where("s.bucket<=?", *filter.To)
+	}
+	return query
+}
@@ -0,0 +1,31 @@
+package rdb
+
+import (
+	"coin-api/common/tenant"
+	"gorm.io/gorm"
+)
+
+// tenantOf DBのcontextに設定されたリクエストのテナントID(ワーカーなどテナント未指定の場合は空文字)
+func tenantOf(db *gorm.DB) string {
+	return tenant.FromContext(db.Statement.Context)
+}
+
+// tenantScope テナントID列で絞り込むscope(テナント未指定の場合は全テナントが対象)
+func tenantScope(column string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if id := tenantOf(db); id != "" {
+			return db.Where(column+" = ?", id)
+		}
+		return db
+	}
+}
+
+// tenantUserScope ユーザーID列のユーザーがテナントに属するもので絞り込むscope(テナント未指定の場合は全テナントが対象)
+func tenantUserScope(column string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if id := tenantOf(db); id != "" {
+			return db.Where(column+" IN (SELECT id FROM users WHERE tenant_id = ?)", id)
+		}
+		return db
+	}
+}
@@ -1,17 +1,28 @@
 package rdb
 
 import (
+	"coin-api/common/audit"
+	"coin-api/common/enum"
+	"coin-api/config"
 	"coin-api/domain/repository"
 	"context"
 	"database/sql"
 	"fmt"
+	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
+	"math/rand"
+	"net/http"
+	"time"
 )
 
 var txKey = struct{}{}
 
+// txDepthKey 入れ子のトランザクションの深さ(セーブポイント名に使用)
+type txDepthKey struct{}
+
 type TxRepository struct {
-	DB *gorm.DB
+	DB   *gorm.DB
+	conf *config.DBRetryInfo
 }
 
 func (tr *TxRepository) GetDBConn() *gorm.DB {
@@ -25,24 +36,134 @@ func GetTx(ctx context.Context) (*gorm.DB, bool) {
 
 func NewTxRepository(DB *gorm.DB) repository.ITxRepository {
 	return &TxRepository{
-		DB: DB,
+		DB:   DB,
+		conf: config.LoadConfig().DBRetryInfo,
 	}
 }
 
-func (tr *TxRepository) DoInTx(ctx context.Context, f func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+// DoInTx fをトランザクション内で実行(直列化失敗・デッドロックの場合はトランザクション全体を再試行するため、fは再実行可能であること)
+// トランザクション内で呼び出した場合はセーブポイントを作成して実行し、エラー・panicの場合はセーブポイントまでロールバックする
+// (入れ子の場合は外側のトランザクションの分離レベル・読み取り専用の設定で実行し、再試行は外側のトランザクションで行う)
+func (tr *TxRepository) DoInTx(ctx context.Context, f func(ctx context.Context) (interface{}, error), opts ...repository.TxOption) (interface{}, error) {
+	if tx, ok := GetTx(ctx); ok {
+		return doInSavepoint(ctx, tx, f)
+	}
+
+	var options repository.TxOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	backoff := tr.conf.TxBackoff
+	for attempt := 1; ; attempt++ {
+		v, err := tr.doInTx(ctx, &sql.TxOptions{Isolation: options.Isolation, ReadOnly: options.ReadOnly}, f)
+		if err == nil || !isRetryableTxError(err) || attempt >= tr.conf.TxAttempts {
+			return v, err
+		}
+
+		// 同時に失敗したトランザクション同士の再衝突を避けるため待機時間をばらつかせる
+		wait := backoff
+		if backoff > 0 {
+			wait = backoff/2 + time.Duration(rand.Int63n(int64(backoff)))
+		}
+		log.Log().Msg(fmt.Sprintf("直列化失敗・デッドロックのため%s後にトランザクションを再試行します 試行回数 : %d/%d", wait, attempt, tr.conf.TxAttempts))
+		select {
+		case <-ctx.Done():
+			return v, err
+		case <-time.After(wait):
+		}
+		backoff *= 2
+	}
+}
+
+func (tr *TxRepository) doInTx(ctx context.Context, opts *sql.TxOptions, f func(ctx context.Context) (interface{}, error)) (v interface{}, err error) {
 	// txを生成する
 	conn := tr.GetDBConn()
-	tx := conn.Begin(&sql.TxOptions{})
+	tx := conn.Begin(opts)
+	if tx.Error != nil {
+		return nil, fmt.Errorf("begin: %w", tx.Error)
+	}
 
 	ctx = context.WithValue(ctx, &txKey, tx)
+	ctx = context.WithValue(ctx, txDepthKey{}, 0)
 
-	v, err := f(ctx)
+	// panicの場合はロールバックしてからpanicを再送出
+	entry := audit.FromContext(ctx)
+	written := false
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+			if written {
+				entry.ID = 0
+			}
+			panic(r)
+		}
+	}()
+
+	v, err = f(ctx)
 	// エラーがあればロールバック
 	if err != nil {
 		_ = tx.Rollback()
 		return v, fmt.Errorf("rollback: %w", err)
 	}
+
+	// リクエストの監査ログを同一transactionで登録(未登録の場合のみ)
+	if entry != nil && entry.ID == 0 {
+		entry.Result = string(enum.AUDIT_SUCCESS)
+		if entry.StatusCode == 0 {
+			entry.StatusCode = http.StatusOK
+		}
+		if _, err := NewAuditRepository(tx).Insert(ctx, entry); err != nil {
+			_ = tx.Rollback()
+			entry.ID = 0
+			return v, fmt.Errorf("rollback: %w", err)
+		}
+		written = true
+	}
+
 	// エラーがなければコミット
-	tx.Commit()
+	if err := tx.Commit().Error; err != nil {
+		if written {
+			entry.ID = 0
+		}
+		return v, fmt.Errorf("commit: %w", err)
+	}
+	return v, nil
+}
+
+// doInSavepoint 実行中のトランザクション内でセーブポイントを作成してfを実行
+func doInSavepoint(ctx context.Context, tx *gorm.DB, f func(ctx context.Context) (interface{}, error)) (v interface{}, err error) {
+	depth, _ := ctx.Value(txDepthKey{}).(int)
+	depth++
+	name := fmt.Sprintf("sp_%d", depth)
+	if err := tx.SavePoint(name).Error; err != nil {
+		return nil, fmt.Errorf("savepoint: %w", err)
+	}
+	ctx = context.WithValue(ctx, txDepthKey{}, depth)
+
+	// panicの場合はセーブポイントまでロールバックしてからpanicを再送出(外側のトランザクションもロールバックされる)
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.RollbackTo(name)
+			panic(r)
+		}
+	}()
+
+	v, err = f(ctx)
+	// エラーがあればセーブポイントまでロールバック(外側のトランザクションは継続)
+	// 直列化失敗・デッドロックは外側で判定して再試行できるようrepository.ErrTxRetryableとして返却
+	if err != nil {
+		if rbErr := tx.RollbackTo(name).Error; rbErr != nil {
+			log.Error().Err(rbErr).Msg(fmt.Sprintf("セーブポイントへのロールバックでエラー発生 セーブポイント : %s", name))
+		}
+		if isRetryableTxError(err) {
+			err = &retryableTxError{err: err}
+		}
+		return v, fmt.Errorf("rollback to savepoint: %w", err)
+	}
+
+	if err := tx.Exec("RELEASE SAVEPOINT " + name).Error; err != nil {
+		return v, fmt.Errorf("release savepoint: %w", err)
+	}
 	return v, nil
 }
@@ -0,0 +1,298 @@
+package rdb
+
+import (
+	"coin-api/config"
+	"coin-api/domain/repository"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// fakePool トランザクションの開始・終了と実行したSQLを記録するConnPool
+type fakePool struct {
+	mu        sync.Mutex
+	log       []string
+	opts      []*sql.TxOptions
+	commitErr error
+}
+
+func (p *fakePool) record(s string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.log = append(p.log, s)
+}
+
+func (p *fakePool) statements() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.log...)
+}
+
+func (p *fakePool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (p *fakePool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	p.record(query)
+	return driver.RowsAffected(0), nil
+}
+
+func (p *fakePool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func (p *fakePool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+func (p *fakePool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	p.record("BEGIN")
+	p.mu.Lock()
+	p.opts = append(p.opts, opts)
+	p.mu.Unlock()
+	return &fakeTx{fakePool: p}, nil
+}
+
+// fakeTx fakePoolで開始したトランザクション
+type fakeTx struct {
+	*fakePool
+}
+
+func (t *fakeTx) Commit() error {
+	t.record("COMMIT")
+	return t.commitErr
+}
+
+func (t *fakeTx) Rollback() error {
+	t.record("ROLLBACK")
+	return nil
+}
+
+func newFakeTxRepository(t *testing.T) (*TxRepository, *fakePool) {
+	t.Helper()
+	pool := &fakePool{}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: pool}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &TxRepository{DB: db, conf: &config.DBRetryInfo{TxAttempts: 3}}, pool
+}
+
+// exec トランザクション内でSQLを実行するf
+func exec(query string) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		tx, _ := GetTx(ctx)
+		return nil, tx.Exec(query).Error
+	}
+}
+
+func assertStatements(t *testing.T, pool *fakePool, want ...string) {
+	t.Helper()
+	if got := pool.statements(); !reflect.DeepEqual(got, want) {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+}
+
+func TestDoInTxCommit(t *testing.T) {
+	tr, pool := newFakeTxRepository(t)
+
+	v, err := tr.DoInTx(context.Background(), func(ctx context.Context) (interface{}, error) {
+		if _, err := exec("UPDATE a")(ctx); err != nil {
+			return nil, err
+		}
+		return "done", nil
+	})
+	if err != nil {
+		t.Fatalf("DoInTx() error = %v", err)
+	}
+	if v != "done" {
+		t.Errorf("DoInTx() = %v, want done", v)
+	}
+	assertStatements(t, pool, "BEGIN", "UPDATE a", "COMMIT")
+}
+
+func TestDoInTxOptions(t *testing.T) {
+	tr, pool := newFakeTxRepository(t)
+
+	if _, err := tr.DoInTx(context.Background(), exec("SELECT a"), repository.WithIsolation(sql.LevelSerializable), repository.WithReadOnly()); err != nil {
+		t.Fatalf("DoInTx() error = %v", err)
+	}
+	want := &sql.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true}
+	if len(pool.opts) != 1 || !reflect.DeepEqual(pool.opts[0], want) {
+		t.Errorf("BeginTx() opts = %+v, want %+v", pool.opts, want)
+	}
+}
+
+func TestDoInTxErrorRollsBack(t *testing.T) {
+	tr, pool := newFakeTxRepository(t)
+	errFailed := errors.New("failed")
+
+	_, err := tr.DoInTx(context.Background(), func(ctx context.Context) (interface{}, error) {
+		return nil, errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("DoInTx() error = %v, want %v", err, errFailed)
+	}
+	assertStatements(t, pool, "BEGIN", "ROLLBACK")
+}
+
+func TestDoInTxPanicRollsBackAndRepanics(t *testing.T) {
+	tr, pool := newFakeTxRepository(t)
+
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("recover() = %v, want boom", r)
+		}
+		assertStatements(t, pool, "BEGIN", "UPDATE a", "ROLLBACK")
+	}()
+	_, _ = tr.DoInTx(context.Background(), func(ctx context.Context) (interface{}, error) {
+		_, _ = exec("UPDATE a")(ctx)
+		panic("boom")
+	})
+	t.Error("DoInTx() did not re-panic")
+}
+
+func TestDoInTxCommitError(t *testing.T) {
+	tr, pool := newFakeTxRepository(t)
+	pool.commitErr = errors.New("connection lost")
+
+	_, err := tr.DoInTx(context.Background(), exec("UPDATE a"))
+	if !errors.Is(err, pool.commitErr) {
+		t.Fatalf("DoInTx() error = %v, want %v", err, pool.commitErr)
+	}
+	assertStatements(t, pool, "BEGIN", "UPDATE a", "COMMIT")
+}
+
+func TestDoInTxSavepointRollbackKeepsOuter(t *testing.T) {
+	tr, pool := newFakeTxRepository(t)
+	errInner := errors.New("inner failed")
+
+	_, err := tr.DoInTx(context.Background(), func(ctx context.Context) (interface{}, error) {
+		if _, err := exec("UPDATE a")(ctx); err != nil {
+			return nil, err
+		}
+		// 内側のエラーはセーブポイントまでロールバックし、外側は継続
+		_, err := tr.DoInTx(ctx, func(ctx context.Context) (interface{}, error) {
+			_, _ = exec("UPDATE b")(ctx)
+			return nil, errInner
+		})
+		if !errors.Is(err, errInner) {
+			t.Errorf("inner DoInTx() error = %v, want %v", err, errInner)
+		}
+		return exec("UPDATE c")(ctx)
+	})
+	if err != nil {
+		t.Fatalf("DoInTx() error = %v", err)
+	}
+	assertStatements(t, pool, "BEGIN", "UPDATE a", "SAVEPOINT sp_1", "UPDATE b", "ROLLBACK TO SAVEPOINT sp_1", "UPDATE c", "COMMIT")
+}
+
+func TestDoInTxNestedSavepoints(t *testing.T) {
+	tr, pool := newFakeTxRepository(t)
+
+	_, err := tr.DoInTx(context.Background(), func(ctx context.Context) (interface{}, error) {
+		return tr.DoInTx(ctx, func(ctx context.Context) (interface{}, error) {
+			return tr.DoInTx(ctx, exec("UPDATE a"))
+		})
+	})
+	if err != nil {
+		t.Fatalf("DoInTx() error = %v", err)
+	}
+	assertStatements(t, pool, "BEGIN", "SAVEPOINT sp_1", "SAVEPOINT sp_2", "UPDATE a", "RELEASE SAVEPOINT sp_2", "RELEASE SAVEPOINT sp_1", "COMMIT")
+}
+
+func TestDoInTxSavepointPanicRollsBackOuter(t *testing.T) {
+	tr, pool := newFakeTxRepository(t)
+
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("recover() = %v, want boom", r)
+		}
+		assertStatements(t, pool, "BEGIN", "SAVEPOINT sp_1", "ROLLBACK TO SAVEPOINT sp_1", "ROLLBACK")
+	}()
+	_, _ = tr.DoInTx(context.Background(), func(ctx context.Context) (interface{}, error) {
+		return tr.DoInTx(ctx, func(ctx context.Context) (interface{}, error) {
+			panic("boom")
+		})
+	})
+	t.Error("DoInTx() did not re-panic")
+}
+
+func TestDoInTxRetriesSerializationFailure(t *testing.T) {
+	tr, pool := newFakeTxRepository(t)
+
+	attempts := 0
+	_, err := tr.DoInTx(context.Background(), func(ctx context.Context) (interface{}, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, &pgconn.PgError{Code: serializationFailure}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("DoInTx() error = %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+	assertStatements(t, pool, "BEGIN", "ROLLBACK", "BEGIN", "COMMIT")
+}
+
+func TestDoInTxGivesUpAfterAttempts(t *testing.T) {
+	tr, _ := newFakeTxRepository(t)
+
+	attempts := 0
+	_, err := tr.DoInTx(context.Background(), func(ctx context.Context) (interface{}, error) {
+		attempts++
+		return nil, &pgconn.PgError{Code: deadlockDetected}
+	})
+	if !isRetryableTxError(err) {
+		t.Fatalf("DoInTx() error = %v, want deadlock", err)
+	}
+	if attempts != tr.conf.TxAttempts {
+		t.Errorf("attempts = %d, want %d", attempts, tr.conf.TxAttempts)
+	}
+}
+
+func TestDoInTxSavepointMarksRetryableError(t *testing.T) {
+	tr, _ := newFakeTxRepository(t)
+
+	attempts := 0
+	_, err := tr.DoInTx(context.Background(), func(ctx context.Context) (interface{}, error) {
+		attempts++
+		// 入れ子の直列化失敗は外側で判定できるよう返却し、外側のトランザクションごと再試行
+		_, err := tr.DoInTx(ctx, func(ctx context.Context) (interface{}, error) {
+			if attempts == 1 {
+				return nil, &pgconn.PgError{Code: serializationFailure}
+			}
+			return nil, errors.New("insufficient balance")
+		})
+		if attempts == 1 && !errors.Is(err, repository.ErrTxRetryable) {
+			t.Errorf("inner DoInTx() error = %v, want %v", err, repository.ErrTxRetryable)
+		}
+		if attempts == 2 && errors.Is(err, repository.ErrTxRetryable) {
+			t.Errorf("inner DoInTx() error = %v, want not retryable", err)
+		}
+		if errors.Is(err, repository.ErrTxRetryable) {
+			return nil, err
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("DoInTx() error = %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+}
@@ -0,0 +1,96 @@
+package rdb
+
+import (
+	"coin-api/common"
+	"coin-api/common/enum"
+	"coin-api/domain/model"
+	"coin-api/domain/repository"
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"time"
+)
+
+type TransferRepository struct {
+	DB *gorm.DB
+}
+
+func NewTransferRepository(db *gorm.DB) repository.ITransferRepository {
+	return &TransferRepository{
+		DB: db,
+	}
+}
+
+func (tr *TransferRepository) SelectById(id uint) (*model.Transfer, error) {
+	// 取得用モデル定義
+	transfer := model.Transfer{}
+
+	// id検索での送金取得処理
+	result := tr.DB.Scopes(tenantUserScope("sender")).First(&transfer, "id=?", id)
+	if result.Error != nil {
+		// エラーまたはレコードを取得できない場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("送金取得処理でエラー発生 送金ID : %d", id))
+		return nil, result.Error
+	}
+
+	return &transfer, result.Error
+}
+
+func (tr *TransferRepository) SelectExpiredPending(now time.Time) ([]model.Transfer, error) {
+	// 取得用モデル定義
+	var transfers []model.Transfer
+
+	// 期限切れの承認待ち送金を取得
+	result := tr.DB.Scopes(tenantUserScope("sender")).Order("id").Find(&transfers, "status=? AND expires_at<=?", string(enum.PENDING), now)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("期限切れ送金取得処理でエラー発生 基準日時 : %s", now))
+		return nil, result.Error
+	}
+
+	return transfers, result.Error
+}
+
+func (tr *TransferRepository) Insert(ctx context.Context, transfer *model.Transfer) (*model.Transfer, error) {
+	// トランザクション取得
+	tx, ok := GetTx(ctx)
+	if !ok {
+		tx = tr.DB
+	}
+
+	// 送金登録処理
+	result := tx.Create(transfer)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("送金登録処理でエラー発生 送金 : %s", common.CreateJsonString(transfer)))
+		return nil, result.Error
+	}
+
+	return transfer, result.Error
+}
+
+func (tr *TransferRepository) UpdateStatus(ctx context.Context, transfer *model.Transfer, from string, to string, resolvedAt time.Time) (*model.Transfer, error) {
+	// トランザクション取得
+	tx, ok := GetTx(ctx)
+	if !ok {
+		tx = tr.DB
+	}
+
+	// 更新前ステータスが一致する場合のみ更新(二重処理防止)
+	result := tx.Model(transfer).
+		Scopes(tenantUserScope("sender")).
+		Where("status=?", from).
+		Updates(map[string]interface{}{"status": to, "resolved_at": resolvedAt})
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("送金ステータス更新処理でエラー発生 送金 : %s", common.CreateJsonString(transfer)))
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		log.Error().Msg(fmt.Sprintf("送金ステータス競合 送金ID : %d 更新前 : %s", transfer.ID, from))
+		return nil, repository.ErrTransferStatusConflict
+	}
+
+	return transfer, nil
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository struct {
@@ -26,7 +27,7 @@ func (ur *UserRepository) SelectById(uid uint) (*model.User, error) {
 	user := model.User{}
 
 	// id検索でのユーザー取得処理
-	result := ur.DB.First(&user, "id=?", uid)
+	result := ur.DB.Scopes(tenantScope("tenant_id")).First(&user, "id=?", uid)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		// エラーまたはレコードを取得できない場合、ログを出力
 		log.Error().Msg(fmt.Sprintf("ユーザー取得処理でエラー発生 ユーザーID : %d", uid))
@@ -36,14 +37,46 @@ func (ur *UserRepository) SelectById(uid uint) (*model.User, error) {
 	return &user, result.Error
 }
 
-func (ur *UserRepository) Insert(user *model.User) (*model.User, error) {
-	// ユーザー新規登録時にコイン残高を0で登録
+func (ur *UserRepository) SelectByUsername(username string) (*model.User, error) {
+	// 取得用モデル定義
+	user := model.User{}
+
+	// テナント内のユーザー名(大文字小文字を区別しない)でのユーザー取得処理
+	result := ur.DB.Scopes(tenantScope("tenant_id")).First(&user, "lower(username)=lower(?)", username)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("ユーザー取得処理でエラー発生 ユーザー名 : %s", username))
+		return nil, result.Error
+	}
+
+	return &user, result.Error
+}
+
+func (ur *UserRepository) Insert(ctx context.Context, user *model.User) (*model.User, error) {
+	// トランザクション取得
+	tr, ok := GetTx(ctx)
+	if !ok {
+		tr = ur.DB
+	}
+
+	// ユーザー新規登録時にリクエストのテナント、コイン残高、保留残高を0で登録
+	if user.TenantId == "" {
+		user.TenantId = tenantOf(tr)
+	}
 	balance := 0
+	held := 0
 	user.CoinBalance = &balance
+	user.HeldBalance = &held
 
 	// ユーザー登録処理
-	result := ur.DB.Create(&user)
+	result := tr.Create(&user)
 
+	if isUniqueViolation(result.Error, usernameIndex) {
+		return nil, repository.ErrDuplicateUsername
+	}
 	if result.Error != nil {
 		// エラーの場合、ログを出力
 		log.Error().Msg(fmt.Sprintf("ユーザー登録処理でエラー発生 ユーザー : %s", common.CreateJsonString(user)))
@@ -61,7 +94,7 @@ func (ur *UserRepository) Update(ctx context.Context, user *model.User) (*model.
 	}
 
 	// ユーザー情報更新処理
-	result := tr.Updates(&user)
+	result := tr.Scopes(tenantScope("tenant_id")).Updates(&user)
 
 	if result.Error != nil {
 		// エラーの場合、ログを出力
@@ -71,3 +104,117 @@ func (ur *UserRepository) Update(ctx context.Context, user *model.User) (*model.
 
 	return user, result.Error
 }
+
+func (ur *UserRepository) SelectForUpdate(ctx context.Context, ids ...uint) (map[uint]*model.User, error) {
+	// トランザクション取得
+	tr, ok := GetTx(ctx)
+	if !ok {
+		tr = ur.DB
+	}
+
+	// 複数ユーザーを同時にロックする処理同士でデッドロックしないようID順にロック
+	users := make([]*model.User, 0, len(ids))
+	result := tr.Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(tenantScope("tenant_id")).Where("id IN ?", ids).Order("id").Find(&users)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("ユーザーロック取得処理でエラー発生 ユーザーID : %v", ids))
+		return nil, result.Error
+	}
+
+	locked := make(map[uint]*model.User, len(users))
+	for _, user := range users {
+		locked[user.ID] = user
+	}
+	for _, id := range ids {
+		if _, ok := locked[id]; !ok {
+			log.Error().Msg(fmt.Sprintf("ユーザー取得処理でエラー発生 ユーザーID : %d", id))
+			return nil, gorm.ErrRecordNotFound
+		}
+	}
+
+	return locked, nil
+}
+
+func (ur *UserRepository) UpdateBalances(ctx context.Context, user *model.User) (*model.User, error) {
+	// トランザクション取得
+	tr, ok := GetTx(ctx)
+	if !ok {
+		tr = ur.DB
+	}
+
+	// ロック取得後に算出した残高のみ更新(ユーザー名などを取得時点の値で上書きしない)
+	result := tr.Model(user).Scopes(tenantScope("tenant_id")).Select("coinbalance", "heldbalance").Updates(user)
+
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("残高更新処理でエラー発生 ユーザーID : %d", user.ID))
+		return nil, result.Error
+	}
+
+	return user, result.Error
+}
+
+func (ur *UserRepository) UpdateProfile(ctx context.Context, user *model.User) (*model.User, error) {
+	// トランザクション取得
+	tr, ok := GetTx(ctx)
+	if !ok {
+		tr = ur.DB
+	}
+
+	// 残高を上書きしないようユーザー名とパスワードのみ更新
+	result := tr.Model(user).Scopes(tenantScope("tenant_id")).Select("username", "password").Updates(user)
+
+	if isUniqueViolation(result.Error, usernameIndex) {
+		return nil, repository.ErrDuplicateUsername
+	}
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("ユーザー更新処理でエラー発生 ユーザーID : %d", user.ID))
+		return nil, result.Error
+	}
+
+	return user, result.Error
+}
+
+func (ur *UserRepository) IncrementFailedLogins(ctx context.Context, uid uint) (int, error) {
+	// トランザクション取得
+	tr, ok := GetTx(ctx)
+	if !ok {
+		tr = ur.DB
+	}
+
+	// 同時に失敗した場合も取りこぼさないようDB上で加算
+	var count int
+	query, args := "UPDATE users SET failed_logins = failed_logins + 1 WHERE id = ?", []interface{}{uid}
+	if id := tenantOf(tr); id != "" {
+		query, args = query+" AND tenant_id = ?", append(args, id)
+	}
+	result := tr.Raw(query+" RETURNING failed_logins", args...).Scan(&count)
+
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("ログイン失敗回数更新処理でエラー発生 ユーザーID : %d", uid))
+		return 0, result.Error
+	}
+
+	return count, result.Error
+}
+
+func (ur *UserRepository) UpdateLoginState(ctx context.Context, user *model.User) (*model.User, error) {
+	// トランザクション取得
+	tr, ok := GetTx(ctx)
+	if !ok {
+		tr = ur.DB
+	}
+
+	// ログイン失敗回数とロック期限のみ更新(ロック解除の場合はNULL)
+	result := tr.Model(user).Scopes(tenantScope("tenant_id")).Select("failed_logins", "locked_until").Updates(user)
+
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("ログイン状態更新処理でエラー発生 ユーザーID : %d", user.ID))
+		return nil, result.Error
+	}
+
+	return user, result.Error
+}
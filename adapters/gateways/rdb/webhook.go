@@ -0,0 +1,167 @@
+package rdb
+
+import (
+	"coin-api/common"
+	"coin-api/common/enum"
+	"coin-api/domain/model"
+	"coin-api/domain/repository"
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"time"
+)
+
+type WebhookRepository struct {
+	DB *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) repository.IWebhookRepository {
+	return &WebhookRepository{
+		DB: db,
+	}
+}
+
+func (wr *WebhookRepository) SelectSubscriptionById(id uint) (*model.WebhookSubscription, error) {
+	// 取得用モデル定義
+	subscription := model.WebhookSubscription{}
+
+	// id検索での購読取得処理
+	result := wr.DB.Scopes(tenantScope("tenant_id")).First(&subscription, "id=?", id)
+	if result.Error != nil {
+		// エラーまたはレコードを取得できない場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("Webhook購読取得処理でエラー発生 購読ID : %d", id))
+		return nil, result.Error
+	}
+
+	return &subscription, result.Error
+}
+
+func (wr *WebhookRepository) SelectSubscriptions() ([]model.WebhookSubscription, error) {
+	// 取得用モデル定義
+	var subscriptions []model.WebhookSubscription
+
+	// テナントの全購読取得
+	result := wr.DB.Scopes(tenantScope("tenant_id")).Order("id").Find(&subscriptions)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg("Webhook購読一覧取得処理でエラー発生")
+		return nil, result.Error
+	}
+
+	return subscriptions, result.Error
+}
+
+func (wr *WebhookRepository) SelectActiveSubscriptionsByEventType(tenantId string, eventType string) ([]model.WebhookSubscription, error) {
+	// 取得用モデル定義
+	var subscriptions []model.WebhookSubscription
+
+	// テナント内で対象イベント種別を購読している有効な購読取得(event_typesはカンマ区切り)
+	result := wr.DB.Order("id").Find(&subscriptions, "tenant_id=? AND active=? AND ','||event_types||',' LIKE ?", tenantId, true, "%,"+eventType+",%")
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("Webhook購読取得処理でエラー発生 イベント種別 : %s", eventType))
+		return nil, result.Error
+	}
+
+	return subscriptions, result.Error
+}
+
+func (wr *WebhookRepository) InsertSubscription(subscription *model.WebhookSubscription) (*model.WebhookSubscription, error) {
+	// リクエストのテナントで購読登録処理
+	if subscription.TenantId == "" {
+		subscription.TenantId = tenantOf(wr.DB)
+	}
+	result := wr.DB.Create(subscription)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("Webhook購読登録処理でエラー発生 URL : %s", subscription.URL))
+		return nil, result.Error
+	}
+
+	return subscription, result.Error
+}
+
+func (wr *WebhookRepository) UpdateSubscription(subscription *model.WebhookSubscription) (*model.WebhookSubscription, error) {
+	// 購読更新処理(activeのfalse更新のためSelectで対象列を指定)
+	result := wr.DB.Model(subscription).Scopes(tenantScope("tenant_id")).Select("url", "secret", "event_types", "active").Updates(subscription)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("Webhook購読更新処理でエラー発生 購読ID : %d", subscription.ID))
+		return nil, result.Error
+	}
+
+	return subscription, result.Error
+}
+
+func (wr *WebhookRepository) SelectDeliveriesBySubscriptionId(id uint) ([]model.WebhookDelivery, error) {
+	// 取得用モデル定義
+	var deliveries []model.WebhookDelivery
+
+	// 購読に紐づく全配信履歴取得
+	result := wr.DB.Scopes(tenantSubscriptionScope).Order("id").Find(&deliveries, "subscription_id=?", id)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("Webhook配信履歴取得処理でエラー発生 購読ID : %d", id))
+		return nil, result.Error
+	}
+
+	return deliveries, result.Error
+}
+
+func (wr *WebhookRepository) SelectDueDeliveries(now time.Time, limit int) ([]model.WebhookDelivery, error) {
+	// 取得用モデル定義
+	var deliveries []model.WebhookDelivery
+
+	// 送信予定日時を過ぎた未完了の配信取得
+	result := wr.DB.Scopes(tenantSubscriptionScope).Order("next_attempt_at").Limit(limit).
+		Find(&deliveries, "status=? AND next_attempt_at<=?", string(enum.DELIVERY_PENDING), now)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("Webhook配信対象取得処理でエラー発生 基準日時 : %s", now))
+		return nil, result.Error
+	}
+
+	return deliveries, result.Error
+}
+
+func (wr *WebhookRepository) BatchInsertDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) ([]*model.WebhookDelivery, error) {
+	// トランザクション取得
+	tx, ok := GetTx(ctx)
+	if !ok {
+		tx = wr.DB
+	}
+
+	// 配信一括登録処理
+	result := tx.Create(deliveries)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("Webhook配信一括登録処理でエラー発生 配信 : %s", common.CreateJsonString(deliveries)))
+		return nil, result.Error
+	}
+
+	return deliveries, result.Error
+}
+
+func (wr *WebhookRepository) UpdateDelivery(delivery *model.WebhookDelivery) (*model.WebhookDelivery, error) {
+	// 配信結果更新処理
+	result := wr.DB.Model(delivery).
+		Scopes(tenantSubscriptionScope).
+		Select("status", "attempts", "next_attempt_at", "response_status", "last_error").
+		Updates(delivery)
+	if result.Error != nil {
+		// エラーの場合、ログを出力
+		log.Error().Msg(fmt.Sprintf("Webhook配信更新処理でエラー発生 配信ID : %d", delivery.ID))
+		return nil, result.Error
+	}
+
+	return delivery, result.Error
+}
+
+// tenantSubscriptionScope 購読がテナントに属する配信で絞り込むscope(テナント未指定の場合は全テナントが対象)
+func tenantSubscriptionScope(db *gorm.DB) *gorm.DB {
+	if id := tenantOf(db); id != "" {
+		return db.Where("subscription_id IN (SELECT id FROM webhook_subscriptions WHERE tenant_id = ?)", id)
+	}
+	return db
+}
@@ -0,0 +1,62 @@
+package stream
+
+import (
+	"coin-api/domain/event"
+	"coin-api/usecase/port"
+	"sync"
+)
+
+// subscriberBuffer 購読者ごとの未送信イベント上限(超過分は破棄)
+const subscriberBuffer = 16
+
+// Hub プロセス内の残高変更通知の購読管理
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[uint]map[chan *event.BalanceChanged]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subs: make(map[uint]map[chan *event.BalanceChanged]struct{}),
+	}
+}
+
+var _ ports.BalanceSubscriber = (*Hub)(nil)
+
+func (h *Hub) Subscribe(uid uint) (<-chan *event.BalanceChanged, func()) {
+	ch := make(chan *event.BalanceChanged, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.subs[uid] == nil {
+		h.subs[uid] = make(map[chan *event.BalanceChanged]struct{})
+	}
+	h.subs[uid][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs[uid], ch)
+			if len(h.subs[uid]) == 0 {
+				delete(h.subs, uid)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+func (h *Hub) Publish(e *event.BalanceChanged) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for ch := range h.subs[e.UserId] {
+		// 受信が遅い購読者で他の購読者を止めない
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
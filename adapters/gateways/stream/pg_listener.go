@@ -0,0 +1,67 @@
+package stream
+
+import (
+	"coin-api/domain/event"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+// reconnectWait 接続断時の再接続待ち時間
+const reconnectWait = 3 * time.Second
+
+// PgListener LISTEN/NOTIFYで受信した残高変更通知をHubへ配信
+type PgListener struct {
+	dsn     string
+	channel string
+	hub     *Hub
+}
+
+func NewPgListener(dsn string, channel string, hub *Hub) *PgListener {
+	return &PgListener{
+		dsn:     dsn,
+		channel: channel,
+		hub:     hub,
+	}
+}
+
+func (l *PgListener) Run(ctx context.Context) {
+	for {
+		if err := l.listen(ctx); err != nil {
+			log.Error().Err(err).Msg("残高変更通知の受信でエラー発生")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectWait):
+		}
+	}
+}
+
+func (l *PgListener) listen(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, l.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.channel}.Sanitize()); err != nil {
+		return err
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var e event.BalanceChanged
+		if err := json.Unmarshal([]byte(n.Payload), &e); err != nil {
+			log.Error().Msg(fmt.Sprintf("残高変更通知の解析でエラー発生 : %s", n.Payload))
+			continue
+		}
+		l.hub.Publish(&e)
+	}
+}
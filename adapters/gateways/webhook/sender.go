@@ -0,0 +1,69 @@
+package webhook
+
+import (
+	"bytes"
+	"coin-api/usecase/port"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	headerEvent     = "X-Coin-Event"
+	headerDelivery  = "X-Coin-Delivery"
+	headerTimestamp = "X-Coin-Timestamp"
+	headerSignature = "X-Coin-Signature"
+)
+
+type HTTPSender struct {
+	Client *http.Client
+}
+
+func NewHTTPSender(timeout time.Duration) ports.WebhookSender {
+	return &HTTPSender{
+		Client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *HTTPSender) Send(ctx context.Context, req *ports.WebhookRequest) (int, error) {
+	// 署名対象は「タイムスタンプ.ペイロード」
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Payload))
+	if err != nil {
+		return 0, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(headerEvent, req.EventType)
+	httpReq.Header.Set(headerDelivery, strconv.FormatUint(uint64(req.DeliveryId), 10))
+	httpReq.Header.Set(headerTimestamp, timestamp)
+	httpReq.Header.Set(headerSignature, "sha256="+Sign(req.Secret, timestamp, req.Payload))
+
+	res, err := s.Client.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	// 2xx以外は失敗扱い
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("webhook送信先がステータス%dを返却", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+// Sign 受信側の検証と同じ手順でHMAC-SHA256署名を作成
+func Sign(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"coin-api/common/audit"
+	"coin-api/common/enum"
+	"coin-api/common/tenant"
+	"coin-api/domain/model"
+	"coin-api/domain/repository"
+	"context"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"net"
+	"time"
+)
+
+// requestIdKey リクエストIDのメタデータキー
+const requestIdKey = "x-request-id"
+
+// readOnlyMethods 監査ログを記録しない参照系メソッド
+var readOnlyMethods = map[string]bool{
+	"/coinapi.v1.UserService/GetBalance":   true,
+	"/coinapi.v1.CoinService/GetHistories": true,
+}
+
+// AuditInterceptor 状態変更RPCの監査ログ記録(REST APIの監査ログと同じ形式)
+func AuditInterceptor(ar repository.IAuditRepository) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		// リクエストIDの採番
+		requestId := ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(requestIdKey)) > 0 {
+			requestId = md.Get(requestIdKey)[0]
+		}
+		if requestId == "" {
+			requestId = audit.NewRequestId()
+		}
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestIdKey, requestId))
+
+		// 参照系は記録しない
+		if readOnlyMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		entry := &model.AuditLog{
+			TenantId:  tenant.FromContext(ctx),
+			RequestId: requestId,
+			ActorType: string(enum.ACTOR_ANONYMOUS),
+			Method:    "GRPC",
+			Endpoint:  info.FullMethod,
+			Payload:   "null",
+			ClientIP:  clientIP(ctx),
+			CreatedAt: time.Now(),
+		}
+		if m, ok := req.(proto.Message); ok {
+			if b, err := protojson.Marshal(m); err == nil {
+				entry.Payload = audit.RedactPayload(b)
+			}
+		}
+
+		res, err := handler(audit.WithEntry(ctx, entry), req)
+
+		// transaction内で登録済みの場合は終了
+		if entry.ID != 0 {
+			return res, err
+		}
+		entry.Result = string(enum.AUDIT_SUCCESS)
+		if err != nil {
+			st := status.Convert(err)
+			entry.Result = string(enum.AUDIT_FAILURE)
+			entry.StatusCode = int(st.Code())
+			entry.ErrorMessage = st.Message()
+		}
+		if _, insertErr := ar.Insert(context.Background(), entry); insertErr != nil {
+			log.Error().Stack().Err(insertErr).Send()
+		}
+		return res, err
+	}
+}
+
+func clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
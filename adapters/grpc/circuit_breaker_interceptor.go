@@ -0,0 +1,22 @@
+package services
+
+import (
+	"coin-api/database"
+	"context"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+// CircuitBreakerInterceptor DB接続の遮断中はリクエストを処理せずUnavailableを即時返却
+func CircuitBreakerInterceptor(retryAfter func() time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if retryAfter() > 0 {
+			log.Log().Msg("DB接続の遮断中のためリクエストを拒否しました")
+			return nil, status.Error(codes.Unavailable, database.ErrUnavailable.Error())
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"coin-api/adapters/controller"
+	"coin-api/adapters/grpc/pb"
+	"coin-api/database"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"coin-api/usecase/presenter"
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+)
+
+type CoinService struct {
+	pb.UnimplementedCoinServiceServer
+	InputFactory                  controllers.CoinInputFactory
+	CoinRepositoryFactory         controllers.CoinRepositoryFactory
+	UserRepositoryFactory         controllers.UserRepositoryFactory
+	TransferRepositoryFactory     controllers.TransferRepositoryFactory
+	OutboxRepositoryFactory       controllers.OutboxRepositoryFactory
+	NotificationRepositoryFactory controllers.NotificationRepositoryFactory
+	TxRepositoryFactory           controllers.TxRepositoryFactory
+	ClientFactory                 *database.PostgreSQLConnector
+}
+
+func NewCoinService(inputFactory controllers.CoinInputFactory, coinRepositoryFactory controllers.CoinRepositoryFactory, userRepositoryFactory controllers.UserRepositoryFactory, transferRepositoryFactory controllers.TransferRepositoryFactory, outboxRepositoryFactory controllers.OutboxRepositoryFactory, notificationRepositoryFactory controllers.NotificationRepositoryFactory, txRepositoryFactory controllers.TxRepositoryFactory, clientFactory *database.PostgreSQLConnector) *CoinService {
+	return &CoinService{
+		InputFactory:                  inputFactory,
+		CoinRepositoryFactory:         coinRepositoryFactory,
+		UserRepositoryFactory:         userRepositoryFactory,
+		TransferRepositoryFactory:     transferRepositoryFactory,
+		OutboxRepositoryFactory:       outboxRepositoryFactory,
+		NotificationRepositoryFactory: notificationRepositoryFactory,
+		TxRepositoryFactory:           txRepositoryFactory,
+		ClientFactory:                 clientFactory,
+	}
+}
+
+func (c *CoinService) AddUseCoin(ctx context.Context, req *pb.AddUseCoinRequest) (*pb.CoinResponse, error) {
+	// request情報をformにマッピング
+	form := model.CoinAddUseForm{
+		UserId:    model.NumericString(fmt.Sprint(req.GetUserid())),
+		Operation: req.GetOperation(),
+		Amount:    model.NumericString(req.GetAmount()),
+	}
+
+	// コイン追加消費処理
+	op := presenter.NewGrpcCoinOutputPort()
+	if err := c.newInputPort(ctx, op).AddUseCoin(ctx, &form); err != nil {
+		log.Error().Stack().Err(err).Send()
+	}
+	if op.Err != nil {
+		return nil, op.Err
+	}
+	return op.Coin, nil
+}
+
+func (c *CoinService) SendCoin(ctx context.Context, req *pb.SendCoinRequest) (*pb.SendCoinResponse, error) {
+	// request情報をformにマッピング
+	form := model.CoinSendForm{
+		Sender:            model.NumericString(fmt.Sprint(req.GetSender())),
+		ReceiverUsername:  req.GetReceiverUsername(),
+		Amount:            model.NumericString(req.GetAmount()),
+		RequireAcceptance: req.GetRequireAcceptance(),
+	}
+	// ユーザー名指定がない場合のみIDで受取人を指定
+	if form.ReceiverUsername == "" {
+		form.Receiver = model.NumericString(fmt.Sprint(req.GetReceiver()))
+	}
+
+	// コイン送金処理
+	op := presenter.NewGrpcCoinOutputPort()
+	if err := c.newInputPort(ctx, op).SendCoin(ctx, &form); err != nil {
+		log.Error().Stack().Err(err).Send()
+	}
+	if op.Err != nil {
+		return nil, op.Err
+	}
+	return op.Send, nil
+}
+
+func (c *CoinService) AcceptTransfer(ctx context.Context, req *pb.ResolveTransferRequest) (*pb.CoinTransferResponse, error) {
+	// request情報をformにマッピング
+	form := model.TransferResolveForm{
+		UserId: fmt.Sprint(req.GetUserid()),
+	}
+
+	// 承認待ち送金の承認処理
+	op := presenter.NewGrpcCoinOutputPort()
+	if err := c.newInputPort(ctx, op).AcceptTransfer(ctx, fmt.Sprint(req.GetTransferId()), &form); err != nil {
+		log.Error().Stack().Err(err).Send()
+	}
+	if op.Err != nil {
+		return nil, op.Err
+	}
+	return op.Transfer, nil
+}
+
+func (c *CoinService) RejectTransfer(ctx context.Context, req *pb.ResolveTransferRequest) (*pb.CoinTransferResponse, error) {
+	// request情報をformにマッピング
+	form := model.TransferResolveForm{
+		UserId: fmt.Sprint(req.GetUserid()),
+	}
+
+	// 承認待ち送金の拒否処理
+	op := presenter.NewGrpcCoinOutputPort()
+	if err := c.newInputPort(ctx, op).RejectTransfer(ctx, fmt.Sprint(req.GetTransferId()), &form); err != nil {
+		log.Error().Stack().Err(err).Send()
+	}
+	if op.Err != nil {
+		return nil, op.Err
+	}
+	return op.Transfer, nil
+}
+
+func (c *CoinService) ReverseHistory(ctx context.Context, req *pb.ReverseHistoryRequest) (*pb.CoinReversalResponse, error) {
+	// request情報をformにマッピング
+	form := model.CoinReverseForm{
+		Reason: req.GetReason(),
+	}
+
+	// コイン履歴取消処理
+	op := presenter.NewGrpcCoinOutputPort()
+	if err := c.newInputPort(ctx, op).ReverseHistory(ctx, fmt.Sprint(req.GetHistoryId()), &form); err != nil {
+		log.Error().Stack().Err(err).Send()
+	}
+	if op.Err != nil {
+		return nil, op.Err
+	}
+	return op.Reversal, nil
+}
+
+func (c *CoinService) GetHistories(ctx context.Context, req *pb.GetHistoriesRequest) (*pb.CoinHistoriesResponse, error) {
+	// コイン履歴取得処理
+	op := presenter.NewGrpcCoinOutputPort()
+	if err := c.newInputPort(ctx, op).SelectHistoriesByUserId(ctx, fmt.Sprint(req.GetUserid())); err != nil {
+		log.Error().Stack().Err(err).Send()
+	}
+	if op.Err != nil {
+		return nil, op.Err
+	}
+	return op.Histories, nil
+}
+
+func (c *CoinService) newInputPort(ctx context.Context, op ports.CoinOutputPort) ports.CoinInputPort {
+	conn := readConn(c.ClientFactory, ctx)
+	cr := c.CoinRepositoryFactory(conn)
+	ur := c.UserRepositoryFactory(conn)
+	tfr := c.TransferRepositoryFactory(conn)
+	obr := c.OutboxRepositoryFactory(conn)
+	nr := c.NotificationRepositoryFactory(conn)
+	tr := c.TxRepositoryFactory(tenantConn(c.ClientFactory.Conn, ctx))
+	return c.InputFactory(op, cr, ur, tfr, obr, nr, tr)
+}
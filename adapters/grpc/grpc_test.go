@@ -0,0 +1,317 @@
+package services
+
+import (
+	"coin-api/adapters/grpc/pb"
+	"coin-api/common/tenant"
+	"coin-api/config"
+	"coin-api/database"
+	models "coin-api/domain/model"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"context"
+	"errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var testCoin = models.Coin{Code: "COIN", Precision: 2}
+
+// fakeCoinInput 受け取ったformとテナントを記録し、outputで出力するCoinInputPort
+type fakeCoinInput struct {
+	ports.CoinInputPort
+	op      ports.CoinOutputPort
+	tenant  string
+	addForm *model.CoinAddUseForm
+	send    *model.CoinSendForm
+	output  func(op ports.CoinOutputPort) error
+}
+
+func (f *fakeCoinInput) AddUseCoin(ctx context.Context, form *model.CoinAddUseForm) error {
+	f.tenant, f.addForm = tenant.FromContext(ctx), form
+	return f.output(f.op)
+}
+
+func (f *fakeCoinInput) SendCoin(ctx context.Context, form *model.CoinSendForm) error {
+	f.tenant, f.send = tenant.FromContext(ctx), form
+	return f.output(f.op)
+}
+
+// fakeUserInput outputで出力するUserInputPort
+type fakeUserInput struct {
+	ports.UserInputPort
+	op     ports.UserOutputPort
+	uid    string
+	output func(op ports.UserOutputPort) error
+}
+
+func (f *fakeUserInput) RegisterUser(_ context.Context, _ *model.UserAddForm) error {
+	return f.output(f.op)
+}
+
+func (f *fakeUserInput) GetBalanceByUserId(uid string) error {
+	f.uid = uid
+	return f.output(f.op)
+}
+
+func (f *fakeUserInput) LookupUser(_ *model.UserLookupForm) error {
+	return f.output(f.op)
+}
+
+type fakeAuditRepository struct {
+	repository.IAuditRepository
+	entries []*models.AuditLog
+}
+
+func (f *fakeAuditRepository) Insert(_ context.Context, entry *models.AuditLog) (*models.AuditLog, error) {
+	f.entries = append(f.entries, entry)
+	return entry, nil
+}
+
+type errorOutputPort interface {
+	OutputError(res *model.ErrorResponse, err error) error
+}
+
+// outputError ErrorResponseを出力
+func outputError(op errorOutputPort, code int, message string) error {
+	return op.OutputError(model.CreateErrorResponse(code, message), errors.New(message))
+}
+
+type testServer struct {
+	coin       *fakeCoinInput
+	user       *fakeUserInput
+	audits     *fakeAuditRepository
+	retryAfter time.Duration
+	coinClient pb.CoinServiceClient
+	userClient pb.UserServiceClient
+}
+
+// newTestServer drivers.InitGrpcServerと同じインターセプターでbufconn上にサーバーを起動
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 port=1"}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	con := &database.PostgreSQLConnector{Conn: db}
+	ts := &testServer{coin: &fakeCoinInput{}, user: &fakeUserInput{}, audits: &fakeAuditRepository{}}
+	tenants := &config.TenantsInfo{DefaultId: "default", Tenants: []config.TenantInfo{{Id: "default"}, {Id: "shop"}}}
+
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		CircuitBreakerInterceptor(func() time.Duration { return ts.retryAfter }),
+		TenantInterceptor(tenants),
+		ReadYourWritesInterceptor(&config.ReplicaInfo{}),
+		AuditInterceptor(ts.audits),
+	))
+	coinInput := func(op ports.CoinOutputPort, _ repository.ICoinRepository, _ repository.IUserRepository, _ repository.ITransferRepository, _ repository.IOutboxRepository, _ repository.INotificationRepository, _ repository.ITxRepository) ports.CoinInputPort {
+		ts.coin.op = op
+		return ts.coin
+	}
+	userInput := func(op ports.UserOutputPort, _ repository.IUserRepository, _ repository.IOutboxRepository, _ repository.ITxRepository, _ repository.IPasswordResetRepository, _ ports.BalanceSubscriber, _ ports.PasswordResetNotifier) ports.UserInputPort {
+		ts.user.op = op
+		return ts.user
+	}
+	pb.RegisterCoinServiceServer(s, NewCoinService(coinInput,
+		func(*gorm.DB) repository.ICoinRepository { return nil },
+		func(*gorm.DB) repository.IUserRepository { return nil },
+		func(*gorm.DB) repository.ITransferRepository { return nil },
+		func(*gorm.DB) repository.IOutboxRepository { return nil },
+		func(*gorm.DB) repository.INotificationRepository { return nil },
+		func(*gorm.DB) repository.ITxRepository { return nil },
+		con))
+	pb.RegisterUserServiceServer(s, NewUserService(userInput,
+		func(*gorm.DB) repository.IUserRepository { return nil },
+		func(*gorm.DB) repository.IOutboxRepository { return nil },
+		func(*gorm.DB) repository.ITxRepository { return nil },
+		func(*gorm.DB) repository.IPasswordResetRepository { return nil },
+		nil, nil, con))
+
+	lis := bufconn.Listen(1024 * 1024)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	ts.coinClient = pb.NewCoinServiceClient(conn)
+	ts.userClient = pb.NewUserServiceClient(conn)
+	return ts
+}
+
+func TestCoinServiceAddUseCoin(t *testing.T) {
+	ts := newTestServer(t)
+	ts.coin.output = func(op ports.CoinOutputPort) error {
+		return op.OutputCoin(&model.CoinResponse{UserId: 1, Operation: "ADD", Amount: testCoin.Decimal(150), Balance: testCoin.Decimal(1150)})
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-tenant-id", "shop")
+	res, err := ts.coinClient.AddUseCoin(ctx, &pb.AddUseCoinRequest{Userid: 1, Operation: "ADD", Amount: "1.5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// リクエストをformへ、出力をレスポンスへ変換
+	if f := ts.coin.addForm; f.UserId != "1" || f.Operation != "ADD" || f.Amount != "1.5" {
+		t.Errorf("form = %+v", f)
+	}
+	if ts.coin.tenant != "shop" {
+		t.Errorf("tenant = %q, want shop", ts.coin.tenant)
+	}
+	if res.GetUserid() != 1 || res.GetAmount() != "1.50" || res.GetBalance() != "11.50" {
+		t.Errorf("response = %+v", res)
+	}
+
+	// 状態変更RPCは監査ログを記録
+	if len(ts.audits.entries) != 1 || ts.audits.entries[0].Endpoint != "/coinapi.v1.CoinService/AddUseCoin" || ts.audits.entries[0].TenantId != "shop" {
+		t.Errorf("audit entries = %+v", ts.audits.entries)
+	}
+}
+
+func TestCoinServiceSendCoinReceiver(t *testing.T) {
+	ts := newTestServer(t)
+	ts.coin.output = func(op ports.CoinOutputPort) error {
+		return op.OutputCoinSend(&model.CoinSendResponse{Sender: 1, Receiver: 2, Amount: testCoin.Decimal(100), SenderBalance: testCoin.Decimal(0)})
+	}
+
+	// ユーザー名指定の場合はIDを無視
+	if _, err := ts.coinClient.SendCoin(context.Background(), &pb.SendCoinRequest{Sender: 1, Receiver: 3, ReceiverUsername: "bob", Amount: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if f := ts.coin.send; f.Receiver != "" || f.ReceiverUsername != "bob" {
+		t.Errorf("form = %+v, want receiver by username only", f)
+	}
+
+	res, err := ts.coinClient.SendCoin(context.Background(), &pb.SendCoinRequest{Sender: 1, Receiver: 2, Amount: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := ts.coin.send; f.Sender != "1" || f.Receiver != "2" || ts.coin.tenant != "default" {
+		t.Errorf("form = %+v tenant = %q", f, ts.coin.tenant)
+	}
+	if sent := res.GetSent(); sent.GetSender() != 1 || sent.GetReceiver() != 2 || sent.GetAmount() != "1.00" {
+		t.Errorf("response = %+v", res)
+	}
+}
+
+func TestCoinServiceErrorCodes(t *testing.T) {
+	tests := []struct {
+		code int
+		want codes.Code
+	}{
+		{code: http.StatusBadRequest, want: codes.InvalidArgument},
+		{code: http.StatusUnauthorized, want: codes.Unauthenticated},
+		{code: http.StatusForbidden, want: codes.PermissionDenied},
+		{code: http.StatusNotFound, want: codes.NotFound},
+		{code: http.StatusConflict, want: codes.Aborted},
+		{code: http.StatusUnprocessableEntity, want: codes.FailedPrecondition},
+		{code: http.StatusLocked, want: codes.FailedPrecondition},
+		{code: http.StatusTooManyRequests, want: codes.ResourceExhausted},
+		{code: http.StatusServiceUnavailable, want: codes.Unavailable},
+		{code: http.StatusInternalServerError, want: codes.Internal},
+	}
+	ts := newTestServer(t)
+	for _, tt := range tests {
+		ts.audits.entries = nil
+		code := tt.code
+		ts.coin.output = func(op ports.CoinOutputPort) error { return outputError(op, code, "エラー") }
+
+		// ErrorResponseのHTTPステータスをgRPCのステータスへ変換
+		_, err := ts.coinClient.SendCoin(context.Background(), &pb.SendCoinRequest{Sender: 1, Receiver: 2, Amount: "1"})
+		st := status.Convert(err)
+		if st.Code() != tt.want || st.Message() != "エラー" {
+			t.Errorf("error_code %d: status = %v %q, want %v", tt.code, st.Code(), st.Message(), tt.want)
+		}
+
+		// 監査ログには失敗とgRPCのステータスを記録
+		if len(ts.audits.entries) != 1 || ts.audits.entries[0].Result != "FAILURE" || ts.audits.entries[0].StatusCode != int(tt.want) {
+			t.Errorf("error_code %d: audit entries = %+v", tt.code, ts.audits.entries)
+		}
+	}
+}
+
+func TestUserService(t *testing.T) {
+	ts := newTestServer(t)
+
+	ts.user.output = func(op ports.UserOutputPort) error {
+		return op.OutputUser(&model.UserResponse{UserId: 1, Name: "alice", Balance: testCoin.Decimal(0)})
+	}
+	res, err := ts.userClient.RegisterUser(context.Background(), &pb.RegisterUserRequest{Username: "alice", Password: "Passw0rd!"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.GetUserid() != 1 || res.GetUsername() != "alice" || res.GetBalance() != "0.00" {
+		t.Errorf("RegisterUser() = %+v", res)
+	}
+	// パスワードは監査ログに残さない
+	if len(ts.audits.entries) != 1 || ts.audits.entries[0].Payload != `{"password":"[REDACTED]","username":"alice"}` {
+		t.Errorf("audit entries = %+v", ts.audits.entries)
+	}
+
+	ts.audits.entries = nil
+	ts.user.output = func(op ports.UserOutputPort) error {
+		return op.OutputUserBalance(&model.UserBalanceResponse{UserId: 1, Balance: testCoin.Decimal(250), HeldBalance: testCoin.Decimal(50)})
+	}
+	var header metadata.MD
+	balance, err := ts.userClient.GetBalance(context.Background(), &pb.GetBalanceRequest{Userid: 1}, grpc.Header(&header))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts.user.uid != "1" || balance.GetBalance() != "2.50" || balance.GetHeldBalance() != "0.50" {
+		t.Errorf("GetBalance() = %+v uid = %q", balance, ts.user.uid)
+	}
+	// 参照系は監査ログを記録せず、リクエストIDのみ返却
+	if len(ts.audits.entries) != 0 || len(header.Get("x-request-id")) != 1 {
+		t.Errorf("audit entries = %d request id = %v", len(ts.audits.entries), header.Get("x-request-id"))
+	}
+
+	ts.user.output = func(op ports.UserOutputPort) error {
+		return outputError(op, http.StatusNotFound, "ユーザーが存在しません")
+	}
+	if _, err := ts.userClient.LookupUser(context.Background(), &pb.LookupUserRequest{Username: "bob"}); status.Code(err) != codes.NotFound {
+		t.Errorf("LookupUser() error = %v, want NotFound", err)
+	}
+}
+
+func TestInterceptorsRejectBeforeHandler(t *testing.T) {
+	ts := newTestServer(t)
+	called := false
+	ts.coin.output = func(op ports.CoinOutputPort) error {
+		called = true
+		return nil
+	}
+
+	// 設定にないテナントはInvalidArgument
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-tenant-id", "unknown")
+	if _, err := ts.coinClient.AddUseCoin(ctx, &pb.AddUseCoinRequest{Userid: 1, Operation: "ADD", Amount: "1"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("unknown tenant error = %v, want InvalidArgument", err)
+	}
+
+	// DB接続の遮断中はUnavailable
+	ts.retryAfter = time.Second
+	if _, err := ts.coinClient.AddUseCoin(context.Background(), &pb.AddUseCoinRequest{Userid: 1, Operation: "ADD", Amount: "1"}); status.Code(err) != codes.Unavailable {
+		t.Errorf("circuit open error = %v, want Unavailable", err)
+	}
+
+	if called || len(ts.audits.entries) != 0 {
+		t.Errorf("handler called = %v audit entries = %d, want neither", called, len(ts.audits.entries))
+	}
+}
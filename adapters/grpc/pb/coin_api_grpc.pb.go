@@ -0,0 +1,499 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: coin_api.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// UserServiceClient is the client API for UserService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type UserServiceClient interface {
+	// ユーザー登録
+	RegisterUser(ctx context.Context, in *RegisterUserRequest, opts ...grpc.CallOption) (*UserResponse, error)
+	// 対象ユーザー残高取得
+	GetBalance(ctx context.Context, in *GetBalanceRequest, opts ...grpc.CallOption) (*UserBalanceResponse, error)
+	// ユーザー名でユーザー検索
+	LookupUser(ctx context.Context, in *LookupUserRequest, opts ...grpc.CallOption) (*UserProfileResponse, error)
+	// ユーザー名・パスワード変更
+	UpdateUser(ctx context.Context, in *UpdateUserRequest, opts ...grpc.CallOption) (*UserProfileResponse, error)
+}
+
+type userServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewUserServiceClient(cc grpc.ClientConnInterface) UserServiceClient {
+	return &userServiceClient{cc}
+}
+
+func (c *userServiceClient) RegisterUser(ctx context.Context, in *RegisterUserRequest, opts ...grpc.CallOption) (*UserResponse, error) {
+	out := new(UserResponse)
+	err := c.cc.Invoke(ctx, "/coinapi.v1.UserService/RegisterUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) GetBalance(ctx context.Context, in *GetBalanceRequest, opts ...grpc.CallOption) (*UserBalanceResponse, error) {
+	out := new(UserBalanceResponse)
+	err := c.cc.Invoke(ctx, "/coinapi.v1.UserService/GetBalance", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) LookupUser(ctx context.Context, in *LookupUserRequest, opts ...grpc.CallOption) (*UserProfileResponse, error) {
+	out := new(UserProfileResponse)
+	err := c.cc.Invoke(ctx, "/coinapi.v1.UserService/LookupUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) UpdateUser(ctx context.Context, in *UpdateUserRequest, opts ...grpc.CallOption) (*UserProfileResponse, error) {
+	out := new(UserProfileResponse)
+	err := c.cc.Invoke(ctx, "/coinapi.v1.UserService/UpdateUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UserServiceServer is the server API for UserService service.
+// All implementations must embed UnimplementedUserServiceServer
+// for forward compatibility
+type UserServiceServer interface {
+	// ユーザー登録
+	RegisterUser(context.Context, *RegisterUserRequest) (*UserResponse, error)
+	// 対象ユーザー残高取得
+	GetBalance(context.Context, *GetBalanceRequest) (*UserBalanceResponse, error)
+	// ユーザー名でユーザー検索
+	LookupUser(context.Context, *LookupUserRequest) (*UserProfileResponse, error)
+	// ユーザー名・パスワード変更
+	UpdateUser(context.Context, *UpdateUserRequest) (*UserProfileResponse, error)
+	mustEmbedUnimplementedUserServiceServer()
+}
+
+// UnimplementedUserServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedUserServiceServer struct {
+}
+
+func (UnimplementedUserServiceServer) RegisterUser(context.Context, *RegisterUserRequest) (*UserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterUser not implemented")
+}
+func (UnimplementedUserServiceServer) GetBalance(context.Context, *GetBalanceRequest) (*UserBalanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalance not implemented")
+}
+func (UnimplementedUserServiceServer) LookupUser(context.Context, *LookupUserRequest) (*UserProfileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LookupUser not implemented")
+}
+func (UnimplementedUserServiceServer) UpdateUser(context.Context, *UpdateUserRequest) (*UserProfileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateUser not implemented")
+}
+func (UnimplementedUserServiceServer) mustEmbedUnimplementedUserServiceServer() {}
+
+// UnsafeUserServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to UserServiceServer will
+// result in compilation errors.
+type UnsafeUserServiceServer interface {
+	mustEmbedUnimplementedUserServiceServer()
+}
+
+func RegisterUserServiceServer(s grpc.ServiceRegistrar, srv UserServiceServer) {
+	s.RegisterService(&UserService_ServiceDesc, srv)
+}
+
+func _UserService_RegisterUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).RegisterUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coinapi.v1.UserService/RegisterUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).RegisterUser(ctx, req.(*RegisterUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_GetBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).GetBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coinapi.v1.UserService/GetBalance",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).GetBalance(ctx, req.(*GetBalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_LookupUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).LookupUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coinapi.v1.UserService/LookupUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).LookupUser(ctx, req.(*LookupUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_UpdateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).UpdateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coinapi.v1.UserService/UpdateUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).UpdateUser(ctx, req.(*UpdateUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// UserService_ServiceDesc is the grpc.ServiceDesc for UserService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var UserService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "coinapi.v1.UserService",
+	HandlerType: (*UserServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RegisterUser",
+			Handler:    _UserService_RegisterUser_Handler,
+		},
+		{
+			MethodName: "GetBalance",
+			Handler:    _UserService_GetBalance_Handler,
+		},
+		{
+			MethodName: "LookupUser",
+			Handler:    _UserService_LookupUser_Handler,
+		},
+		{
+			MethodName: "UpdateUser",
+			Handler:    _UserService_UpdateUser_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "coin_api.proto",
+}
+
+// CoinServiceClient is the client API for CoinService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CoinServiceClient interface {
+	// コイン追加消費
+	AddUseCoin(ctx context.Context, in *AddUseCoinRequest, opts ...grpc.CallOption) (*CoinResponse, error)
+	// コイン送金
+	SendCoin(ctx context.Context, in *SendCoinRequest, opts ...grpc.CallOption) (*SendCoinResponse, error)
+	// 承認待ち送金の承認
+	AcceptTransfer(ctx context.Context, in *ResolveTransferRequest, opts ...grpc.CallOption) (*CoinTransferResponse, error)
+	// 承認待ち送金の拒否
+	RejectTransfer(ctx context.Context, in *ResolveTransferRequest, opts ...grpc.CallOption) (*CoinTransferResponse, error)
+	// コイン履歴取消
+	ReverseHistory(ctx context.Context, in *ReverseHistoryRequest, opts ...grpc.CallOption) (*CoinReversalResponse, error)
+	// コイン履歴確認
+	GetHistories(ctx context.Context, in *GetHistoriesRequest, opts ...grpc.CallOption) (*CoinHistoriesResponse, error)
+}
+
+type coinServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCoinServiceClient(cc grpc.ClientConnInterface) CoinServiceClient {
+	return &coinServiceClient{cc}
+}
+
+func (c *coinServiceClient) AddUseCoin(ctx context.Context, in *AddUseCoinRequest, opts ...grpc.CallOption) (*CoinResponse, error) {
+	out := new(CoinResponse)
+	err := c.cc.Invoke(ctx, "/coinapi.v1.CoinService/AddUseCoin", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coinServiceClient) SendCoin(ctx context.Context, in *SendCoinRequest, opts ...grpc.CallOption) (*SendCoinResponse, error) {
+	out := new(SendCoinResponse)
+	err := c.cc.Invoke(ctx, "/coinapi.v1.CoinService/SendCoin", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coinServiceClient) AcceptTransfer(ctx context.Context, in *ResolveTransferRequest, opts ...grpc.CallOption) (*CoinTransferResponse, error) {
+	out := new(CoinTransferResponse)
+	err := c.cc.Invoke(ctx, "/coinapi.v1.CoinService/AcceptTransfer", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coinServiceClient) RejectTransfer(ctx context.Context, in *ResolveTransferRequest, opts ...grpc.CallOption) (*CoinTransferResponse, error) {
+	out := new(CoinTransferResponse)
+	err := c.cc.Invoke(ctx, "/coinapi.v1.CoinService/RejectTransfer", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coinServiceClient) ReverseHistory(ctx context.Context, in *ReverseHistoryRequest, opts ...grpc.CallOption) (*CoinReversalResponse, error) {
+	out := new(CoinReversalResponse)
+	err := c.cc.Invoke(ctx, "/coinapi.v1.CoinService/ReverseHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coinServiceClient) GetHistories(ctx context.Context, in *GetHistoriesRequest, opts ...grpc.CallOption) (*CoinHistoriesResponse, error) {
+	out := new(CoinHistoriesResponse)
+	err := c.cc.Invoke(ctx, "/coinapi.v1.CoinService/GetHistories", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CoinServiceServer is the server API for CoinService service.
+// All implementations must embed UnimplementedCoinServiceServer
+// for forward compatibility
+type CoinServiceServer interface {
+	// コイン追加消費
+	AddUseCoin(context.Context, *AddUseCoinRequest) (*CoinResponse, error)
+	// コイン送金
+	SendCoin(context.Context, *SendCoinRequest) (*SendCoinResponse, error)
+	// 承認待ち送金の承認
+	AcceptTransfer(context.Context, *ResolveTransferRequest) (*CoinTransferResponse, error)
+	// 承認待ち送金の拒否
+	RejectTransfer(context.Context, *ResolveTransferRequest) (*CoinTransferResponse, error)
+	// コイン履歴取消
+	ReverseHistory(context.Context, *ReverseHistoryRequest) (*CoinReversalResponse, error)
+	// コイン履歴確認
+	GetHistories(context.Context, *GetHistoriesRequest) (*CoinHistoriesResponse, error)
+	mustEmbedUnimplementedCoinServiceServer()
+}
+
+// UnimplementedCoinServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedCoinServiceServer struct {
+}
+
+func (UnimplementedCoinServiceServer) AddUseCoin(context.Context, *AddUseCoinRequest) (*CoinResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddUseCoin not implemented")
+}
+func (UnimplementedCoinServiceServer) SendCoin(context.Context, *SendCoinRequest) (*SendCoinResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendCoin not implemented")
+}
+func (UnimplementedCoinServiceServer) AcceptTransfer(context.Context, *ResolveTransferRequest) (*CoinTransferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcceptTransfer not implemented")
+}
+func (UnimplementedCoinServiceServer) RejectTransfer(context.Context, *ResolveTransferRequest) (*CoinTransferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RejectTransfer not implemented")
+}
+func (UnimplementedCoinServiceServer) ReverseHistory(context.Context, *ReverseHistoryRequest) (*CoinReversalResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReverseHistory not implemented")
+}
+func (UnimplementedCoinServiceServer) GetHistories(context.Context, *GetHistoriesRequest) (*CoinHistoriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHistories not implemented")
+}
+func (UnimplementedCoinServiceServer) mustEmbedUnimplementedCoinServiceServer() {}
+
+// UnsafeCoinServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CoinServiceServer will
+// result in compilation errors.
+type UnsafeCoinServiceServer interface {
+	mustEmbedUnimplementedCoinServiceServer()
+}
+
+func RegisterCoinServiceServer(s grpc.ServiceRegistrar, srv CoinServiceServer) {
+	s.RegisterService(&CoinService_ServiceDesc, srv)
+}
+
+func _CoinService_AddUseCoin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddUseCoinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoinServiceServer).AddUseCoin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coinapi.v1.CoinService/AddUseCoin",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoinServiceServer).AddUseCoin(ctx, req.(*AddUseCoinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoinService_SendCoin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendCoinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoinServiceServer).SendCoin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coinapi.v1.CoinService/SendCoin",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoinServiceServer).SendCoin(ctx, req.(*SendCoinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoinService_AcceptTransfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResolveTransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoinServiceServer).AcceptTransfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coinapi.v1.CoinService/AcceptTransfer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoinServiceServer).AcceptTransfer(ctx, req.(*ResolveTransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoinService_RejectTransfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResolveTransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoinServiceServer).RejectTransfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coinapi.v1.CoinService/RejectTransfer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoinServiceServer).RejectTransfer(ctx, req.(*ResolveTransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoinService_ReverseHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReverseHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoinServiceServer).ReverseHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coinapi.v1.CoinService/ReverseHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoinServiceServer).ReverseHistory(ctx, req.(*ReverseHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoinService_GetHistories_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHistoriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoinServiceServer).GetHistories(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coinapi.v1.CoinService/GetHistories",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoinServiceServer).GetHistories(ctx, req.(*GetHistoriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CoinService_ServiceDesc is the grpc.ServiceDesc for CoinService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CoinService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "coinapi.v1.CoinService",
+	HandlerType: (*CoinServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddUseCoin",
+			Handler:    _CoinService_AddUseCoin_Handler,
+		},
+		{
+			MethodName: "SendCoin",
+			Handler:    _CoinService_SendCoin_Handler,
+		},
+		{
+			MethodName: "AcceptTransfer",
+			Handler:    _CoinService_AcceptTransfer_Handler,
+		},
+		{
+			MethodName: "RejectTransfer",
+			Handler:    _CoinService_RejectTransfer_Handler,
+		},
+		{
+			MethodName: "ReverseHistory",
+			Handler:    _CoinService_ReverseHistory_Handler,
+		},
+		{
+			MethodName: "GetHistories",
+			Handler:    _CoinService_GetHistories_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "coin_api.proto",
+}
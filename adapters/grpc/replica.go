@@ -0,0 +1,42 @@
+package services
+
+import (
+	"coin-api/config"
+	"coin-api/database"
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"gorm.io/gorm"
+	"time"
+)
+
+// readAfterKey 更新時刻のメタデータキー(REST APIのX-Read-Afterと同じ形式)
+const readAfterKey = "x-read-after"
+
+// readConn リポジトリ用のDB接続(参照系メソッドはレプリカ、それ以外はプライマリ、トランザクションは常にプライマリ)
+func readConn(connector *database.PostgreSQLConnector, ctx context.Context) *gorm.DB {
+	method, _ := grpc.Method(ctx)
+	if !readOnlyMethods[method] {
+		return tenantConn(connector.Conn, ctx)
+	}
+
+	// 指定された時刻までの更新が反映されたDBから参照(不正な形式の場合は指定なしとして扱う)
+	notBefore := time.Time{}
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(readAfterKey)) > 0 && config.LoadConfig().ReplicaInfo.ReadYourWrites {
+		if t, err := time.Parse(time.RFC3339Nano, md.Get(readAfterKey)[0]); err == nil {
+			notBefore = t
+		}
+	}
+	return tenantConn(connector.Reader(notBefore), ctx)
+}
+
+// ReadYourWritesInterceptor 更新系メソッドの成功時にx-read-afterをレスポンスヘッダーへ付与
+func ReadYourWritesInterceptor(conf *config.ReplicaInfo) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		res, err := handler(ctx, req)
+		if err == nil && conf.ReadYourWrites && !readOnlyMethods[info.FullMethod] {
+			_ = grpc.SetHeader(ctx, metadata.Pairs(readAfterKey, time.Now().UTC().Format(time.RFC3339Nano)))
+		}
+		return res, err
+	}
+}
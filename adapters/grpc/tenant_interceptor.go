@@ -0,0 +1,41 @@
+package services
+
+import (
+	"coin-api/common/tenant"
+	"coin-api/config"
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// tenantIdKey テナントIDのメタデータキー(未指定の場合は既定のテナント)
+const tenantIdKey = "x-tenant-id"
+
+// TenantInterceptor リクエストのテナントの解決(設定にないテナントはエラー)
+func TenantInterceptor(conf *config.TenantsInfo) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		id := ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(tenantIdKey)) > 0 {
+			id = md.Get(tenantIdKey)[0]
+		}
+		if id == "" {
+			id = conf.DefaultId
+		}
+		if conf.Find(id) == nil {
+			log.Log().Msg(fmt.Sprintf("テナントが存在しません テナントID : %s", id))
+			return nil, status.Error(codes.InvalidArgument, "テナントが存在しません")
+		}
+
+		return handler(tenant.WithTenant(ctx, id), req)
+	}
+}
+
+// tenantConn リクエストのテナントを設定したDB接続(rdbのリポジトリはDB接続のテナントで検索条件を絞り込む)
+func tenantConn(conn *gorm.DB, ctx context.Context) *gorm.DB {
+	return conn.WithContext(tenant.WithTenant(context.Background(), tenant.FromContext(ctx)))
+}
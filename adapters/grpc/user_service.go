@@ -0,0 +1,113 @@
+package services
+
+import (
+	"coin-api/adapters/controller"
+	"coin-api/adapters/grpc/pb"
+	"coin-api/database"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"coin-api/usecase/presenter"
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+)
+
+type UserService struct {
+	pb.UnimplementedUserServiceServer
+	InputFactory                   controllers.UserInputFactory
+	UserRepositoryFactory          controllers.UserRepositoryFactory
+	OutboxRepositoryFactory        controllers.OutboxRepositoryFactory
+	TxRepositoryFactory            controllers.TxRepositoryFactory
+	PasswordResetRepositoryFactory controllers.PasswordResetRepositoryFactory
+	BalanceSubscriber              ports.BalanceSubscriber
+	PasswordResetNotifier          ports.PasswordResetNotifier
+	ClientFactory                  *database.PostgreSQLConnector
+}
+
+func NewUserService(inputFactory controllers.UserInputFactory, userRepositoryFactory controllers.UserRepositoryFactory, outboxRepositoryFactory controllers.OutboxRepositoryFactory, txRepositoryFactory controllers.TxRepositoryFactory, passwordResetRepositoryFactory controllers.PasswordResetRepositoryFactory, balanceSubscriber ports.BalanceSubscriber, passwordResetNotifier ports.PasswordResetNotifier, clientFactory *database.PostgreSQLConnector) *UserService {
+	return &UserService{
+		InputFactory:                   inputFactory,
+		UserRepositoryFactory:          userRepositoryFactory,
+		OutboxRepositoryFactory:        outboxRepositoryFactory,
+		TxRepositoryFactory:            txRepositoryFactory,
+		PasswordResetRepositoryFactory: passwordResetRepositoryFactory,
+		BalanceSubscriber:              balanceSubscriber,
+		PasswordResetNotifier:          passwordResetNotifier,
+		ClientFactory:                  clientFactory,
+	}
+}
+
+func (u *UserService) RegisterUser(ctx context.Context, req *pb.RegisterUserRequest) (*pb.UserResponse, error) {
+	// request情報をformにマッピング
+	form := model.UserAddForm{
+		UserName: req.GetUsername(),
+		Password: req.GetPassword(),
+	}
+
+	// ユーザー登録処理実行
+	op := presenter.NewGrpcUserOutputPort()
+	if err := u.newInputPort(ctx, op).RegisterUser(ctx, &form); err != nil {
+		log.Error().Stack().Err(err).Send()
+	}
+	if op.Err != nil {
+		return nil, op.Err
+	}
+	return op.User, nil
+}
+
+func (u *UserService) GetBalance(ctx context.Context, req *pb.GetBalanceRequest) (*pb.UserBalanceResponse, error) {
+	// コイン残高取得処理実行
+	op := presenter.NewGrpcUserOutputPort()
+	if err := u.newInputPort(ctx, op).GetBalanceByUserId(fmt.Sprint(req.GetUserid())); err != nil {
+		log.Error().Stack().Err(err).Send()
+	}
+	if op.Err != nil {
+		return nil, op.Err
+	}
+	return op.Balance, nil
+}
+
+func (u *UserService) LookupUser(ctx context.Context, req *pb.LookupUserRequest) (*pb.UserProfileResponse, error) {
+	// request情報をformにマッピング
+	form := model.UserLookupForm{
+		UserName: req.GetUsername(),
+	}
+
+	// ユーザー検索処理実行
+	op := presenter.NewGrpcUserOutputPort()
+	if err := u.newInputPort(ctx, op).LookupUser(&form); err != nil {
+		log.Error().Stack().Err(err).Send()
+	}
+	if op.Err != nil {
+		return nil, op.Err
+	}
+	return op.Profile, nil
+}
+
+func (u *UserService) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UserProfileResponse, error) {
+	// request情報をformにマッピング
+	form := model.UserUpdateForm{
+		UserName:    req.GetUsername(),
+		Password:    req.GetPassword(),
+		OldPassword: req.GetOldPassword(),
+	}
+
+	// ユーザー名・パスワード変更処理実行
+	op := presenter.NewGrpcUserOutputPort()
+	if err := u.newInputPort(ctx, op).UpdateUser(ctx, fmt.Sprint(req.GetUserid()), &form); err != nil {
+		log.Error().Stack().Err(err).Send()
+	}
+	if op.Err != nil {
+		return nil, op.Err
+	}
+	return op.Profile, nil
+}
+
+func (u *UserService) newInputPort(ctx context.Context, op ports.UserOutputPort) ports.UserInputPort {
+	conn := readConn(u.ClientFactory, ctx)
+	ur := u.UserRepositoryFactory(conn)
+	obr := u.OutboxRepositoryFactory(conn)
+	tr := u.TxRepositoryFactory(tenantConn(u.ClientFactory.Conn, ctx))
+	prr := u.PasswordResetRepositoryFactory(conn)
+	return u.InputFactory(op, ur, obr, tr, prr, u.BalanceSubscriber, u.PasswordResetNotifier)
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"coin-api/common/apikey"
+	"coin-api/common/enum"
+	models "coin-api/domain/model"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ApiKeyHeader APIキーのヘッダー(Authorization: Bearer <APIキー> も可)
+	ApiKeyHeader = "X-Api-Key"
+	// AuthApiKeyKey APIキーで認証済みであることを格納するgin.Contextのキー(値はAPIキーのプレフィックス)
+	AuthApiKeyKey = "auth_api_key"
+	// lastUsedInterval 最終利用日時を更新する間隔(リクエストごとの更新を避ける)
+	lastUsedInterval = time.Minute
+)
+
+// ApiKeyAuth APIキーの認証とスコープの確認
+// APIキーが指定されていない場合は何もしない。scopesは"METHOD パス"ごとに必要なスコープ(いずれか)で、定義のないルートはAPIキーで利用できない
+func ApiKeyAuth(ar repository.IApiKeyRepository, scopes map[string][]enum.ApiKeyScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := apiKeyOf(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		// ハッシュで失効していないAPIキーを検索
+		k, err := ar.SelectActiveByHash(models.ApiKeyHash(key))
+		if err != nil {
+			log.Error().Stack().Err(err).Send()
+			c.AbortWithStatusJSON(http.StatusInternalServerError, model.CreateServerErrorResponse(err))
+			return
+		}
+		if k == nil {
+			log.Log().Msg(fmt.Sprintf("APIキー認証エラー クライアントIP : %s", c.ClientIP()))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, model.CreateErrorResponse(http.StatusUnauthorized, "APIキーが無効です"))
+			return
+		}
+
+		// ルートに必要なスコープの確認
+		p := &apikey.Principal{KeyId: k.ID, Prefix: k.Prefix, TenantId: k.TenantId, Scopes: parseScopes(k.Scopes), UserId: k.UserId}
+		required, ok := scopes[c.Request.Method+" "+c.FullPath()]
+		if !ok || !p.HasScope(required...) {
+			log.Log().Msg(fmt.Sprintf("APIキーのスコープ不足 プレフィックス : %s エンドポイント : %s %s", k.Prefix, c.Request.Method, c.FullPath()))
+			c.AbortWithStatusJSON(http.StatusForbidden, model.CreateErrorResponse(http.StatusForbidden, "APIキーにこの操作のスコープがありません"))
+			return
+		}
+
+		// ユーザーに発行したAPIキーは発行対象のユーザーとして認証(他のユーザーのリソースは利用不可)
+		if k.UserId != nil {
+			uid := strconv.FormatUint(uint64(*k.UserId), 10)
+			if param := c.Param("userid"); param != "" && param != uid {
+				log.Log().Msg(fmt.Sprintf("APIキーの発行対象外のユーザー プレフィックス : %s ユーザーID : %s", k.Prefix, param))
+				c.AbortWithStatusJSON(http.StatusForbidden, model.CreateErrorResponse(http.StatusForbidden, "APIキーの発行対象のユーザー以外は操作できません"))
+				return
+			}
+			c.Set(AuthUserKey, uid)
+		}
+
+		// 最終利用日時の更新(失敗しても処理は継続)
+		now := time.Now()
+		if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= lastUsedInterval {
+			if err := ar.UpdateLastUsed(k.ID, now); err != nil {
+				log.Error().Stack().Err(err).Send()
+			}
+		}
+
+		c.Set(AuthApiKeyKey, k.Prefix)
+		// 管理者スコープのAPIキーは管理者として認証
+		if p.HasScope(enum.SCOPE_ADMIN) {
+			c.Set(AuthAdminKey, true)
+		}
+		c.Request = c.Request.WithContext(apikey.WithPrincipal(c.Request.Context(), p))
+		c.Next()
+	}
+}
+
+// RequireAdmin 管理者スコープのAPIキーで認証済みであることを確認(管理者API用)
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool(AuthAdminKey) {
+			log.Log().Msg(fmt.Sprintf("管理者APIの認証エラー クライアントIP : %s エンドポイント : %s %s", c.ClientIP(), c.Request.Method, c.FullPath()))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, model.CreateErrorResponse(http.StatusUnauthorized, "管理者スコープのAPIキーが必要です"))
+			return
+		}
+		c.Next()
+	}
+}
+
+func apiKeyOf(c *gin.Context) string {
+	if key := c.GetHeader(ApiKeyHeader); key != "" {
+		return key
+	}
+	if auth := c.GetHeader("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	return ""
+}
+
+// parseScopes カンマ区切りのスコープを分割
+func parseScopes(s string) []enum.ApiKeyScope {
+	scopes := make([]enum.ApiKeyScope, 0)
+	for _, v := range strings.Split(s, ",") {
+		if v != "" {
+			scopes = append(scopes, enum.ApiKeyScope(v))
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"bytes"
+	"coin-api/common/audit"
+	"coin-api/common/enum"
+	"coin-api/common/tenant"
+	models "coin-api/domain/model"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// RequestIdHeader リクエストIDのヘッダー(未指定の場合は採番)
+	RequestIdHeader = "X-Request-Id"
+	// AuthAdminKey 管理者として認証済みであることを格納するgin.Contextのキー(管理者スコープのAPIキーで認証した場合に設定)
+	AuthAdminKey = "auth_admin"
+	// maxAuditBody 監査ログに記録するリクエストボディの上限
+	maxAuditBody = 64 * 1024
+)
+
+// Audit 状態変更リクエストの監査ログ記録
+// 変更がtransaction内で行われる場合は同一transactionで、それ以外はレスポンス後に登録する
+func Audit(ar repository.IAuditRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// リクエストIDの採番
+		requestId := c.GetHeader(RequestIdHeader)
+		if requestId == "" {
+			requestId = audit.NewRequestId()
+		}
+		c.Header(RequestIdHeader, requestId)
+
+		// 参照系は記録しない
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		// リクエストボディを読み取り、後続処理用に戻す
+		body, _ := io.ReadAll(io.LimitReader(c.Request.Body, maxAuditBody))
+		c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+
+		actorType, actor := actorOf(c)
+		entry := &models.AuditLog{
+			TenantId:  tenant.FromContext(c.Request.Context()),
+			RequestId: requestId,
+			ActorType: string(actorType),
+			Actor:     actor,
+			Method:    c.Request.Method,
+			Endpoint:  c.FullPath(),
+			Payload:   audit.RedactPayload(body),
+			ClientIP:  c.ClientIP(),
+			CreatedAt: time.Now(),
+		}
+		c.Request = c.Request.WithContext(audit.WithEntry(c.Request.Context(), entry))
+
+		// レスポンスボディを保持
+		w := &bodyWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+
+		c.Next()
+
+		// transaction内で登録済みの場合は終了(監査ログは更新できないため、記録と異なるステータスを返却した場合は警告のみ)
+		if entry.ID != 0 {
+			if status := c.Writer.Status(); status != entry.StatusCode {
+				log.Warn().Msg(fmt.Sprintf("監査ログのステータスコードがレスポンスと異なります リクエストID : %s 記録 : %d レスポンス : %d", requestId, entry.StatusCode, status))
+			}
+			return
+		}
+		entry.StatusCode = c.Writer.Status()
+		entry.Result = string(enum.AUDIT_SUCCESS)
+		if entry.StatusCode >= http.StatusBadRequest {
+			entry.Result = string(enum.AUDIT_FAILURE)
+			var res model.ErrorResponse
+			if err := json.Unmarshal(w.body.Bytes(), &res); err == nil {
+				entry.ErrorMessage = res.Message
+			}
+		}
+		if _, err := ar.Insert(context.Background(), entry); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+}
+
+func actorOf(c *gin.Context) (enum.ActorType, string) {
+	uid := c.GetString(AuthUserKey)
+	prefix := c.GetString(AuthApiKeyKey)
+	switch {
+	case c.GetBool(AuthAdminKey):
+		return enum.ACTOR_ADMIN, prefix
+	case prefix != "":
+		return enum.ACTOR_API_KEY, prefix
+	case uid != "":
+		return enum.ACTOR_USER, uid
+	default:
+		return enum.ACTOR_ANONYMOUS, ""
+	}
+}
+
+// bodyWriter エラー内容の記録用にレスポンスボディを保持
+type bodyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyWriter) Write(b []byte) (int, error) {
+	if w.body.Len() < maxAuditBody {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	if w.body.Len() < maxAuditBody {
+		w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
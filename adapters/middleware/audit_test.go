@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"coin-api/common/enum"
+	models "coin-api/domain/model"
+	"coin-api/domain/repository"
+	"context"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeAuditRepository struct {
+	repository.IAuditRepository
+	entries []*models.AuditLog
+}
+
+func (f *fakeAuditRepository) Insert(_ context.Context, entry *models.AuditLog) (*models.AuditLog, error) {
+	f.entries = append(f.entries, entry)
+	return entry, nil
+}
+
+func TestAuditAdminActor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ar := &fakeApiKeyRepository{keys: map[string]*models.ApiKey{
+		models.ApiKeyHash("ck_admin_secret"): {Prefix: "ck_admin", Scopes: "admin"},
+		models.ApiKeyHash("ck_svc_secret"):   {Prefix: "ck_svc", Scopes: "coin:add"},
+	}}
+	audits := &fakeAuditRepository{}
+	r := gin.New()
+	r.Use(ApiKeyAuth(ar, map[string][]enum.ApiKeyScope{"POST /admin/api-keys": {enum.SCOPE_ADMIN}}))
+	r.Use(Audit(audits))
+	ag := r.Group("/admin")
+	ag.Use(RequireAdmin())
+	ag.POST("/api-keys", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name      string
+		key       string
+		want      int
+		wantType  enum.ActorType
+		wantActor string
+	}{
+		// 管理者スコープのAPIキーは管理者としてプレフィックスを記録
+		{name: "admin key", key: "ck_admin_secret", want: http.StatusOK, wantType: enum.ACTOR_ADMIN, wantActor: "ck_admin"},
+		// 管理者スコープのないAPIキーはスコープ不足(監査ログより前に拒否)
+		{name: "service key", key: "ck_svc_secret", want: http.StatusForbidden},
+		// APIキーなしは認証エラー
+		{name: "anonymous", want: http.StatusUnauthorized, wantType: enum.ACTOR_ANONYMOUS},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audits.entries = nil
+			req := httptest.NewRequest(http.MethodPost, "/admin/api-keys", nil)
+			if tt.key != "" {
+				req.Header.Set(ApiKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if tt.wantType == "" {
+				if len(audits.entries) != 0 {
+					t.Errorf("audit entries = %d, want none", len(audits.entries))
+				}
+				return
+			}
+			if len(audits.entries) != 1 {
+				t.Fatalf("audit entries = %d, want 1", len(audits.entries))
+			}
+			e := audits.entries[0]
+			if e.ActorType != string(tt.wantType) || e.Actor != tt.wantActor || e.StatusCode != tt.want {
+				t.Errorf("audit entry = %s %q %d, want %s %q %d", e.ActorType, e.Actor, e.StatusCode, tt.wantType, tt.wantActor, tt.want)
+			}
+		})
+	}
+}
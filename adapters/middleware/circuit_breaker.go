@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"coin-api/database"
+	"coin-api/usecase/model"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// CircuitBreaker DB接続の遮断中はリクエストを処理せず503を即時返却
+// retryAfterは遮断が解除されるまでの時間(遮断中でない場合は0)
+func CircuitBreaker(retryAfter func() time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if d := retryAfter(); d > 0 {
+			log.Log().Msg("DB接続の遮断中のためリクエストを拒否しました")
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(d)))
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, model.CreateErrorResponse(http.StatusServiceUnavailable, database.ErrUnavailable.Error()))
+			return
+		}
+		c.Next()
+	}
+}
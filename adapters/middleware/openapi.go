@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"coin-api/adapters/openapi"
+	"coin-api/usecase/model"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"net/http"
+)
+
+// RequestValidator API定義(OpenAPI)に一致しないリクエストは処理せず400を返却
+func RequestValidator(doc func() *openapi.Document) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := doc().ValidateRequest(c.Request, c.FullPath()); err != nil {
+			log.Log().Msg(fmt.Sprintf("API定義に一致しないリクエスト エンドポイント : %s %s : %v", c.Request.Method, c.FullPath(), err))
+			c.AbortWithStatusJSON(http.StatusBadRequest, model.CreateErrorResponse(http.StatusBadRequest, err.Error()))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"coin-api/config"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// AuthUserKey 認証済みユーザーIDを格納するgin.Contextのキー(ユーザーに発行したAPIキーで認証した場合に設定)
+const AuthUserKey = "auth_userid"
+
+// RateLimit 認証済みユーザーID(未認証の場合はクライアントIP)ごとのレート制限(参照系と更新系で別の上限)
+func RateLimit(store ports.RateLimitStore, conf *config.RateLimitInfo) gin.HandlerFunc {
+	read := ports.RateLimit{Rate: conf.ReadRate, Burst: conf.ReadBurst}
+	write := ports.RateLimit{Rate: conf.WriteRate, Burst: conf.WriteBurst}
+
+	return func(c *gin.Context) {
+		// 参照系か更新系かで上限を切り替え
+		class, limit := "write", write
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			class, limit = "read", read
+		}
+		if limit.Rate <= 0 || limit.Burst <= 0 {
+			c.Next()
+			return
+		}
+
+		res, err := store.Take(c.Request.Context(), rateLimitKey(c, class), limit, time.Now())
+		if err != nil {
+			// 保存先の障害時はリクエストを制限しない
+			log.Error().Stack().Err(err).Send()
+			c.Next()
+			return
+		}
+
+		// RateLimitヘッダー付与
+		c.Header("RateLimit-Limit", strconv.Itoa(limit.Burst))
+		c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+		if !res.Allowed {
+			log.Log().Msg(fmt.Sprintf("レート制限超過 key : %s", rateLimitKey(c, class)))
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, model.CreateErrorResponse(http.StatusTooManyRequests, "リクエスト数が上限を超えています"))
+			return
+		}
+		c.Next()
+	}
+}
+
+func rateLimitKey(c *gin.Context, class string) string {
+	if uid := c.GetString(AuthUserKey); uid != "" {
+		return "ratelimit:" + class + ":user:" + uid
+	}
+	if prefix := c.GetString(AuthApiKeyKey); prefix != "" {
+		return "ratelimit:" + class + ":apikey:" + prefix
+	}
+	return "ratelimit:" + class + ":ip:" + c.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"coin-api/common/enum"
+	"coin-api/config"
+	models "coin-api/domain/model"
+	"coin-api/domain/repository"
+	"coin-api/usecase/port"
+	"context"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeApiKeyRepository struct {
+	repository.IApiKeyRepository
+	keys map[string]*models.ApiKey
+}
+
+func (f *fakeApiKeyRepository) SelectActiveByHash(keyHash string) (*models.ApiKey, error) {
+	return f.keys[keyHash], nil
+}
+
+func (f *fakeApiKeyRepository) UpdateLastUsed(uint, time.Time) error {
+	return nil
+}
+
+// recordingStore 取得したキーを記録し、常に許可する
+type recordingStore struct {
+	keys []string
+}
+
+func (r *recordingStore) Take(_ context.Context, key string, limit ports.RateLimit, _ time.Time) (*ports.RateLimitResult, error) {
+	r.keys = append(r.keys, key)
+	return &ports.RateLimitResult{Allowed: true, Remaining: limit.Burst - 1}, nil
+}
+
+func TestRateLimitKeyByApiKeyUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	uid := uint(1)
+	ar := &fakeApiKeyRepository{keys: map[string]*models.ApiKey{
+		models.ApiKeyHash("ck_user_secret"): {Prefix: "ck_user", Scopes: "coin:read", UserId: &uid},
+		models.ApiKeyHash("ck_svc_secret"):  {Prefix: "ck_svc", Scopes: "coin:read"},
+	}}
+	store := &recordingStore{}
+	r := gin.New()
+	r.Use(ApiKeyAuth(ar, map[string][]enum.ApiKeyScope{"GET /coin/:userid": {enum.SCOPE_COIN_READ}}))
+	r.Use(RateLimit(store, &config.RateLimitInfo{ReadRate: 60, ReadBurst: 10}))
+	r.GET("/coin/:userid", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name    string
+		key     string
+		path    string
+		want    int
+		wantKey string
+	}{
+		// ユーザーに発行したAPIキーはユーザーごとに制限
+		{name: "user key", key: "ck_user_secret", path: "/coin/1", want: http.StatusOK, wantKey: "ratelimit:read:user:1"},
+		// 発行対象以外のユーザーは操作不可
+		{name: "other user", key: "ck_user_secret", path: "/coin/2", want: http.StatusForbidden},
+		// ユーザーに紐づかないAPIキーはAPIキーごとに制限
+		{name: "service key", key: "ck_svc_secret", path: "/coin/2", want: http.StatusOK, wantKey: "ratelimit:read:apikey:ck_svc"},
+		// 未認証の場合はクライアントIPごとに制限
+		{name: "anonymous", path: "/coin/1", want: http.StatusOK, wantKey: "ratelimit:read:ip:192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store.keys = nil
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set(ApiKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if tt.wantKey == "" {
+				if len(store.keys) != 0 {
+					t.Errorf("rate limit keys = %v, want none", store.keys)
+				}
+				return
+			}
+			if len(store.keys) != 1 || store.keys[0] != tt.wantKey {
+				t.Errorf("rate limit keys = %v, want [%s]", store.keys, tt.wantKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"coin-api/config"
+	"coin-api/database"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+const (
+	// ReadAfterHeader 更新系のレスポンスで返却する更新時刻(参照系のリクエストで指定するとその時刻までの更新が反映されたDBから参照する)
+	ReadAfterHeader = "X-Read-After"
+)
+
+// ReadYourWrites レプリカ参照時の自身の更新の反映保証
+// 更新系の成功レスポンスにX-Read-Afterを付与し、参照系のリクエストで指定されたX-Read-Afterをcontextへ設定する
+func ReadYourWrites(conf *config.ReplicaInfo) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !conf.ReadYourWrites {
+			c.Next()
+			return
+		}
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead:
+			// 不正な形式の場合は指定なしとして扱う
+			if t, err := time.Parse(time.RFC3339Nano, c.GetHeader(ReadAfterHeader)); err == nil {
+				c.Request = c.Request.WithContext(database.WithReadAfter(c.Request.Context(), t))
+			}
+		case http.MethodOptions:
+		default:
+			w := &readAfterWriter{ResponseWriter: c.Writer}
+			c.Writer = w
+			c.Next()
+			// ボディのないレスポンスもヘッダー送信前に付与
+			w.WriteHeaderNow()
+			return
+		}
+		c.Next()
+	}
+}
+
+// readAfterWriter レスポンスヘッダーの送信時(コミット後)に成功レスポンスへX-Read-Afterを付与
+type readAfterWriter struct {
+	gin.ResponseWriter
+	stamped bool
+}
+
+func (w *readAfterWriter) stamp() {
+	if w.stamped {
+		return
+	}
+	w.stamped = true
+	if !w.Written() && w.Status() < http.StatusBadRequest {
+		w.Header().Set(ReadAfterHeader, time.Now().UTC().Format(time.RFC3339Nano))
+	}
+}
+
+func (w *readAfterWriter) WriteHeaderNow() {
+	w.stamp()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *readAfterWriter) Write(data []byte) (int, error) {
+	w.stamp()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *readAfterWriter) WriteString(s string) (int, error) {
+	w.stamp()
+	return w.ResponseWriter.WriteString(s)
+}
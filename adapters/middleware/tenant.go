@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"coin-api/common/apikey"
+	"coin-api/common/tenant"
+	"coin-api/config"
+	"coin-api/usecase/model"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"net/http"
+)
+
+const (
+	// TenantHeader テナントIDのヘッダー(未指定の場合は既定のテナント)
+	TenantHeader = "X-Tenant-Id"
+)
+
+// Tenant リクエストのテナントの解決
+// APIキーでのリクエストはAPIキーのテナント、それ以外はX-Tenant-Idのテナントとし、設定にないテナントはエラーとする
+func Tenant(conf *config.TenantsInfo) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(TenantHeader)
+		if p := apikey.FromContext(c.Request.Context()); p != nil {
+			if id != "" && id != p.TenantId {
+				log.Log().Msg(fmt.Sprintf("APIキーと異なるテナントの指定 プレフィックス : %s テナントID : %s", p.Prefix, id))
+				c.AbortWithStatusJSON(http.StatusForbidden, model.CreateErrorResponse(http.StatusForbidden, "APIキーのテナント以外は指定できません"))
+				return
+			}
+			id = p.TenantId
+		}
+		if id == "" {
+			id = conf.DefaultId
+		}
+		if conf.Find(id) == nil {
+			log.Log().Msg(fmt.Sprintf("テナントが存在しません テナントID : %s", id))
+			c.AbortWithStatusJSON(http.StatusBadRequest, model.CreateErrorResponse(http.StatusBadRequest, "テナントが存在しません"))
+			return
+		}
+
+		c.Request = c.Request.WithContext(tenant.WithTenant(c.Request.Context(), id))
+		c.Next()
+	}
+}
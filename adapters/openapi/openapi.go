@@ -0,0 +1,325 @@
+package openapi
+
+import (
+	models "coin-api/domain/model"
+	"coin-api/usecase/model"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Operation ルートごとのAPI定義
+type Operation struct {
+	Summary  string
+	Tag      string
+	Query    []string
+	Optional []string
+	Request  interface{}
+	Response interface{}
+	Stream   bool
+	// Formats JSON以外に返却できるメディアタイプ(バイナリとして定義)
+	Formats []string
+}
+
+// OneOf いずれかの型を返却するレスポンス
+type OneOf []interface{}
+
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]*PathItem `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type PathItem struct {
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *Body                `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type Body struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+type Schema struct {
+	Ref        string             `json:"$ref,omitempty"`
+	Type       string             `json:"type,omitempty"`
+	Format     string             `json:"format,omitempty"`
+	Nullable   bool               `json:"nullable,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	OneOf      []*Schema          `json:"oneOf,omitempty"`
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	numericStringType = reflect.TypeOf(model.NumericString(""))
+	decimalType       = reflect.TypeOf(models.Decimal{})
+)
+
+// Build 登録済みルートとAPI定義からOpenAPI3ドキュメントを生成
+func Build(title string, version string, routes gin.RoutesInfo, operations map[string]Operation) *Document {
+	doc := &Document{
+		OpenAPI:    "3.0.3",
+		Info:       Info{Title: title, Version: version},
+		Paths:      make(map[string]map[string]*PathItem),
+		Components: Components{Schemas: make(map[string]*Schema)},
+	}
+	errSchema := doc.schemaOf(reflect.TypeOf(model.ErrorResponse{}))
+
+	// 定義漏れ・ルートが存在しない定義はドキュメントに含めず警告のみ(drivers/openapi_test.goで検出)
+	missing, unused := Unmatched(routes, operations)
+	for _, key := range missing {
+		log.Warn().Msg(fmt.Sprintf("OpenAPI定義なしのルート : %s", key))
+	}
+	for _, key := range unused {
+		log.Warn().Msg(fmt.Sprintf("ルートが存在しないOpenAPI定義 : %s", key))
+	}
+
+	for _, r := range routes {
+		op, ok := operations[r.Method+" "+r.Path]
+		if !ok {
+			continue
+		}
+
+		path, params := convertPath(r.Path)
+		for _, q := range op.Query {
+			params = append(params, &Parameter{Name: q, In: "query", Required: true, Schema: &Schema{Type: "string"}})
+		}
+		for _, q := range op.Optional {
+			params = append(params, &Parameter{Name: q, In: "query", Required: false, Schema: &Schema{Type: "string"}})
+		}
+		item := &PathItem{
+			Summary:    op.Summary,
+			Parameters: params,
+			Responses: map[string]*Response{
+				"default": {Description: "エラー", Content: jsonContent(errSchema)},
+			},
+		}
+		if op.Tag != "" {
+			item.Tags = []string{op.Tag}
+		}
+		if op.Request != nil {
+			item.RequestBody = &Body{Required: true, Content: jsonContent(doc.schemaFor(op.Request))}
+		}
+		switch {
+		case op.Stream:
+			item.Responses["200"] = &Response{Description: "成功", Content: map[string]*MediaType{"text/event-stream": {Schema: doc.schemaFor(op.Response)}}}
+		case op.Response != nil:
+			item.Responses["200"] = &Response{Description: "成功", Content: jsonContent(doc.schemaFor(op.Response))}
+		default:
+			item.Responses["200"] = &Response{Description: "成功"}
+		}
+		for _, f := range op.Formats {
+			if item.Responses["200"].Content == nil {
+				item.Responses["200"].Content = make(map[string]*MediaType)
+			}
+			item.Responses["200"].Content[f] = &MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*PathItem)
+		}
+		doc.Paths[path][strings.ToLower(r.Method)] = item
+	}
+	return doc
+}
+
+// Unmatched API定義のないルートとルートが存在しないAPI定義("METHOD パス"の昇順)
+func Unmatched(routes gin.RoutesInfo, operations map[string]Operation) ([]string, []string) {
+	registered := make(map[string]bool)
+	missing := make([]string, 0)
+	for _, r := range routes {
+		key := r.Method + " " + r.Path
+		registered[key] = true
+		if _, ok := operations[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	unused := make([]string, 0)
+	for key := range operations {
+		if !registered[key] {
+			unused = append(unused, key)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(unused)
+	return missing, unused
+}
+
+// Lazy 初回呼び出し時に登録済みルートからドキュメントを生成する関数(全ルートの登録後に参照する)
+func Lazy(g *gin.Engine, title string, version string, operations map[string]Operation) func() *Document {
+	var once sync.Once
+	var doc *Document
+	return func() *Document {
+		once.Do(func() {
+			doc = Build(title, version, g.Routes(), operations)
+		})
+		return doc
+	}
+}
+
+// Handler 初回リクエスト時に生成したドキュメントを返却
+func Handler(doc func() *Document) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, doc())
+	}
+}
+
+// convertPath ginのパス(:id)をOpenAPIのパス({id})とパスパラメータへ変換
+func convertPath(path string) (string, []*Parameter) {
+	params := make([]*Parameter, 0)
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") {
+			name := strings.TrimPrefix(s, ":")
+			segments[i] = "{" + name + "}"
+			params = append(params, &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func jsonContent(s *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: s}}
+}
+
+// schemaFor 値の型からスキーマを生成
+func (d *Document) schemaFor(v interface{}) *Schema {
+	if o, ok := v.(OneOf); ok {
+		s := &Schema{}
+		for _, e := range o {
+			s.OneOf = append(s.OneOf, d.schemaFor(e))
+		}
+		return s
+	}
+	return d.schemaOf(reflect.TypeOf(v))
+}
+
+// schemaOf 型のjsonタグからスキーマを生成(構造体はcomponentsへ登録して参照)
+func (d *Document) schemaOf(t reflect.Type) *Schema {
+	// 数値・数値文字列のどちらも受け付ける項目
+	if t == numericStringType {
+		return &Schema{OneOf: []*Schema{{Type: "number"}, {Type: "string"}}}
+	}
+	// 固定小数点のコイン量は10進数表記の文字列
+	if t == decimalType {
+		return &Schema{Type: "string", Format: "decimal"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := d.schemaOf(t.Elem())
+		if s.Ref != "" {
+			return s
+		}
+		s.Nullable = true
+		return s
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// json.RawMessage等は任意のJSON
+			return &Schema{}
+		}
+		return &Schema{Type: "array", Items: d.schemaOf(t.Elem())}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Struct:
+		if t == timeType {
+			return &Schema{Type: "string", Format: "date-time"}
+		}
+		ref := &Schema{Ref: "#/components/schemas/" + t.Name()}
+		if _, ok := d.Components.Schemas[t.Name()]; ok {
+			return ref
+		}
+		s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		d.Components.Schemas[t.Name()] = s
+		d.addProperties(s, t)
+		sort.Strings(s.Required)
+		return ref
+	default:
+		return &Schema{}
+	}
+}
+
+// addProperties 構造体のフィールドをプロパティへ追加(jsonタグのない埋め込み構造体はencoding/jsonと同様に展開)
+func (d *Document) addProperties(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Tag.Get("json") == "" && f.Type.Kind() == reflect.Struct {
+			d.addProperties(s, f.Type)
+			continue
+		}
+		name, omitempty, ok := jsonName(f)
+		if !ok {
+			continue
+		}
+		s.Properties[name] = d.schemaOf(f.Type)
+		if !omitempty {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// jsonName jsonタグからプロパティ名を取得(非公開・"-"は対象外)
+func jsonName(f reflect.StructField) (string, bool, bool) {
+	if !f.IsExported() {
+		return "", false, false
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = f.Name
+	}
+	omitempty := false
+	for _, p := range parts[1:] {
+		if p == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, true
+}
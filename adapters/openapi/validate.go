@@ -0,0 +1,113 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ValidateRequest リクエストがAPI定義に一致することを検証(API定義のないルートは対象外、読み込んだボディは復元)
+// 必須のクエリパラメータとリクエストボディの型を検証し、項目の必須・値の範囲はユースケースのバリデーションで検証する
+func (d *Document) ValidateRequest(r *http.Request, route string) error {
+	path, _ := convertPath(route)
+	item := d.Paths[path][strings.ToLower(r.Method)]
+	if item == nil {
+		return nil
+	}
+
+	query := r.URL.Query()
+	for _, p := range item.Parameters {
+		if p.In == "query" && p.Required && query.Get(p.Name) == "" {
+			return fmt.Errorf("クエリパラメータ%sを指定してください", p.Name)
+		}
+	}
+
+	// JSON以外・ボディのないリクエストはユースケースのバリデーションでエラーとする
+	if item.RequestBody == nil || r.Body == nil || !isJSON(r.Header.Get("Content-Type")) {
+		return nil
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return errors.New("リクエストボディがJSONではありません")
+	}
+	return d.validate(item.RequestBody.Content["application/json"].Schema, v, "リクエストボディ")
+}
+
+// validate JSONの値がスキーマの型に一致することを検証(定義のないプロパティは対象外)
+func (d *Document) validate(s *Schema, v interface{}, name string) error {
+	if s.Ref != "" {
+		return d.validate(d.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")], v, name)
+	}
+	if len(s.OneOf) > 0 {
+		for _, o := range s.OneOf {
+			if d.validate(o, v, name) == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("%sの型が不正です", name)
+	}
+	if v == nil {
+		if s.Type == "" || s.Nullable {
+			return nil
+		}
+		return fmt.Errorf("%sにnullは指定できません", name)
+	}
+
+	ok := true
+	switch s.Type {
+	case "object":
+		var m map[string]interface{}
+		if m, ok = v.(map[string]interface{}); ok {
+			for key, p := range s.Properties {
+				if pv, exists := m[key]; exists {
+					if err := d.validate(p, pv, key); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	case "array":
+		var a []interface{}
+		if a, ok = v.([]interface{}); ok {
+			for i, e := range a {
+				if err := d.validate(s.Items, e, fmt.Sprintf("%s[%d]", name, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case "string":
+		_, ok = v.(string)
+	case "boolean":
+		_, ok = v.(bool)
+	case "number":
+		_, ok = v.(json.Number)
+	case "integer":
+		var n json.Number
+		n, ok = v.(json.Number)
+		ok = ok && !strings.ContainsAny(n.String(), ".eE")
+	}
+	if !ok {
+		return fmt.Errorf("%sの型が不正です(%s)", name, s.Type)
+	}
+	return nil
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.EqualFold(strings.TrimSpace(mediaType), "application/json")
+}
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"coin-api/config"
+	"coin-api/database"
 	"coin-api/drivers"
 	"github.com/gin-gonic/gin"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/jinzhu/gorm/dialects/postgres"
 	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 	"github.com/rs/zerolog/pkgerrors"
+	"net"
 	"time"
 )
 
@@ -15,8 +19,16 @@ func main() {
 	// log設定
 	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
 
+	// 設定値の検証(コインの桁数が範囲外等の場合は起動しない)
+	if err := config.LoadConfig().Validate(); err != nil {
+		panic(err)
+	}
+
+	// DB接続(REST/gRPCで共有)
+	con := database.NewPostgreSQLConnector()
+
 	// Gin設定
-	engine := drivers.InitRouter()
+	engine := drivers.InitRouter(con)
 	engine.Use(gin.Logger())
 	engine.Use(gin.Recovery())
 
@@ -27,6 +39,17 @@ func main() {
 	}
 	time.Local = jst
 
+	// gRPCサーバー起動
+	lis, err := net.Listen("tcp", ":9091")
+	if err != nil {
+		panic("gRPCサーバーの起動に失敗しました。")
+	}
+	go func() {
+		if err := drivers.InitGrpcServer(con).Serve(lis); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}()
+
 	// サーバー起動
 	err = engine.Run(":8081")
 	if err != nil {
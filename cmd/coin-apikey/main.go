@@ -0,0 +1,68 @@
+package main
+
+import (
+	"coin-api/adapters/gateways/rdb"
+	"coin-api/common/enum"
+	"coin-api/common/tenant"
+	"coin-api/config"
+	"coin-api/database"
+	"coin-api/usecase/interactor"
+	"coin-api/usecase/model"
+	"context"
+	"flag"
+	"fmt"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/pkgerrors"
+	"os"
+)
+
+// 管理者スコープのAPIキーを発行する(管理者APIは管理者スコープのAPIキーが必要なため、最初のキーはこのコマンドで発行する)
+func main() {
+	name := flag.String("name", "admin", "APIキーの名前")
+	tenantId := flag.String("tenant", "", "APIキーのテナント(未指定の場合はdefault)")
+	flag.Parse()
+
+	// log設定
+	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
+
+	// 設定にないテナントは発行しない
+	conf := config.LoadConfig()
+	if *tenantId == "" {
+		*tenantId = conf.TenantsInfo.DefaultId
+	}
+	if conf.TenantsInfo.Find(*tenantId) == nil {
+		fmt.Fprintf(os.Stderr, "未定義のテナントです : %s\n", *tenantId)
+		os.Exit(2)
+	}
+
+	con := database.NewPostgreSQLConnector()
+	conn := con.Conn.WithContext(tenant.WithTenant(context.Background(), *tenantId))
+	op := &printer{}
+	form := &model.ApiKeyAddForm{Name: *name, Scopes: []string{string(enum.SCOPE_ADMIN)}}
+	if err := interactor.NewApiKeyUseCase(op, rdb.NewApiKeyRepository(conn), rdb.NewUserRepository(conn)).CreateApiKey(form); err != nil {
+		fmt.Fprintf(os.Stderr, "APIキー発行でエラー発生 : %v\n", err)
+		os.Exit(2)
+	}
+}
+
+// printer 発行したAPIキーを標準出力へ出力
+type printer struct{}
+
+func (p *printer) OutputApiKeyCreated(key *model.ApiKeyCreatedResponse) error {
+	fmt.Printf("APIキー発行完了 テナント : %s プレフィックス : %s\n", key.TenantId, key.Prefix)
+	fmt.Println(key.Key)
+	return nil
+}
+
+func (p *printer) OutputApiKey(*model.ApiKeyResponse) error {
+	return nil
+}
+
+func (p *printer) OutputApiKeys([]*model.ApiKeyResponse) error {
+	return nil
+}
+
+func (p *printer) OutputError(_ *model.ErrorResponse, err error) error {
+	return err
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"coin-api/adapters/gateways/rdb"
+	"coin-api/config"
+	"coin-api/database"
+	"coin-api/domain/repository"
+	"coin-api/usecase/interactor"
+	"coin-api/usecase/model"
+	"context"
+	"flag"
+	"fmt"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/pkgerrors"
+	"os"
+	"time"
+)
+
+// 確定した日付の残高スナップショットを作成する(-verifyの場合は作成済みのスナップショットを履歴と照合し、不整合があれば終了コード1で終了する)
+func main() {
+	verify := flag.Bool("verify", false, "作成済みのスナップショットを履歴と照合する")
+	flag.Parse()
+
+	// log設定
+	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
+
+	// timezoneのグローバル変数を日本時刻へ変換(日付の区切りはAPIと同じ)
+	jst, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		panic(err)
+	}
+	time.Local = jst
+
+	// 設定値の検証(コインの桁数が範囲外等の場合は起動しない)
+	if err := config.LoadConfig().Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "設定エラー : %v\n", err)
+		os.Exit(2)
+	}
+
+	con := database.NewPostgreSQLConnector()
+	sr := rdb.NewSnapshotRepository(con.Conn)
+
+	if !*verify {
+		n, err := interactor.NewSnapshotUseCase(nil, sr).TakeSnapshots(context.Background())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "スナップショット作成でエラー発生 : %v\n", err)
+			os.Exit(2)
+		}
+		fmt.Printf("スナップショット作成完了 : %d 件\n", n)
+		return
+	}
+
+	filter := &repository.SnapshotFilter{}
+	checked, err := sr.Count(filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "スナップショット件数取得でエラー発生 : %v\n", err)
+		os.Exit(2)
+	}
+	mismatches, err := sr.SelectMismatches(filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "スナップショット検証でエラー発生 : %v\n", err)
+		os.Exit(2)
+	}
+	for _, m := range mismatches {
+		coin := model.CoinOf(m.TenantId)
+		fmt.Printf("NG ユーザーID : %d 日時 : %s スナップショット : %s 履歴 : %s\n", m.UserId, m.AsOf.Format(time.RFC3339), coin.Decimal(m.Balance), coin.Decimal(m.Actual))
+	}
+	if len(mismatches) > 0 {
+		fmt.Printf("スナップショット不整合 : %d/%d 件\n", len(mismatches), checked)
+		os.Exit(1)
+	}
+	fmt.Printf("スナップショット検証完了 : %d 件\n", checked)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"coin-api/adapters/gateways/rdb"
+	"coin-api/config"
+	"coin-api/database"
+	"coin-api/domain/model"
+	"fmt"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/pkgerrors"
+	"os"
+)
+
+// 全ユーザーのコイン履歴ハッシュチェーンを検証し、不整合があれば最初の破損箇所を出力して終了コード1で終了する
+func main() {
+	// log設定
+	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
+
+	// 設定値の検証(コインの桁数が範囲外等の場合は起動しない)
+	conf := config.LoadConfig()
+	if err := conf.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "設定エラー : %v\n", err)
+		os.Exit(2)
+	}
+
+	con := database.NewPostgreSQLConnector()
+	cr := rdb.NewCoinRepository(con.Conn)
+
+	uids, err := cr.SelectHistoryUserIds()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "履歴ユーザー取得でエラー発生 : %v\n", err)
+		os.Exit(2)
+	}
+
+	broken := 0
+	for _, uid := range uids {
+		histories, err := cr.SelectChainByUserId(uid)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "コイン履歴取得でエラー発生 ユーザーID : %d : %v\n", uid, err)
+			os.Exit(2)
+		}
+		// 履歴のテナントで扱うコインの桁数で検証
+		if b := model.VerifyChain(histories, conf.CoinOf(histories[0].TenantId).Precision); b != nil {
+			fmt.Printf("NG ユーザーID : %d 履歴ID : %d 理由 : %s\n", uid, b.HistoryId, b.Reason)
+			broken++
+			continue
+		}
+		fmt.Printf("OK ユーザーID : %d 履歴件数 : %d\n", uid, len(histories))
+	}
+
+	if broken > 0 {
+		fmt.Printf("ハッシュチェーン不整合 : %d/%d ユーザー\n", broken, len(uids))
+		os.Exit(1)
+	}
+	fmt.Printf("ハッシュチェーン検証完了 : %d ユーザー\n", len(uids))
+}
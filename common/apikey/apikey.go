@@ -0,0 +1,53 @@
+package apikey
+
+import (
+	"coin-api/common/enum"
+	"context"
+)
+
+type principalKey struct{}
+
+// Principal APIキーで認証されたリクエストの主体
+type Principal struct {
+	KeyId    uint
+	Prefix   string
+	TenantId string
+	Scopes   []enum.ApiKeyScope
+	// UserId 発行対象のユーザー(ユーザーに紐づかないAPIキーの場合はnil)
+	UserId *uint
+}
+
+// HasScope 指定したスコープのいずれかを持つか判定
+func (p *Principal) HasScope(scopes ...enum.ApiKeyScope) bool {
+	for _, s := range p.Scopes {
+		for _, required := range scopes {
+			if s == required {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WithPrincipal APIキーの主体をcontextへ設定
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext contextに設定されたAPIキーの主体を取得(APIキーでのリクエストでない場合はnil)
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// ActsFor APIキーでのリクエストの場合のみ発行対象のユーザーか判定(ユーザーに紐づかないAPIキー・APIキー以外は常にtrue)
+func ActsFor(ctx context.Context, uid uint) bool {
+	p := FromContext(ctx)
+	return p == nil || p.UserId == nil || *p.UserId == uid
+}
+
+// Allowed APIキーでのリクエストの場合のみスコープを判定(それ以外は常にtrue)
+func Allowed(ctx context.Context, scope enum.ApiKeyScope) bool {
+	p := FromContext(ctx)
+	return p == nil || p.HasScope(scope)
+}
@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"coin-api/domain/model"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+type entryKey struct{}
+
+// WithEntry リクエストの監査ログをcontextへ設定
+func WithEntry(ctx context.Context, entry *model.AuditLog) context.Context {
+	return context.WithValue(ctx, entryKey{}, entry)
+}
+
+// FromContext contextに設定された監査ログを取得(未設定の場合はnil)
+func FromContext(ctx context.Context) *model.AuditLog {
+	entry, _ := ctx.Value(entryKey{}).(*model.AuditLog)
+	return entry
+}
+
+// SetStatus transaction内で監査ログを登録する場合のステータスコードを設定(200以外を返却する場合にtransaction実行前に設定)
+func SetStatus(ctx context.Context, code int) {
+	if entry := FromContext(ctx); entry != nil {
+		entry.StatusCode = code
+	}
+}
+
+// NewRequestId ランダムなリクエストIDを採番
+func NewRequestId() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// redactKeys 監査ログに残さない項目(部分一致、大文字小文字は区別しない)
+var redactKeys = []string{"password", "secret", "token", "api_key", "apikey", "authorization"}
+
+const redacted = "[REDACTED]"
+
+// RedactPayload JSONの秘匿項目をマスク(JSONでない場合はnull)
+func RedactPayload(body []byte) string {
+	if len(body) == 0 {
+		return "null"
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return "null"
+	}
+	b, err := json.Marshal(redact(v))
+	if err != nil {
+		return "null"
+	}
+	return string(b)
+}
+
+func redact(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			if isSecretKey(k) {
+				t[k] = redacted
+				continue
+			}
+			t[k] = redact(e)
+		}
+		return t
+	case []interface{}:
+		for i, e := range t {
+			t[i] = redact(e)
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+func isSecretKey(k string) bool {
+	k = strings.ToLower(k)
+	for _, s := range redactKeys {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+package enum
+
+type ApiKeyScope string
+
+const (
+	SCOPE_COIN_ADD  = ApiKeyScope("coin:add")
+	SCOPE_COIN_USE  = ApiKeyScope("coin:use")
+	SCOPE_COIN_SEND = ApiKeyScope("coin:send")
+	SCOPE_COIN_READ = ApiKeyScope("coin:read")
+	SCOPE_USER_READ = ApiKeyScope("user:read")
+	// SCOPE_ADMIN 管理者API(ユーザーに発行したAPIキーには付与不可)
+	SCOPE_ADMIN = ApiKeyScope("admin")
+)
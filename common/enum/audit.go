@@ -0,0 +1,17 @@
+package enum
+
+type AuditResult string
+
+const (
+	AUDIT_SUCCESS = AuditResult("SUCCESS")
+	AUDIT_FAILURE = AuditResult("FAILURE")
+)
+
+type ActorType string
+
+const (
+	ACTOR_USER      = ActorType("USER")
+	ACTOR_ADMIN     = ActorType("ADMIN")
+	ACTOR_API_KEY   = ActorType("API_KEY")
+	ACTOR_ANONYMOUS = ActorType("ANONYMOUS")
+)
@@ -0,0 +1,23 @@
+package enum
+
+type EventType string
+
+const (
+	COIN_ADDED       = EventType("coin.added")
+	COIN_USED        = EventType("coin.used")
+	COIN_SENT        = EventType("coin.sent")
+	COIN_RECEIVED    = EventType("coin.received")
+	COIN_HELD        = EventType("coin.held")
+	COIN_REFUNDED    = EventType("coin.refunded")
+	COIN_REVERSED    = EventType("coin.reversed")
+	SCHEDULE_SKIPPED = EventType("schedule.skipped")
+	USER_CREATED     = EventType("user.created")
+)
+
+type DeliveryStatus string
+
+const (
+	DELIVERY_PENDING   = DeliveryStatus("PENDING")
+	DELIVERY_SUCCEEDED = DeliveryStatus("SUCCEEDED")
+	DELIVERY_FAILED    = DeliveryStatus("FAILED")
+)
@@ -3,8 +3,12 @@ package enum
 type Operation string
 
 const (
-	ADD     = Operation("ADD")
-	USE     = Operation("USE")
-	RECEIVE = Operation("RECEIVE")
-	SEND    = Operation("SEND")
+	ADD      = Operation("ADD")
+	USE      = Operation("USE")
+	RECEIVE  = Operation("RECEIVE")
+	SEND     = Operation("SEND")
+	HOLD     = Operation("HOLD")
+	RELEASE  = Operation("RELEASE")
+	REFUND   = Operation("REFUND")
+	REVERSAL = Operation("REVERSAL")
 )
@@ -0,0 +1,23 @@
+package enum
+
+type ScheduleInterval string
+
+const (
+	DAILY   = ScheduleInterval("DAILY")
+	WEEKLY  = ScheduleInterval("WEEKLY")
+	MONTHLY = ScheduleInterval("MONTHLY")
+)
+
+type ScheduleStatus string
+
+const (
+	ACTIVE    = ScheduleStatus("ACTIVE")
+	CANCELLED = ScheduleStatus("CANCELLED")
+)
+
+type ExecutionStatus string
+
+const (
+	SUCCEEDED = ExecutionStatus("SUCCEEDED")
+	SKIPPED   = ExecutionStatus("SKIPPED")
+)
@@ -0,0 +1,10 @@
+package enum
+
+type TransferStatus string
+
+const (
+	PENDING  = TransferStatus("PENDING")
+	ACCEPTED = TransferStatus("ACCEPTED")
+	REJECTED = TransferStatus("REJECTED")
+	EXPIRED  = TransferStatus("EXPIRED")
+)
@@ -0,0 +1,108 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	// A4縦(pt)
+	pageWidth  = 595
+	pageHeight = 842
+	margin     = 40
+	fontSize   = 9
+	lineHeight = 12
+)
+
+// Document 等幅フォント(Courier)でテキスト行のみを出力する最小限のPDF
+//
+// 標準フォントを使用するためASCII以外の文字は"?"に置換される
+type Document struct {
+	pages [][]string
+}
+
+func NewDocument() *Document {
+	return &Document{}
+}
+
+// LinesPerPage 1ページに出力できる行数
+func LinesPerPage() int {
+	return (pageHeight - 2*margin) / lineHeight
+}
+
+// AddLine 1行追加(ページに収まらない場合は改ページ)
+func (d *Document) AddLine(line string) {
+	if len(d.pages) == 0 || len(d.pages[len(d.pages)-1]) >= LinesPerPage() {
+		d.pages = append(d.pages, nil)
+	}
+	last := len(d.pages) - 1
+	d.pages[last] = append(d.pages[last], line)
+}
+
+// Bytes PDFファイルの内容を生成
+func (d *Document) Bytes() []byte {
+	pages := d.pages
+	if len(pages) == 0 {
+		pages = [][]string{nil}
+	}
+
+	// オブジェクト番号 1:Catalog 2:Pages 3:Font 4以降:各ページのPageとContents
+	var buf bytes.Buffer
+	offsets := make([]int, 0, 3+2*len(pages))
+	writeObj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+	kids := make([]string, 0, len(pages))
+	for i := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 4+2*i))
+	}
+	writeObj("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	writeObj("<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>")
+	for i, lines := range pages {
+		content := pageContent(lines)
+		writeObj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, 5+2*i))
+		writeObj(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	// 相互参照表とトレーラー
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, o := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
+
+func pageContent(lines []string) string {
+	var b strings.Builder
+	// 各行は改行(')してから出力するため開始位置は上余白の位置
+	fmt.Fprintf(&b, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", fontSize, lineHeight, margin, pageHeight-margin)
+	for _, line := range lines {
+		fmt.Fprintf(&b, "(%s) '\n", escape(line))
+	}
+	b.WriteString("ET")
+	return b.String()
+}
+
+// escape 文字列リテラル用のエスケープ(ASCII以外は置換)
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,21 @@
+package tenant
+
+import (
+	"context"
+)
+
+type tenantKey struct{}
+
+// WithTenant リクエストのテナントIDをcontextへ設定
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext contextに設定されたテナントIDを取得(ワーカーなどテナント未指定の場合は空文字)
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(tenantKey{}).(string)
+	return id
+}
@@ -1,5 +1,10 @@
 package config
 
+import (
+	"fmt"
+	"time"
+)
+
 const (
 	dbUser     = "admin"
 	dbPassword = "admin"
@@ -8,8 +13,181 @@ const (
 	dbPort     = "5433"
 )
 
+// DB接続プール設定(statementTimeoutを超えたSQLはDBで中断する、0の場合は無制限)
+const (
+	dbMaxOpenConns     = 25
+	dbMaxIdleConns     = 10
+	dbConnMaxLifetime  = 30 * time.Minute
+	dbConnMaxIdleTime  = 5 * time.Minute
+	dbStatementTimeout = 30 * time.Second
+)
+
+// DB再試行設定(起動時の接続はconnectBackoffから2倍ずつconnectMaxBackoffまで待機してconnectAttempts回まで試行、
+// 直列化失敗・デッドロックのトランザクションはtxBackoffから2倍ずつ待機してtxAttempts回まで試行)
+const (
+	dbConnectAttempts   = 10
+	dbConnectBackoff    = 1 * time.Second
+	dbConnectMaxBackoff = 30 * time.Second
+	dbTxAttempts        = 3
+	dbTxBackoff         = 50 * time.Millisecond
+)
+
+// サーキットブレーカー設定(DB接続エラーがfailureThreshold回連続した場合、openTimeoutの間はDBへ接続せずエラーとする)
+const (
+	dbBreakerFailureThreshold = 5
+	dbBreakerOpenTimeout      = 10 * time.Second
+)
+
+// レプリカ設定(maxLagを超えて遅延しているレプリカは参照に使用しない、checkIntervalは遅延の測定間隔、
+// readYourWritesは更新系のレスポンスのX-Read-Afterを参照系のリクエストで指定した場合にその時刻までの更新が反映されたDBから参照する)
+const (
+	replicaMaxLag         = 5 * time.Second
+	replicaCheckInterval  = 1 * time.Second
+	replicaReadYourWrites = true
+)
+
+// 既定のコイン設定(precisionは小数点以下の桁数、金額・残高は10^-precision単位の整数で保持)
+const (
+	coinCode      = "COIN"
+	coinPrecision = 2
+)
+
+// maxCoinPrecision コインの小数点以下の桁数の上限(1回の操作の上限額を最小単位にしてもint64に収まる桁数)
+const maxCoinPrecision = 9
+
+// 送金ルール設定(コイン単位、0の場合は無効)
+const (
+	transferMaxAmount       = 100000
+	transferDailyCap        = 300000
+	transferMonthlyCap      = 3000000
+	transferMaxCountPerHour = 10
+	transferMinAccountAge   = 0 * time.Hour
+)
+
+// 承認待ち送金設定
+const (
+	pendingTransferTimeout       = 72 * time.Hour
+	pendingTransferCheckInterval = 1 * time.Minute
+)
+
+// 定期送金設定
+const (
+	scheduleCheckInterval = 1 * time.Minute
+)
+
+// Webhook設定
+const (
+	webhookDispatchInterval = 5 * time.Second
+	webhookBatchSize        = 100
+	webhookMaxAttempts      = 8
+	webhookBaseBackoff      = 30 * time.Second
+	webhookMaxBackoff       = 1 * time.Hour
+	webhookTimeout          = 10 * time.Second
+)
+
+// イベント発行設定(publisherはstdout,file,nats,kafka、空文字の場合は発行しない)
+const (
+	eventPublisher     = "stdout"
+	eventFilePath      = "events.jsonl"
+	eventNatsURL       = "nats://coin_nats:4222"
+	eventKafkaTopic    = "coin-events"
+	eventSubjectPrefix = "coin.events"
+	eventRelayInterval = 1 * time.Second
+	eventRelayBatch    = 100
+)
+
+// 残高変更ストリーム設定
+const (
+	streamKeepAlive = 30 * time.Second
+)
+
+// レート制限設定(トークンバケット、rateは1分あたりの補充数、storeはmemory,redis、0の場合は無効)
+const (
+	rateLimitStore      = "memory"
+	rateLimitRedisAddr  = "coin_redis:6379"
+	rateLimitReadRate   = 120
+	rateLimitReadBurst  = 60
+	rateLimitWriteRate  = 30
+	rateLimitWriteBurst = 10
+)
+
+// 集計設定(timezoneは集計の時間区切り、rollupLagはコミット前の履歴を取りこぼさないようロールアップ対象外とする直近の期間)
+const (
+	statsTimeZone       = "Asia/Tokyo"
+	statsRollupInterval = 1 * time.Minute
+	statsRollupLag      = 1 * time.Minute
+	statsTopLimit       = 10
+)
+
+// 残高スナップショット設定(日付はサーバーのタイムゾーンで区切る、lagは日付が変わってからスナップショットを作成するまでの猶予)
+const (
+	snapshotCheckInterval = 10 * time.Minute
+	snapshotLag           = 5 * time.Minute
+)
+
+// パスワード設定(minCharClassesは英大文字・英小文字・数字・記号のうち含める種類数、maxLengthはbcryptの上限のバイト数、
+// breachedListは漏洩パスワード一覧(1行1件)のファイルで空文字の場合は確認しない、maxFailedLoginsは0の場合ロックしない)
+const (
+	passwordMinLength       = 8
+	passwordMaxLength       = 72
+	passwordMinCharClasses  = 3
+	passwordBreachedList    = "config/breached_passwords.txt"
+	passwordBcryptCost      = 12
+	passwordMaxFailedLogins = 5
+	passwordLockoutDuration = 15 * time.Minute
+	passwordResetTokenTTL   = 30 * time.Minute
+)
+
+// パスワード再設定トークンの通知先(メール送信サービス等、トークンはイベントのリレー・Webhookを経由せずこの通知先にのみ送信する、
+// URLが空の場合は送信しない、secretはWebhookと同じ形式の署名に使用)
+const (
+	passwordResetNotifyURL     = ""
+	passwordResetNotifySecret  = ""
+	passwordResetNotifyTimeout = 10 * time.Second
+)
+
+// テナント設定(idはX-Tenant-Id(gRPCはx-tenant-id)で指定する値で、未指定の場合はdefaultTenantId)
+const (
+	defaultTenantId = "default"
+)
+
+// 参照用レプリカのホスト(空の場合はプライマリのみ使用、ユーザー・パスワード・DB名はプライマリと同じ)
+var replicaHosts = []string{}
+
+// Kafkaブローカー
+var eventKafkaBrokers = []string{"coin_kafka:9092"}
+
+// 送金禁止ユーザーペア
+var transferBlockedPairs = []BlockedPair{}
+
+// コインの種類(codeはテナントのcoinCodeで指定、precisionはコインごとの小数点以下の桁数)
+var coins = []CoinInfo{
+	{Code: coinCode, Precision: coinPrecision},
+}
+
+// テナント(coinCodeはテナントで扱うコインの種類、transferRuleがnilの場合は送金ルール設定を使用)
+var tenants = []TenantInfo{
+	{Id: defaultTenantId, Name: "default", CoinCode: coinCode},
+}
+
 type AppConfig struct {
-	PostgreSQLInfo *PostgreSQLInfo
+	PostgreSQLInfo      *PostgreSQLInfo
+	DBPoolInfo          *DBPoolInfo
+	DBRetryInfo         *DBRetryInfo
+	CircuitBreakerInfo  *CircuitBreakerInfo
+	ReplicaInfo         *ReplicaInfo
+	CoinsInfo           *CoinsInfo
+	TransferRuleInfo    *TransferRuleInfo
+	PendingTransferInfo *PendingTransferInfo
+	ScheduleInfo        *ScheduleInfo
+	WebhookInfo         *WebhookInfo
+	EventRelayInfo      *EventRelayInfo
+	StreamInfo          *StreamInfo
+	RateLimitInfo       *RateLimitInfo
+	StatsInfo           *StatsInfo
+	SnapshotInfo        *SnapshotInfo
+	PasswordInfo        *PasswordInfo
+	TenantsInfo         *TenantsInfo
 }
 type PostgreSQLInfo struct {
 	User     string
@@ -18,6 +196,121 @@ type PostgreSQLInfo struct {
 	Host     string
 	Port     string
 }
+type DBPoolInfo struct {
+	MaxOpenConns     int
+	MaxIdleConns     int
+	ConnMaxLifetime  time.Duration
+	ConnMaxIdleTime  time.Duration
+	StatementTimeout time.Duration
+}
+type DBRetryInfo struct {
+	ConnectAttempts   int
+	ConnectBackoff    time.Duration
+	ConnectMaxBackoff time.Duration
+	TxAttempts        int
+	TxBackoff         time.Duration
+}
+type CircuitBreakerInfo struct {
+	FailureThreshold int
+	OpenTimeout      time.Duration
+}
+type ReplicaInfo struct {
+	Hosts          []string
+	MaxLag         time.Duration
+	CheckInterval  time.Duration
+	ReadYourWrites bool
+}
+type CoinsInfo struct {
+	Coins []CoinInfo
+}
+type CoinInfo struct {
+	Code      string
+	Precision int
+}
+type TransferRuleInfo struct {
+	MaxAmount       int
+	DailyCap        int
+	MonthlyCap      int
+	MaxCountPerHour int
+	MinAccountAge   time.Duration
+	BlockedPairs    []BlockedPair
+}
+type PendingTransferInfo struct {
+	Timeout       time.Duration
+	CheckInterval time.Duration
+}
+type ScheduleInfo struct {
+	CheckInterval time.Duration
+}
+type WebhookInfo struct {
+	DispatchInterval time.Duration
+	BatchSize        int
+	MaxAttempts      int
+	BaseBackoff      time.Duration
+	MaxBackoff       time.Duration
+	Timeout          time.Duration
+}
+type EventRelayInfo struct {
+	Publisher     string
+	FilePath      string
+	NatsURL       string
+	KafkaBrokers  []string
+	KafkaTopic    string
+	SubjectPrefix string
+	Interval      time.Duration
+	BatchSize     int
+}
+type StreamInfo struct {
+	KeepAlive time.Duration
+}
+type RateLimitInfo struct {
+	Store      string
+	RedisAddr  string
+	ReadRate   int
+	ReadBurst  int
+	WriteRate  int
+	WriteBurst int
+}
+type StatsInfo struct {
+	TimeZone       string
+	RollupInterval time.Duration
+	RollupLag      time.Duration
+	TopLimit       int
+}
+type SnapshotInfo struct {
+	CheckInterval time.Duration
+	Lag           time.Duration
+}
+type PasswordInfo struct {
+	MinLength       int
+	MaxLength       int
+	MinCharClasses  int
+	BreachedList    string
+	BcryptCost      int
+	MaxFailedLogins int
+	LockoutDuration time.Duration
+	ResetTokenTTL   time.Duration
+	ResetNotify     *PasswordResetNotifyInfo
+}
+type PasswordResetNotifyInfo struct {
+	URL     string
+	Secret  string
+	Timeout time.Duration
+}
+type TenantsInfo struct {
+	DefaultId string
+	Tenants   []TenantInfo
+}
+type TenantInfo struct {
+	Id           string
+	Name         string
+	CoinCode     string
+	TransferRule *TransferRuleInfo
+}
+type BlockedPair struct {
+	Sender   uint
+	Receiver uint
+}
 
 func LoadConfig() *AppConfig {
 	dbInfo := &PostgreSQLInfo{
@@ -28,9 +321,194 @@ func LoadConfig() *AppConfig {
 		Port:     dbPort,
 	}
 
+	poolInfo := &DBPoolInfo{
+		MaxOpenConns:     dbMaxOpenConns,
+		MaxIdleConns:     dbMaxIdleConns,
+		ConnMaxLifetime:  dbConnMaxLifetime,
+		ConnMaxIdleTime:  dbConnMaxIdleTime,
+		StatementTimeout: dbStatementTimeout,
+	}
+
+	retryInfo := &DBRetryInfo{
+		ConnectAttempts:   dbConnectAttempts,
+		ConnectBackoff:    dbConnectBackoff,
+		ConnectMaxBackoff: dbConnectMaxBackoff,
+		TxAttempts:        dbTxAttempts,
+		TxBackoff:         dbTxBackoff,
+	}
+
+	breakerInfo := &CircuitBreakerInfo{
+		FailureThreshold: dbBreakerFailureThreshold,
+		OpenTimeout:      dbBreakerOpenTimeout,
+	}
+
+	replicaInfo := &ReplicaInfo{
+		Hosts:          replicaHosts,
+		MaxLag:         replicaMaxLag,
+		CheckInterval:  replicaCheckInterval,
+		ReadYourWrites: replicaReadYourWrites,
+	}
+
+	coinsInfo := &CoinsInfo{
+		Coins: coins,
+	}
+
+	ruleInfo := &TransferRuleInfo{
+		MaxAmount:       transferMaxAmount,
+		DailyCap:        transferDailyCap,
+		MonthlyCap:      transferMonthlyCap,
+		MaxCountPerHour: transferMaxCountPerHour,
+		MinAccountAge:   transferMinAccountAge,
+		BlockedPairs:    transferBlockedPairs,
+	}
+
+	pendingInfo := &PendingTransferInfo{
+		Timeout:       pendingTransferTimeout,
+		CheckInterval: pendingTransferCheckInterval,
+	}
+
+	scheduleInfo := &ScheduleInfo{
+		CheckInterval: scheduleCheckInterval,
+	}
+
+	webhookInfo := &WebhookInfo{
+		DispatchInterval: webhookDispatchInterval,
+		BatchSize:        webhookBatchSize,
+		MaxAttempts:      webhookMaxAttempts,
+		BaseBackoff:      webhookBaseBackoff,
+		MaxBackoff:       webhookMaxBackoff,
+		Timeout:          webhookTimeout,
+	}
+
+	eventInfo := &EventRelayInfo{
+		Publisher:     eventPublisher,
+		FilePath:      eventFilePath,
+		NatsURL:       eventNatsURL,
+		KafkaBrokers:  eventKafkaBrokers,
+		KafkaTopic:    eventKafkaTopic,
+		SubjectPrefix: eventSubjectPrefix,
+		Interval:      eventRelayInterval,
+		BatchSize:     eventRelayBatch,
+	}
+
+	streamInfo := &StreamInfo{
+		KeepAlive: streamKeepAlive,
+	}
+
+	rateLimitInfo := &RateLimitInfo{
+		Store:      rateLimitStore,
+		RedisAddr:  rateLimitRedisAddr,
+		ReadRate:   rateLimitReadRate,
+		ReadBurst:  rateLimitReadBurst,
+		WriteRate:  rateLimitWriteRate,
+		WriteBurst: rateLimitWriteBurst,
+	}
+
+	statsInfo := &StatsInfo{
+		TimeZone:       statsTimeZone,
+		RollupInterval: statsRollupInterval,
+		RollupLag:      statsRollupLag,
+		TopLimit:       statsTopLimit,
+	}
+
+	snapshotInfo := &SnapshotInfo{
+		CheckInterval: snapshotCheckInterval,
+		Lag:           snapshotLag,
+	}
+
+	passwordInfo := &PasswordInfo{
+		MinLength:       passwordMinLength,
+		MaxLength:       passwordMaxLength,
+		MinCharClasses:  passwordMinCharClasses,
+		BreachedList:    passwordBreachedList,
+		BcryptCost:      passwordBcryptCost,
+		MaxFailedLogins: passwordMaxFailedLogins,
+		LockoutDuration: passwordLockoutDuration,
+		ResetTokenTTL:   passwordResetTokenTTL,
+		ResetNotify: &PasswordResetNotifyInfo{
+			URL:     passwordResetNotifyURL,
+			Secret:  passwordResetNotifySecret,
+			Timeout: passwordResetNotifyTimeout,
+		},
+	}
+
+	tenantsInfo := &TenantsInfo{
+		DefaultId: defaultTenantId,
+		Tenants:   tenants,
+	}
+
 	conf := AppConfig{
-		PostgreSQLInfo: dbInfo,
+		PostgreSQLInfo:      dbInfo,
+		DBPoolInfo:          poolInfo,
+		DBRetryInfo:         retryInfo,
+		CircuitBreakerInfo:  breakerInfo,
+		ReplicaInfo:         replicaInfo,
+		CoinsInfo:           coinsInfo,
+		TransferRuleInfo:    ruleInfo,
+		PendingTransferInfo: pendingInfo,
+		ScheduleInfo:        scheduleInfo,
+		WebhookInfo:         webhookInfo,
+		EventRelayInfo:      eventInfo,
+		StreamInfo:          streamInfo,
+		RateLimitInfo:       rateLimitInfo,
+		StatsInfo:           statsInfo,
+		SnapshotInfo:        snapshotInfo,
+		PasswordInfo:        passwordInfo,
+		TenantsInfo:         tenantsInfo,
 	}
 
 	return &conf
 }
+
+// Find 指定IDのテナント設定を取得(存在しない場合はnil)
+func (t *TenantsInfo) Find(id string) *TenantInfo {
+	for i := range t.Tenants {
+		if t.Tenants[i].Id == id {
+			return &t.Tenants[i]
+		}
+	}
+	return nil
+}
+
+// Find 指定コードのコイン設定を取得(存在しない場合はnil)
+func (c *CoinsInfo) Find(code string) *CoinInfo {
+	for i := range c.Coins {
+		if c.Coins[i].Code == code {
+			return &c.Coins[i]
+		}
+	}
+	return nil
+}
+
+// CoinOf テナントで扱うコイン設定(テナント未指定・未登録の場合は既定のテナントのコイン、未定義のコインの場合はゼロ値)
+func (c *AppConfig) CoinOf(tenantId string) CoinInfo {
+	t := c.TenantsInfo.Find(tenantId)
+	if t == nil {
+		t = c.TenantsInfo.Find(c.TenantsInfo.DefaultId)
+	}
+	if t == nil {
+		return CoinInfo{}
+	}
+	if info := c.CoinsInfo.Find(t.CoinCode); info != nil {
+		return *info
+	}
+	return CoinInfo{}
+}
+
+// Validate 起動時の設定値の検証(コインの桁数が範囲外、テナントのコインが未定義、既定のテナントが未登録の場合はエラー)
+func (c *AppConfig) Validate() error {
+	for _, coin := range c.CoinsInfo.Coins {
+		if coin.Precision < 0 || coin.Precision > maxCoinPrecision {
+			return fmt.Errorf("コイン%sの小数点以下の桁数は0から%dの範囲で指定してください : %d", coin.Code, maxCoinPrecision, coin.Precision)
+		}
+	}
+	for _, t := range c.TenantsInfo.Tenants {
+		if c.CoinsInfo.Find(t.CoinCode) == nil {
+			return fmt.Errorf("テナント%sのコイン%sが定義されていません", t.Id, t.CoinCode)
+		}
+	}
+	if c.TenantsInfo.Find(c.TenantsInfo.DefaultId) == nil {
+		return fmt.Errorf("既定のテナント%sが登録されていません", c.TenantsInfo.DefaultId)
+	}
+	return nil
+}
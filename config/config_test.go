@@ -0,0 +1,64 @@
+package config
+
+import (
+	"testing"
+)
+
+func testConfig() *AppConfig {
+	return &AppConfig{
+		CoinsInfo: &CoinsInfo{Coins: []CoinInfo{{Code: "COIN", Precision: 2}, {Code: "POINT", Precision: 0}}},
+		TenantsInfo: &TenantsInfo{
+			DefaultId: "default",
+			Tenants:   []TenantInfo{{Id: "default", CoinCode: "COIN"}, {Id: "shop", CoinCode: "POINT"}},
+		},
+	}
+}
+
+func TestCoinOf(t *testing.T) {
+	conf := testConfig()
+
+	// テナントのcoinCodeでコインを選択(未指定・未登録のテナントは既定のテナントのコイン)
+	tests := []struct {
+		tenantId string
+		want     CoinInfo
+	}{
+		{tenantId: "shop", want: CoinInfo{Code: "POINT", Precision: 0}},
+		{tenantId: "default", want: CoinInfo{Code: "COIN", Precision: 2}},
+		{tenantId: "", want: CoinInfo{Code: "COIN", Precision: 2}},
+		{tenantId: "other", want: CoinInfo{Code: "COIN", Precision: 2}},
+	}
+	for _, tt := range tests {
+		if got := conf.CoinOf(tt.tenantId); got != tt.want {
+			t.Errorf("CoinOf(%q) = %+v, want %+v", tt.tenantId, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *AppConfig)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(c *AppConfig) {}},
+		{name: "max precision", modify: func(c *AppConfig) { c.CoinsInfo.Coins[0].Precision = maxCoinPrecision }},
+		{name: "precision too large", modify: func(c *AppConfig) { c.CoinsInfo.Coins[0].Precision = maxCoinPrecision + 1 }, wantErr: true},
+		{name: "negative precision", modify: func(c *AppConfig) { c.CoinsInfo.Coins[1].Precision = -1 }, wantErr: true},
+		{name: "undefined coin", modify: func(c *AppConfig) {
+			c.TenantsInfo.Tenants = append(c.TenantsInfo.Tenants, TenantInfo{Id: "other", CoinCode: "GOLD"})
+		}, wantErr: true},
+		{name: "undefined default tenant", modify: func(c *AppConfig) { c.TenantsInfo.DefaultId = "none" }, wantErr: true},
+	}
+	for _, tt := range tests {
+		conf := testConfig()
+		tt.modify(conf)
+		if err := conf.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	// 既定の設定値は検証を通過すること
+	if err := LoadConfig().Validate(); err != nil {
+		t.Errorf("LoadConfig().Validate() error = %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package database
+
+import (
+	"gorm.io/gorm"
+)
+
+// protectAuditLogs 監査ログの更新・削除を禁止するトリガーを作成
+func protectAuditLogs(conn *gorm.DB) error {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS audit_logs_no_modify ON audit_logs`,
+		`CREATE TRIGGER audit_logs_no_modify BEFORE UPDATE OR DELETE ON audit_logs FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only()`,
+		`DROP TRIGGER IF EXISTS audit_logs_no_truncate ON audit_logs`,
+		`CREATE TRIGGER audit_logs_no_truncate BEFORE TRUNCATE ON audit_logs FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only()`,
+	}
+	return conn.Transaction(func(tx *gorm.DB) error {
+		for _, s := range statements {
+			if err := tx.Exec(s).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
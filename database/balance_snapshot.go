@@ -0,0 +1,10 @@
+package database
+
+import (
+	"gorm.io/gorm"
+)
+
+// initSnapshotState 残高スナップショット状態の行を作成(作成済みの場合は何もしない)
+func initSnapshotState(conn *gorm.DB) error {
+	return conn.Exec("INSERT INTO snapshot_states (id, updated_at) VALUES (1, now()) ON CONFLICT (id) DO NOTHING").Error
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"coin-api/config"
+	"coin-api/domain/repository"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnavailable サーキットブレーカーの遮断中にDBへの接続を行わずに返却するエラー(ユースケースでは503として扱う)
+var ErrUnavailable = repository.ErrUnavailable
+
+// circuitBreaker DB接続エラーが連続した場合に一定時間DBへの接続を遮断
+// 遮断時間の経過後はSQLを実行し、再度接続エラーとなった場合は再び遮断する
+type circuitBreaker struct {
+	conf *config.CircuitBreakerInfo
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+func newCircuitBreaker(conf *config.CircuitBreakerInfo) *circuitBreaker {
+	return &circuitBreaker{conf: conf}
+}
+
+// retryAfter 遮断中の場合は遮断が解除されるまでの時間(遮断中でない場合は0)
+func (b *circuitBreaker) retryAfter(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if now.Before(b.openUntil) {
+		return b.openUntil.Sub(now)
+	}
+	return 0
+}
+
+// record SQLの実行結果を記録(接続エラー以外は成功として扱う)
+func (b *circuitBreaker) record(err error, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !isConnectionError(err) {
+		if b.failures >= b.conf.FailureThreshold {
+			log.Log().Msg("DB接続の遮断を解除しました")
+		}
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.conf.FailureThreshold && !now.Before(b.openUntil) {
+		b.openUntil = now.Add(b.conf.OpenTimeout)
+		log.Error().Err(err).Msg(fmt.Sprintf("DB接続エラーが%d回連続したため%sの間DB接続を遮断します", b.failures, b.conf.OpenTimeout))
+	}
+}
+
+// register SQL実行前後のコールバックを登録(遮断中はSQLを実行せずErrUnavailableとする)
+func (b *circuitBreaker) register(conn *gorm.DB) error {
+	before := func(db *gorm.DB) {
+		if b.retryAfter(time.Now()) > 0 {
+			_ = db.AddError(ErrUnavailable)
+		}
+	}
+	after := func(db *gorm.DB) {
+		if errors.Is(db.Error, ErrUnavailable) {
+			return
+		}
+		b.record(db.Error, time.Now())
+	}
+
+	cb := conn.Callback()
+	for _, err := range []error{
+		cb.Create().Before("gorm:create").Register("breaker:before_create", before),
+		cb.Create().After("gorm:create").Register("breaker:after_create", after),
+		cb.Query().Before("gorm:query").Register("breaker:before_query", before),
+		cb.Query().After("gorm:query").Register("breaker:after_query", after),
+		cb.Update().Before("gorm:update").Register("breaker:before_update", before),
+		cb.Update().After("gorm:update").Register("breaker:after_update", after),
+		cb.Delete().Before("gorm:delete").Register("breaker:before_delete", before),
+		cb.Delete().After("gorm:delete").Register("breaker:after_delete", after),
+		cb.Row().Before("gorm:row").Register("breaker:before_row", before),
+		cb.Row().After("gorm:row").Register("breaker:after_row", after),
+		cb.Raw().Before("gorm:raw").Register("breaker:before_raw", before),
+		cb.Raw().After("gorm:raw").Register("breaker:after_raw", after),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isConnectionError DBに接続できないことによるエラーか判定
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// 08:接続例外、57P01:管理者による停止、57P02:クラッシュによる停止、57P03:接続不可
+		return strings.HasPrefix(pgErr.Code, "08") || pgErr.Code == "57P01" || pgErr.Code == "57P02" || pgErr.Code == "57P03"
+	}
+	// 接続・通信の失敗(接続時のエラーはnet.Errorをラップして返却される)
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
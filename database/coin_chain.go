@@ -0,0 +1,104 @@
+package database
+
+import (
+	"coin-api/config"
+	"coin-api/domain/model"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// chainVersion 金額を桁数によらない10進数表記で算出するハッシュチェーンの形式
+const chainVersion = 1
+
+// upgradeHistoryChain コインを扱うテナントのユーザーの旧形式(金額を最小単位の整数で算出)のハッシュチェーンとチェーン導入前のハッシュ未設定の履歴を現在の形式へ移行
+// 旧形式のチェーンを検証できたユーザーのみ再計算し、不整合のあるユーザーは改ざんを正当化しないよう移行せず検証で不整合として検出する
+func upgradeHistoryChain(conn *gorm.DB, tenants *config.TenantsInfo, info config.CoinInfo) error {
+	return conn.Transaction(func(tx *gorm.DB) error {
+		// 複数インスタンスの同時起動に備えてテーブルロック
+		if err := tx.Exec("LOCK TABLE coin_settings IN EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+		setting, err := selectCoinSetting(tx, info.Code)
+		if err != nil {
+			return err
+		}
+		if setting.ChainVersion >= chainVersion {
+			return nil
+		}
+
+		// 検証から再計算までの間に履歴が追加・更新されないようロック
+		if err := tx.Exec("LOCK TABLE coin_histories IN SHARE MODE").Error; err != nil {
+			return err
+		}
+
+		uids, err := historyUserIds(tx, coinUsers(tx, tenants, info.Code))
+		if err != nil {
+			return err
+		}
+		for _, uid := range uids {
+			var histories []model.CoinHistory
+			if err := tx.Unscoped().Order("id").Find(&histories, "userid = ?", uid).Error; err != nil {
+				return err
+			}
+
+			// 全履歴がハッシュ未設定(チェーン導入前)または旧形式のチェーンとして正しい場合のみ再計算
+			if !allUnhashed(histories) {
+				if b := model.VerifyLegacyChain(histories); b != nil {
+					log.Warn().Msg(fmt.Sprintf("ハッシュチェーン不整合のため形式を移行しません ユーザーID : %d 履歴ID : %d 理由 : %s", uid, b.HistoryId, b.Reason))
+					continue
+				}
+			}
+
+			prev := ""
+			for i := range histories {
+				h := &histories[i]
+				h.PrevHash = prev
+				h.Hash = h.ChainHash(prev, setting.Precision)
+				if err := tx.Unscoped().Model(h).UpdateColumns(map[string]interface{}{"prev_hash": h.PrevHash, "hash": h.Hash}).Error; err != nil {
+					return err
+				}
+				prev = h.Hash
+			}
+		}
+		log.Info().Msg(fmt.Sprintf("履歴のハッシュチェーンの形式を移行 %s : %d -> %d ユーザー数 : %d", info.Code, setting.ChainVersion, chainVersion, len(uids)))
+
+		setting.ChainVersion = chainVersion
+		return tx.Save(setting).Error
+	})
+}
+
+// verifyHistoryChains 対象ユーザーの履歴のハッシュチェーンを検証し、最初に見つかった不整合をエラーとして返却
+func verifyHistoryChains(tx *gorm.DB, users *gorm.DB, precision int) error {
+	uids, err := historyUserIds(tx, users)
+	if err != nil {
+		return err
+	}
+
+	for _, uid := range uids {
+		var histories []model.CoinHistory
+		if err := tx.Unscoped().Order("id").Find(&histories, "userid = ?", uid).Error; err != nil {
+			return err
+		}
+		if b := model.VerifyChain(histories, precision); b != nil {
+			return fmt.Errorf("ハッシュチェーン不整合 ユーザーID : %d 履歴ID : %d 理由 : %s", uid, b.HistoryId, b.Reason)
+		}
+	}
+	return nil
+}
+
+// historyUserIds 履歴のある対象ユーザーのID
+func historyUserIds(tx *gorm.DB, users *gorm.DB) ([]uint, error) {
+	var uids []uint
+	err := tx.Unscoped().Model(&model.CoinHistory{}).Where("userid IN (?)", users).Distinct("userid").Order("userid").Pluck("userid", &uids).Error
+	return uids, err
+}
+
+func allUnhashed(histories []model.CoinHistory) bool {
+	for i := range histories {
+		if histories[i].Hash != "" {
+			return false
+		}
+	}
+	return true
+}
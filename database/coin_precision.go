@@ -0,0 +1,108 @@
+package database
+
+import (
+	"coin-api/config"
+	"coin-api/domain/model"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// amountColumns 最小単位で保持している金額・残高のカラム(userColumnはコインを扱うテナントのユーザーで絞り込むカラム)
+var amountColumns = []struct {
+	table      string
+	column     string
+	userColumn string
+}{
+	{"users", "coinbalance", "id"},
+	{"users", "heldbalance", "id"},
+	{"coin_histories", "amount", "userid"},
+	{"transfers", "amount", "sender"},
+	{"schedules", "amount", "sender"},
+	{"coin_history_rollups", "amount", "userid"},
+	{"balance_snapshots", "balance", "userid"},
+}
+
+// migrateCoinPrecision 保存済みの桁数と設定の桁数が異なる場合にコインを扱うテナントのユーザーの金額・残高を再スケール
+// 履歴のハッシュチェーンが壊れている場合は変換せず、変換後もハッシュは再計算しない(ハッシュは桁数によらない金額で算出)
+func migrateCoinPrecision(conn *gorm.DB, tenants *config.TenantsInfo, info config.CoinInfo) error {
+	return conn.Transaction(func(tx *gorm.DB) error {
+		// 複数インスタンスの同時起動に備えてテーブルロック
+		if err := tx.Exec("LOCK TABLE coin_settings IN EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+		setting, err := selectCoinSetting(tx, info.Code)
+		if err != nil {
+			return err
+		}
+		if setting.Precision == info.Precision {
+			return nil
+		}
+		if info.Precision < setting.Precision {
+			// 桁数を減らすと端数が失われるため変換しない
+			return fmt.Errorf("コインの桁数を%dから%dへ減らすことはできません", setting.Precision, info.Precision)
+		}
+
+		// 検証から変換までの間に履歴が追加・更新されないようロックし、変換前の桁数でハッシュチェーンを検証
+		if err := tx.Exec("LOCK TABLE coin_histories IN SHARE MODE").Error; err != nil {
+			return err
+		}
+		if err := verifyHistoryChains(tx, coinUsers(tx, tenants, info.Code), setting.Precision); err != nil {
+			return fmt.Errorf("コインの桁数を%dから%dへ変換できません : %w", setting.Precision, info.Precision, err)
+		}
+
+		// 増えた桁数分だけ金額・残高を10倍する
+		factor := int64(1)
+		for i := setting.Precision; i < info.Precision; i++ {
+			factor *= 10
+		}
+		for _, c := range amountColumns {
+			query := fmt.Sprintf("UPDATE %s SET %s = %s * ? WHERE %s IN (?)", c.table, c.column, c.column, c.userColumn)
+			if err := tx.Exec(query, factor, coinUsers(tx, tenants, info.Code)).Error; err != nil {
+				return err
+			}
+		}
+
+		// 変換を記録
+		change := &model.CoinPrecisionChange{Code: info.Code, FromPrecision: setting.Precision, ToPrecision: info.Precision}
+		if err := tx.Create(change).Error; err != nil {
+			return err
+		}
+		log.Info().Msg(fmt.Sprintf("コインの桁数を変換 %s : %d -> %d", info.Code, setting.Precision, info.Precision))
+
+		setting.Precision = info.Precision
+		return tx.Save(setting).Error
+	})
+}
+
+// selectCoinSetting 保存済みのコインの設定を取得(存在しない場合は整数で保持していた既存データ(桁数0)とみなす)
+func selectCoinSetting(tx *gorm.DB, code string) (*model.CoinSetting, error) {
+	var setting model.CoinSetting
+	err := tx.Where("code = ?", code).First(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &model.CoinSetting{Code: code, Precision: 0}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+// coinUsers コインを扱うテナントのユーザーIDのサブクエリ(既定のテナントのコインの場合は未登録のテナントのユーザーを含む)
+func coinUsers(tx *gorm.DB, tenants *config.TenantsInfo, code string) *gorm.DB {
+	ids := make([]string, 0)
+	all := make([]string, 0)
+	for _, t := range tenants.Tenants {
+		all = append(all, t.Id)
+		if t.CoinCode == code {
+			ids = append(ids, t.Id)
+		}
+	}
+
+	query := tx.Unscoped().Model(&model.User{}).Select("id")
+	if d := tenants.Find(tenants.DefaultId); d != nil && d.CoinCode == code {
+		return query.Where("tenant_id IN ? OR tenant_id NOT IN ?", ids, all)
+	}
+	return query.Where("tenant_id IN ?", ids)
+}
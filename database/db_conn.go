@@ -4,12 +4,19 @@ import (
 	"coin-api/config"
 	"coin-api/domain/model"
 	"fmt"
+	"github.com/rs/zerolog/log"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"time"
 )
 
+// PostgreSQLConnector プライマリ(Conn)と参照用レプリカのDB接続
 type PostgreSQLConnector struct {
-	Conn *gorm.DB
+	Conn        *gorm.DB
+	replicas    []*replica
+	replicaInfo *config.ReplicaInfo
+	next        uint32
+	breaker     *circuitBreaker
 }
 
 func NewPostgreSQLConnector() *PostgreSQLConnector {
@@ -17,18 +24,149 @@ func NewPostgreSQLConnector() *PostgreSQLConnector {
 	conf := config.LoadConfig()
 	dsn := postgresConnInfo(*conf.PostgreSQLInfo)
 
-	// db接続
-	conn, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// マイグレーション用のdb接続(DBの起動を待機、時間を要するためステートメントタイムアウトなし)
+	conn, err := openWithRetry(dsn, conf.DBRetryInfo)
 	if err != nil {
 		panic(err)
 	}
 
 	// gormのmigrate
-	err = conn.AutoMigrate(&model.User{}, &model.CoinHistory{})
+	err = conn.AutoMigrate(&model.User{}, &model.CoinHistory{}, &model.Transfer{}, &model.Schedule{}, &model.ScheduleExecution{},
+		&model.OutboxEvent{}, &model.WebhookSubscription{}, &model.WebhookDelivery{}, &model.CoinSetting{}, &model.CoinPrecisionChange{}, &model.AuditLog{},
+		&model.CoinHistoryRollup{}, &model.RollupState{}, &model.BalanceSnapshot{}, &model.SnapshotState{},
+		&model.PasswordResetToken{}, &model.ApiKey{})
 
-	return &PostgreSQLConnector{
-		Conn: conn,
+	// ユーザー名の一意制約
+	if err := uniqueUsernames(conn); err != nil {
+		panic(err)
+	}
+
+	// パスワード再設定イベントのトークン削除
+	if err := scrubResetTokens(conn); err != nil {
+		panic(err)
+	}
+
+	// 監査ログの更新・削除禁止
+	if err := protectAuditLogs(conn); err != nil {
+		panic(err)
+	}
+
+	// 集計のロールアップ状態の作成
+	if err := initRollupState(conn); err != nil {
+		panic(err)
+	}
+
+	// 残高スナップショット状態の作成
+	if err := initSnapshotState(conn); err != nil {
+		panic(err)
 	}
+
+	for _, coin := range conf.CoinsInfo.Coins {
+		// 既存履歴のハッシュチェーンの形式の移行(チェーン導入前の履歴へのハッシュ設定を含む)
+		if err := upgradeHistoryChain(conn, conf.TenantsInfo, coin); err != nil {
+			panic(err)
+		}
+
+		// 金額・残高の桁数の移行
+		if err := migrateCoinPrecision(conn, conf.TenantsInfo, coin); err != nil {
+			panic(err)
+		}
+	}
+
+	if sqlDB, err := conn.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+
+	// アプリケーション用のdb接続(接続プール・ステートメントタイムアウト・サーキットブレーカー設定)
+	conn, err = openWithRetry(withStatementTimeout(dsn, conf.DBPoolInfo.StatementTimeout), conf.DBRetryInfo)
+	if err != nil {
+		panic(err)
+	}
+	if err := configurePool(conn, conf.DBPoolInfo); err != nil {
+		panic(err)
+	}
+
+	// 参照用レプリカへ接続
+	replicas := openReplicas(conf.ReplicaInfo.Hosts, func(host string) string {
+		info := *conf.PostgreSQLInfo
+		info.Host = host
+		return withStatementTimeout(postgresConnInfo(info), conf.DBPoolInfo.StatementTimeout)
+	}, conf.DBPoolInfo)
+
+	con := &PostgreSQLConnector{
+		Conn:        conn,
+		replicas:    replicas,
+		replicaInfo: conf.ReplicaInfo,
+	}
+	if err := con.UseCircuitBreaker(conf.CircuitBreakerInfo); err != nil {
+		panic(err)
+	}
+	return con
+}
+
+// UseCircuitBreaker プライマリの接続へサーキットブレーカーを登録(遮断中はSQLを実行せずErrUnavailableとする)
+func (p *PostgreSQLConnector) UseCircuitBreaker(conf *config.CircuitBreakerInfo) error {
+	breaker := newCircuitBreaker(conf)
+	if err := breaker.register(p.Conn); err != nil {
+		return err
+	}
+	p.breaker = breaker
+	return nil
+}
+
+// RetryAfter DB接続の遮断中の場合は遮断が解除されるまでの時間(遮断中でない場合は0)
+func (p *PostgreSQLConnector) RetryAfter() time.Duration {
+	if p.breaker == nil {
+		return 0
+	}
+	return p.breaker.retryAfter(time.Now())
+}
+
+// openWithRetry DBへ接続(接続できない場合は待機時間を2倍ずつ延ばして再試行)
+func openWithRetry(dsn string, conf *config.DBRetryInfo) (*gorm.DB, error) {
+	backoff := conf.ConnectBackoff
+	for attempt := 1; ; attempt++ {
+		conn, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+		if err == nil {
+			return conn, nil
+		}
+		if attempt >= conf.ConnectAttempts {
+			return nil, err
+		}
+
+		log.Error().Err(err).Msg(fmt.Sprintf("DB接続でエラー発生 %s後に再試行します 試行回数 : %d/%d", backoff, attempt, conf.ConnectAttempts))
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > conf.ConnectMaxBackoff {
+			backoff = conf.ConnectMaxBackoff
+		}
+	}
+}
+
+// configurePool 接続プールの設定
+func configurePool(conn *gorm.DB, conf *config.DBPoolInfo) error {
+	sqlDB, err := conn.DB()
+	if err != nil {
+		return err
+	}
+	sqlDB.SetMaxOpenConns(conf.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(conf.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(conf.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(conf.ConnMaxIdleTime)
+	return nil
+}
+
+// withStatementTimeout 接続文字列へステートメントタイムアウトを設定(0の場合は無制限)
+func withStatementTimeout(dsn string, timeout time.Duration) string {
+	if timeout <= 0 {
+		return dsn
+	}
+	return fmt.Sprintf("%s statement_timeout=%d", dsn, timeout.Milliseconds())
+}
+
+// PostgresDSN LISTEN用など個別接続の接続文字列
+func PostgresDSN() string {
+	conf := config.LoadConfig()
+	return postgresConnInfo(*conf.PostgreSQLInfo)
 }
 
 func postgresConnInfo(postgresInfo config.PostgreSQLInfo) string {
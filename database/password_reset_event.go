@@ -0,0 +1,22 @@
+package database
+
+import (
+	"coin-api/domain/event"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// scrubResetTokens 既存のパスワード再設定イベントのペイロードからトークンを削除し、未発行のものは発行・配信の対象外とする
+func scrubResetTokens(conn *gorm.DB) error {
+	result := conn.Exec(`UPDATE outbox_events SET payload = payload - 'token',
+published_at = COALESCE(published_at, now()), dispatched_at = COALESCE(dispatched_at, now())
+WHERE event_type = ? AND payload->>'token' IS NOT NULL`, event.PasswordResetRequestedName)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Warn().Msg(fmt.Sprintf("パスワード再設定イベントのトークンを削除 件数 : %d", result.RowsAffected))
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"coin-api/config"
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type readAfterKey struct{}
+
+// replica 参照用レプリカの接続と遅延の測定結果
+type replica struct {
+	host string
+	conn *gorm.DB
+
+	mu sync.RWMutex
+	// replayedAt レプリカに反映済みのプライマリの時刻(測定時刻から遅延を差し引いた時刻、未測定・異常時はゼロ値)
+	replayedAt time.Time
+}
+
+// replicaStatus レプリカの遅延の測定結果
+type replicaStatus struct {
+	InRecovery bool    `gorm:"column:in_recovery"`
+	LagSeconds float64 `gorm:"column:lag_seconds"`
+}
+
+// openReplicas レプリカへ接続(接続できないレプリカはログを出力して除外)
+func openReplicas(hosts []string, dsn func(host string) string, pool *config.DBPoolInfo) []*replica {
+	replicas := make([]*replica, 0, len(hosts))
+	for _, host := range hosts {
+		conn, err := gorm.Open(postgres.Open(dsn(host)), &gorm.Config{})
+		if err != nil {
+			log.Error().Err(err).Msg(fmt.Sprintf("レプリカへの接続でエラー発生 ホスト : %s", host))
+			continue
+		}
+		if err := configurePool(conn, pool); err != nil {
+			log.Error().Err(err).Msg(fmt.Sprintf("レプリカの接続プール設定でエラー発生 ホスト : %s", host))
+			continue
+		}
+		replicas = append(replicas, &replica{host: host, conn: conn})
+	}
+	return replicas
+}
+
+// Reader 参照用のDB接続
+// notBeforeまでの更新(ゼロ値の場合は許容する遅延の範囲内)が反映されたレプリカを順に使用し、該当するレプリカがない場合はプライマリを返却
+func (p *PostgreSQLConnector) Reader(notBefore time.Time) *gorm.DB {
+	if len(p.replicas) == 0 {
+		return p.Conn
+	}
+	if limit := time.Now().Add(-p.replicaInfo.MaxLag); notBefore.Before(limit) {
+		notBefore = limit
+	}
+
+	start := atomic.AddUint32(&p.next, 1)
+	for i := range p.replicas {
+		r := p.replicas[(int(start)+i)%len(p.replicas)]
+		r.mu.RLock()
+		ok := !r.replayedAt.IsZero() && !r.replayedAt.Before(notBefore)
+		r.mu.RUnlock()
+		if ok {
+			return r.conn
+		}
+	}
+	return p.Conn
+}
+
+// MonitorReplicas レプリカの遅延を定期的に測定(ctxの終了まで)
+func (p *PostgreSQLConnector) MonitorReplicas(ctx context.Context) {
+	if len(p.replicas) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(p.replicaInfo.CheckInterval)
+	defer ticker.Stop()
+	for {
+		for _, r := range p.replicas {
+			r.measure(ctx)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// measure レプリカの遅延を測定(WALを受信済みの位置まで適用済みの場合は遅延なし)
+func (r *replica) measure(ctx context.Context) {
+	measuredAt := time.Now()
+	var status replicaStatus
+	err := r.conn.WithContext(ctx).Raw(`SELECT pg_is_in_recovery() AS in_recovery,
+CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0) END AS lag_seconds`).Scan(&status).Error
+
+	replayedAt := time.Time{}
+	switch {
+	case err != nil:
+		log.Error().Err(err).Msg(fmt.Sprintf("レプリカの遅延測定でエラー発生 ホスト : %s", r.host))
+	case !status.InRecovery:
+		// 昇格済みなどレプリカでない場合は参照に使用しない
+		log.Warn().Msg(fmt.Sprintf("レプリカがリカバリ中ではありません ホスト : %s", r.host))
+	default:
+		replayedAt = measuredAt.Add(-time.Duration(status.LagSeconds * float64(time.Second)))
+	}
+
+	r.mu.Lock()
+	r.replayedAt = replayedAt
+	r.mu.Unlock()
+}
+
+// WithReadAfter 参照時に反映済みであるべき更新の時刻をcontextへ設定
+func WithReadAfter(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, readAfterKey{}, t)
+}
+
+// ReadAfterFromContext contextに設定された反映済みであるべき更新の時刻を取得(未設定の場合はゼロ値)
+func ReadAfterFromContext(ctx context.Context) time.Time {
+	t, _ := ctx.Value(readAfterKey{}).(time.Time)
+	return t
+}
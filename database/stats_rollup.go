@@ -0,0 +1,10 @@
+package database
+
+import (
+	"gorm.io/gorm"
+)
+
+// initRollupState ロールアップ状態の行を作成(作成済みの場合は何もしない)
+func initRollupState(conn *gorm.DB) error {
+	return conn.Exec("INSERT INTO rollup_states (id, last_history_id, updated_at) VALUES (1, 0, now()) ON CONFLICT (id) DO NOTHING").Error
+}
@@ -0,0 +1,39 @@
+package database
+
+import (
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// uniqueUsernames テナント内のユーザー名(大文字小文字を区別しない)の一意インデックスを作成
+//
+// 既存の重複は最も古いユーザー以外のユーザー名を"ユーザー名_ユーザーID"へ変更してから作成する
+func uniqueUsernames(conn *gorm.DB) error {
+	return conn.Transaction(func(tx *gorm.DB) error {
+		var duplicates []struct {
+			ID       uint
+			Username string
+		}
+		if err := tx.Raw(`SELECT id, username FROM (
+	SELECT id, username, ROW_NUMBER() OVER (PARTITION BY tenant_id, lower(username) ORDER BY id) AS n FROM users WHERE deleted_at IS NULL
+) u WHERE n > 1`).Scan(&duplicates).Error; err != nil {
+			return err
+		}
+		for _, d := range duplicates {
+			renamed := fmt.Sprintf("%s_%d", d.Username, d.ID)
+			if err := tx.Exec("UPDATE users SET username = ? WHERE id = ?", renamed, d.ID).Error; err != nil {
+				return err
+			}
+			log.Warn().Msg(fmt.Sprintf("重複したユーザー名を変更 ユーザーID : %d %s -> %s", d.ID, d.Username, renamed))
+		}
+
+		// テナント導入前の全テナント共通の一意インデックスを削除
+		if err := tx.Exec("DROP INDEX IF EXISTS idx_users_username_lower").Error; err != nil {
+			return err
+		}
+
+		// 論理削除済みのユーザーのユーザー名は再利用可能
+		return tx.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_users_tenant_username_lower ON users (tenant_id, lower(username)) WHERE deleted_at IS NULL").Error
+	})
+}
@@ -0,0 +1,33 @@
+package event
+
+import (
+	"coin-api/domain/model"
+	"time"
+)
+
+// BalanceChanged 残高変更通知(commit時にLISTEN/NOTIFYで全インスタンスへ配信)
+type BalanceChanged struct {
+	UserId             uint       `json:"userid"`
+	Balance            int        `json:"balance"`
+	HeldBalance        int        `json:"held_balance"`
+	HistoryId          uint       `json:"history_id,omitempty"`
+	Operation          string     `json:"operation,omitempty"`
+	Amount             int        `json:"amount,omitempty"`
+	OperationTimestamp *time.Time `json:"operation_timestamp,omitempty"`
+}
+
+func NewBalanceChanged(user *model.User, history *model.CoinHistory) *BalanceChanged {
+	e := &BalanceChanged{
+		UserId:      user.ID,
+		Balance:     *user.CoinBalance,
+		HeldBalance: *user.HeldBalance,
+	}
+	// 保留解除のみの場合など履歴を伴わない変更もある
+	if history != nil {
+		e.HistoryId = history.ID
+		e.Operation = history.Operation
+		e.Amount = history.Amount
+		e.OperationTimestamp = &history.OperationTimestamp
+	}
+	return e
+}
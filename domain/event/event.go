@@ -0,0 +1,170 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	CoinAddedName       = "CoinAdded"
+	CoinUsedName        = "CoinUsed"
+	CoinTransferredName = "CoinTransferred"
+	CoinHeldName        = "CoinHeld"
+	CoinRefundedName    = "CoinRefunded"
+	CoinReversedName    = "CoinReversed"
+	ScheduleSkippedName = "ScheduleSkipped"
+	UserCreatedName     = "UserCreated"
+	// PasswordResetRequestedName パスワード再設定トークンの発行(内部イベント、トークンは含まない)
+	PasswordResetRequestedName = "PasswordResetRequested"
+)
+
+// internalEvents メッセージバスへの発行・Webhookの配信を行わない内部イベント
+var internalEvents = map[string]bool{
+	PasswordResetRequestedName: true,
+}
+
+// IsInternal メッセージバスへの発行・Webhookの配信を行わない内部イベントか判定
+func IsInternal(name string) bool {
+	return internalEvents[name]
+}
+
+// DomainEvent 残高更新と同一transactionでoutboxに登録されるイベント
+type DomainEvent interface {
+	EventName() string
+	AggregateId() uint
+}
+
+type CoinAdded struct {
+	UserId     uint      `json:"userid"`
+	Amount     int       `json:"amount"`
+	Balance    int       `json:"balance"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+type CoinUsed struct {
+	UserId     uint      `json:"userid"`
+	Amount     int       `json:"amount"`
+	Balance    int       `json:"balance"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+type CoinTransferred struct {
+	TransferId      *uint     `json:"transfer_id,omitempty"`
+	Sender          uint      `json:"sender"`
+	Receiver        uint      `json:"receiver"`
+	Amount          int       `json:"amount"`
+	SenderBalance   int       `json:"sender_balance"`
+	ReceiverBalance int       `json:"receiver_balance"`
+	OccurredAt      time.Time `json:"occurred_at"`
+}
+
+// CoinHeld 承認要の送金による保留(承認時はCoinTransferred、拒否・期限切れ時はCoinRefunded)
+type CoinHeld struct {
+	TransferId    uint      `json:"transfer_id"`
+	Sender        uint      `json:"sender"`
+	Receiver      uint      `json:"receiver"`
+	Amount        int       `json:"amount"`
+	SenderBalance int       `json:"sender_balance"`
+	SenderHeld    int       `json:"sender_held_balance"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+// CoinRefunded 保留中の送金の拒否(REJECTED)・期限切れ(EXPIRED)による返金
+type CoinRefunded struct {
+	TransferId    uint      `json:"transfer_id"`
+	Sender        uint      `json:"sender"`
+	Receiver      uint      `json:"receiver"`
+	Amount        int       `json:"amount"`
+	Status        string    `json:"status"`
+	SenderBalance int       `json:"sender_balance"`
+	SenderHeld    int       `json:"sender_held_balance"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+// CoinReversed 履歴の取消(送金の取消は送受それぞれの打消し履歴ごとに登録)
+type CoinReversed struct {
+	HistoryId    uint      `json:"history_id"`
+	ReversalOf   uint      `json:"reversal_of"`
+	UserId       uint      `json:"userid"`
+	Amount       int       `json:"amount"`
+	Balance      int       `json:"balance"`
+	Counterparty *uint     `json:"counterparty,omitempty"`
+	Reason       string    `json:"reason"`
+	OccurredAt   time.Time `json:"occurred_at"`
+}
+
+// ScheduleSkipped 残高不足・送金ルール違反等による定期送金のスキップ(実行履歴の登録と同一transaction)
+type ScheduleSkipped struct {
+	ScheduleId uint      `json:"schedule_id"`
+	Sender     uint      `json:"sender"`
+	Receiver   uint      `json:"receiver"`
+	Amount     int       `json:"amount"`
+	Reason     string    `json:"reason"`
+	NextRunAt  time.Time `json:"next_run_at"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+type UserCreated struct {
+	UserId     uint      `json:"userid"`
+	Username   string    `json:"username"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+type PasswordResetRequested struct {
+	UserId     uint      `json:"userid"`
+	Username   string    `json:"username"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+func (e *CoinAdded) EventName() string              { return CoinAddedName }
+func (e *CoinAdded) AggregateId() uint              { return e.UserId }
+func (e *CoinUsed) EventName() string               { return CoinUsedName }
+func (e *CoinUsed) AggregateId() uint               { return e.UserId }
+func (e *CoinTransferred) EventName() string        { return CoinTransferredName }
+func (e *CoinTransferred) AggregateId() uint        { return e.Sender }
+func (e *CoinHeld) EventName() string               { return CoinHeldName }
+func (e *CoinHeld) AggregateId() uint               { return e.Sender }
+func (e *CoinRefunded) EventName() string           { return CoinRefundedName }
+func (e *CoinRefunded) AggregateId() uint           { return e.Sender }
+func (e *CoinReversed) EventName() string           { return CoinReversedName }
+func (e *CoinReversed) AggregateId() uint           { return e.UserId }
+func (e *ScheduleSkipped) EventName() string        { return ScheduleSkippedName }
+func (e *ScheduleSkipped) AggregateId() uint        { return e.Sender }
+func (e *UserCreated) EventName() string            { return UserCreatedName }
+func (e *UserCreated) AggregateId() uint            { return e.UserId }
+func (e *PasswordResetRequested) EventName() string { return PasswordResetRequestedName }
+func (e *PasswordResetRequested) AggregateId() uint { return e.UserId }
+
+// Decode outboxに保存されたイベント名とペイロードからイベントを復元
+func Decode(name string, payload []byte) (DomainEvent, error) {
+	var e DomainEvent
+	switch name {
+	case CoinAddedName:
+		e = &CoinAdded{}
+	case CoinUsedName:
+		e = &CoinUsed{}
+	case CoinTransferredName:
+		e = &CoinTransferred{}
+	case CoinHeldName:
+		e = &CoinHeld{}
+	case CoinRefundedName:
+		e = &CoinRefunded{}
+	case CoinReversedName:
+		e = &CoinReversed{}
+	case ScheduleSkippedName:
+		e = &ScheduleSkipped{}
+	case UserCreatedName:
+		e = &UserCreated{}
+	case PasswordResetRequestedName:
+		e = &PasswordResetRequested{}
+	default:
+		return nil, fmt.Errorf("未定義のイベントです : %s", name)
+	}
+	if err := json.Unmarshal(payload, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Amount 1回の操作で扱うコイン量(最小単位、1以上Coin.MaxAmount以下)
+type Amount int
+
+// maxAmountCoins 1回の操作で扱えるコイン量の上限(コイン単位)
+const maxAmountCoins = 1000000000
+
+// MaxAmount 1回の操作で扱えるコイン量の上限(最小単位)
+func (c Coin) MaxAmount() Amount {
+	return Amount(maxAmountCoins * c.Scale())
+}
+
+var (
+	ErrAmountInvalid     = errors.New("金額は数値で指定してください")
+	ErrAmountNotPositive = errors.New("金額は0より大きい値を指定してください")
+	ErrAmountTooLarge    = fmt.Errorf("金額は%d以下を指定してください", maxAmountCoins)
+	ErrBalanceOverflow   = errors.New("残高が上限を超えるため処理できません")
+)
+
+func (c Coin) NewAmount(v int64) (Amount, error) {
+	if v <= 0 {
+		return 0, ErrAmountNotPositive
+	}
+	if v > int64(c.MaxAmount()) {
+		return 0, ErrAmountTooLarge
+	}
+	return Amount(v), nil
+}
+
+// ParseAmount 10進数表記の文字列からAmountを生成(桁あふれは上限超過として扱う)
+func (c Coin) ParseAmount(s string) (Amount, error) {
+	d, err := c.ParseDecimal(s)
+	if err != nil {
+		var numErr *strconv.NumError
+		if errors.As(err, &numErr) && errors.Is(numErr.Err, strconv.ErrRange) {
+			if len(s) > 0 && s[0] == '-' {
+				return 0, ErrAmountNotPositive
+			}
+			return 0, ErrAmountTooLarge
+		}
+		return 0, err
+	}
+	return c.NewAmount(d.Int64())
+}
+
+func (a Amount) Int() int {
+	return int(a)
+}
+
+// AddTo 残高へ加算(桁あふれの場合はエラー)
+func (a Amount) AddTo(balance int) (int, error) {
+	if balance > math.MaxInt-int(a) {
+		return 0, ErrBalanceOverflow
+	}
+	return balance + int(a), nil
+}
+
+// SubtractFrom 残高から減算(桁あふれの場合はエラー、残高不足の判定は呼び出し側で行う)
+func (a Amount) SubtractFrom(balance int) (int, error) {
+	if balance < math.MinInt+int(a) {
+		return 0, ErrBalanceOverflow
+	}
+	return balance - int(a), nil
+}
@@ -0,0 +1,135 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestCoinNewAmount(t *testing.T) {
+	coin := Coin{Precision: 2}
+	max := int64(coin.MaxAmount())
+	tests := []struct {
+		v       int64
+		want    Amount
+		wantErr error
+	}{
+		{v: 0, wantErr: ErrAmountNotPositive},
+		{v: -1, wantErr: ErrAmountNotPositive},
+		{v: 1, want: 1},
+		{v: max - 1, want: Amount(max - 1)},
+		{v: max, want: Amount(max)},
+		{v: max + 1, wantErr: ErrAmountTooLarge},
+	}
+	for _, tt := range tests {
+		got, err := coin.NewAmount(tt.v)
+		if !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Errorf("NewAmount(%d) = %d, %v, want %d, %v", tt.v, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestCoinMaxAmount(t *testing.T) {
+	// 上限額は最小単位でコインの桁数分だけ大きい(桁数の上限9でもint64に収まる)
+	tests := []struct {
+		precision int
+		want      Amount
+	}{
+		{precision: 0, want: 1000000000},
+		{precision: 2, want: 100000000000},
+		{precision: 9, want: 1000000000000000000},
+	}
+	for _, tt := range tests {
+		if got := (Coin{Precision: tt.precision}).MaxAmount(); got != tt.want || got <= 0 {
+			t.Errorf("Coin{Precision: %d}.MaxAmount() = %d, want %d", tt.precision, got, tt.want)
+		}
+	}
+}
+
+func TestCoinParseAmount(t *testing.T) {
+	tests := []struct {
+		coin    Coin
+		s       string
+		want    Amount
+		wantErr error
+	}{
+		{coin: Coin{Precision: 2}, s: "1.5", want: 150},
+		{coin: Coin{Precision: 3}, s: "1.5", want: 1500},
+		{coin: Coin{Precision: 0}, s: "15", want: 15},
+		{coin: Coin{Precision: 2}, s: "0.01", want: 1},
+
+		// 0・負数は0より大きい値のみ
+		{coin: Coin{Precision: 2}, s: "0", wantErr: ErrAmountNotPositive},
+		{coin: Coin{Precision: 2}, s: "0.00", wantErr: ErrAmountNotPositive},
+		{coin: Coin{Precision: 2}, s: "-1", wantErr: ErrAmountNotPositive},
+		{coin: Coin{Precision: 2}, s: "-99999999999999999999", wantErr: ErrAmountNotPositive},
+
+		// 指数表記・数値以外は不正な金額
+		{coin: Coin{Precision: 2}, s: "1e3", wantErr: ErrAmountInvalid},
+		{coin: Coin{Precision: 2}, s: "1E3", wantErr: ErrAmountInvalid},
+		{coin: Coin{Precision: 2}, s: "", wantErr: ErrAmountInvalid},
+		{coin: Coin{Precision: 2}, s: "1.", wantErr: ErrAmountInvalid},
+		{coin: Coin{Precision: 2}, s: ".5", wantErr: ErrAmountInvalid},
+		{coin: Coin{Precision: 2}, s: "+1", wantErr: ErrAmountInvalid},
+		{coin: Coin{Precision: 2}, s: "1,000", wantErr: ErrAmountInvalid},
+
+		// コインの桁数を超える小数
+		{coin: Coin{Precision: 0}, s: "1.5", wantErr: ErrDecimalPrecision},
+		{coin: Coin{Precision: 2}, s: "0.001", wantErr: ErrDecimalPrecision},
+		{coin: Coin{Precision: 2}, s: "1.500", wantErr: ErrDecimalPrecision},
+
+		// 上限額の境界(桁あふれは上限超過)
+		{coin: Coin{Precision: 2}, s: "999999999.99", want: 99999999999},
+		{coin: Coin{Precision: 2}, s: "1000000000", want: 100000000000},
+		{coin: Coin{Precision: 2}, s: "1000000000.01", wantErr: ErrAmountTooLarge},
+		{coin: Coin{Precision: 9}, s: "1000000000", want: 1000000000000000000},
+		{coin: Coin{Precision: 9}, s: "1000000000.000000001", wantErr: ErrAmountTooLarge},
+		{coin: Coin{Precision: 2}, s: "99999999999999999999", wantErr: ErrAmountTooLarge},
+	}
+	for _, tt := range tests {
+		got, err := tt.coin.ParseAmount(tt.s)
+		if !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Errorf("Coin{Precision: %d}.ParseAmount(%q) = %d, %v, want %d, %v", tt.coin.Precision, tt.s, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestAmountAddTo(t *testing.T) {
+	tests := []struct {
+		a       Amount
+		balance int
+		want    int
+		wantErr error
+	}{
+		{a: 150, balance: 0, want: 150},
+		{a: 150, balance: -200, want: -50},
+		{a: 1, balance: math.MaxInt - 1, want: math.MaxInt},
+		{a: 1, balance: math.MaxInt, wantErr: ErrBalanceOverflow},
+		{a: 100, balance: math.MaxInt - 99, wantErr: ErrBalanceOverflow},
+	}
+	for _, tt := range tests {
+		got, err := tt.a.AddTo(tt.balance)
+		if !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Errorf("Amount(%d).AddTo(%d) = %d, %v, want %d, %v", tt.a, tt.balance, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestAmountSubtractFrom(t *testing.T) {
+	tests := []struct {
+		a       Amount
+		balance int
+		want    int
+		wantErr error
+	}{
+		{a: 150, balance: 200, want: 50},
+		{a: 1, balance: math.MinInt + 1, want: math.MinInt},
+		{a: 1, balance: math.MinInt, wantErr: ErrBalanceOverflow},
+	}
+	for _, tt := range tests {
+		got, err := tt.a.SubtractFrom(tt.balance)
+		if !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Errorf("Amount(%d).SubtractFrom(%d) = %d, %v, want %d, %v", tt.a, tt.balance, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
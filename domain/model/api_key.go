@@ -0,0 +1,28 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ApiKey サービス間連携用のAPIキー(キー自体は保存せずSHA-256のハッシュと識別用のプレフィックスのみ保持)
+type ApiKey struct {
+	gorm.Model
+	TenantId string `gorm:"column:tenant_id;not null;default:default;index"`
+	Name     string `gorm:"column:name"`
+	Prefix   string `gorm:"column:prefix;uniqueIndex"`
+	KeyHash  string `gorm:"column:key_hash;not null;uniqueIndex"`
+	Scopes   string `gorm:"column:scopes"`
+	// UserId 発行対象のユーザー(nilの場合はユーザーに紐づかないサービス用のAPIキー)
+	UserId     *uint      `gorm:"column:userid;index"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at"`
+}
+
+// ApiKeyHash APIキーのSHA-256(16進数)
+func ApiKeyHash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+)
+
+// AuditLog 状態変更リクエストの監査ログ(追記のみ、更新・削除はDBのトリガーで禁止)
+type AuditLog struct {
+	ID           uint      `gorm:"primarykey"`
+	TenantId     string    `gorm:"column:tenant_id;not null;default:default;index"`
+	RequestId    string    `gorm:"column:request_id;index"`
+	ActorType    string    `gorm:"column:actor_type"`
+	Actor        string    `gorm:"column:actor;index"`
+	Method       string    `gorm:"column:method"`
+	Endpoint     string    `gorm:"column:endpoint;index"`
+	Payload      string    `gorm:"column:payload;type:jsonb"`
+	Result       string    `gorm:"column:result;index"`
+	StatusCode   int       `gorm:"column:status_code"`
+	ErrorMessage string    `gorm:"column:error_message"`
+	ClientIP     string    `gorm:"column:client_ip"`
+	CreatedAt    time.Time `gorm:"column:created_at;index"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+)
+
+// BalanceSnapshot 日次の残高スナップショット(as_ofより前の履歴の合計)
+type BalanceSnapshot struct {
+	UserId    uint      `gorm:"column:userid;primaryKey;autoIncrement:false"`
+	AsOf      time.Time `gorm:"column:as_of;primaryKey"`
+	Balance   int       `gorm:"column:balance"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+// SnapshotState スナップショット作成済みの日付の境界(1行のみ)
+type SnapshotState struct {
+	ID        uint       `gorm:"primaryKey"`
+	LastAsOf  *time.Time `gorm:"column:last_as_of"`
+	UpdatedAt time.Time  `gorm:"column:updated_at"`
+}
+
+// SnapshotMismatch 履歴から算出した残高と一致しないスナップショット
+type SnapshotMismatch struct {
+	UserId   uint      `gorm:"column:userid"`
+	TenantId string    `gorm:"column:tenant_id"`
+	AsOf     time.Time `gorm:"column:as_of"`
+	Balance  int       `gorm:"column:balance"`
+	Actual   int       `gorm:"column:actual"`
+}
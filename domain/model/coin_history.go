@@ -1,14 +1,61 @@
 package model
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"gorm.io/gorm"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type CoinHistory struct {
 	gorm.Model
+	TenantId           string    `gorm:"column:tenant_id;not null;default:default;index"`
 	Operation          string    `gorm:"column:operation"`
-	OperationTimestamp time.Time `gorm:"column:operation_timestamp"`
-	UserId             uint      `gorm:"column:userid"`
+	OperationTimestamp time.Time `gorm:"column:operation_timestamp;index;index:idx_coin_histories_user_timestamp,priority:2"`
+	UserId             uint      `gorm:"column:userid;index:idx_coin_histories_user_timestamp,priority:1"`
 	Amount             int       `gorm:"column:amount"`
+	Counterparty       *uint     `gorm:"column:counterparty"`
+	ReversalOf         *uint     `gorm:"column:reversal_of;uniqueIndex"`
+	Reason             string    `gorm:"column:reason"`
+	ScheduleId         *uint     `gorm:"column:schedule_id;index"`
+	PrevHash           string    `gorm:"column:prev_hash"`
+	Hash               string    `gorm:"column:hash;index"`
+	ActorType          string    `gorm:"column:actor_type"`
+	Actor              string    `gorm:"column:actor;index"`
+}
+
+// ChainHash 履歴の内容と直前の履歴のハッシュから算出したSHA-256(16進数)
+// 金額は桁数によらない10進数表記で算出するため、桁数の変換後もハッシュは変わらない(precisionは保存時の金額の桁数)
+func (c *CoinHistory) ChainHash(prevHash string, precision int) string {
+	return c.chainHash(prevHash, canonicalDecimal(int64(c.Amount), precision))
+}
+
+// LegacyChainHash 金額を最小単位の整数で算出していた旧形式のハッシュ(旧形式のチェーンの移行時の検証用)
+func (c *CoinHistory) LegacyChainHash(prevHash string) string {
+	return c.chainHash(prevHash, strconv.Itoa(c.Amount))
+}
+
+func (c *CoinHistory) chainHash(prevHash string, amount string) string {
+	content := strings.Join([]string{
+		strconv.FormatUint(uint64(c.UserId), 10),
+		c.Operation,
+		c.OperationTimestamp.UTC().Format(time.RFC3339Nano),
+		amount,
+		optionalUint(c.Counterparty),
+		optionalUint(c.ReversalOf),
+		c.Reason,
+		optionalUint(c.ScheduleId),
+		prevHash,
+	}, "|")
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func optionalUint(v *uint) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*v), 10)
 }
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"gorm.io/gorm"
+)
+
+// CoinSetting DBに保存済みの金額・残高の小数点以下の桁数と履歴のハッシュチェーンの形式
+type CoinSetting struct {
+	gorm.Model
+	Code      string `gorm:"column:code;uniqueIndex"`
+	Precision int    `gorm:"column:precision"`
+	// ChainVersion 履歴のハッシュチェーンの形式(0 : 旧形式またはチェーン導入前、1 : 金額を桁数によらない10進数表記で算出)
+	ChainVersion int `gorm:"column:chain_version;not null;default:0"`
+}
+
+// CoinPrecisionChange 金額・残高の桁数の変換の記録
+type CoinPrecisionChange struct {
+	gorm.Model
+	Code          string `gorm:"column:code;index"`
+	FromPrecision int    `gorm:"column:from_precision"`
+	ToPrecision   int    `gorm:"column:to_precision"`
+}
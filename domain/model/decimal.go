@@ -0,0 +1,125 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Coin コインの種類と小数点以下の桁数
+type Coin struct {
+	Code      string
+	Precision int
+}
+
+var ErrDecimalPrecision = errors.New("金額の小数点以下の桁数が上限を超えています")
+
+// Decimal 10^-precision単位の整数で保持する固定小数点のコイン量(JSONではコインの桁数の文字列)
+type Decimal struct {
+	value     int64
+	precision int
+}
+
+// Scale 1コインあたりの最小単位数
+func (c Coin) Scale() int64 {
+	return pow10(c.Precision)
+}
+
+// Decimal 最小単位の整数からコインの桁数のDecimalを生成
+func (c Coin) Decimal(v int) Decimal {
+	return Decimal{value: int64(v), precision: c.Precision}
+}
+
+// ParseDecimal 10進数表記の文字列を最小単位の整数へ変換(丸めは行わない)
+func (c Coin) ParseDecimal(s string) (Decimal, error) {
+	neg := strings.HasPrefix(s, "-")
+	body := strings.TrimPrefix(s, "-")
+	intPart, fracPart, hasFrac := strings.Cut(body, ".")
+	if intPart == "" || (hasFrac && fracPart == "") || !isDigits(intPart) || !isDigits(fracPart) {
+		return Decimal{}, ErrAmountInvalid
+	}
+	if len(fracPart) > c.Precision {
+		return Decimal{}, ErrDecimalPrecision
+	}
+
+	// 整数部と小数部を最小単位へ変換(桁あふれはstrconvの範囲エラー)
+	fracPart += strings.Repeat("0", c.Precision-len(fracPart))
+	i, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil {
+		return Decimal{}, err
+	}
+	var f int64
+	if fracPart != "" {
+		f, _ = strconv.ParseInt(fracPart, 10, 64)
+	}
+	scale := c.Scale()
+	if i > (math.MaxInt64-f)/scale {
+		return Decimal{}, &strconv.NumError{Func: "ParseDecimal", Num: s, Err: strconv.ErrRange}
+	}
+	v := i*scale + f
+	if neg {
+		v = -v
+	}
+	return Decimal{value: v, precision: c.Precision}, nil
+}
+
+// Int64 最小単位の整数
+func (d Decimal) Int64() int64 {
+	return d.value
+}
+
+// Add 最小単位の整数を加算
+func (d Decimal) Add(v int) Decimal {
+	return Decimal{value: d.value + int64(v), precision: d.precision}
+}
+
+func (d Decimal) String() string {
+	return formatDecimal(d.value, d.precision)
+}
+
+// formatDecimal 10^-precision単位の整数を小数点以下precision桁の10進数表記へ変換
+func formatDecimal(v int64, precision int) string {
+	if precision == 0 {
+		return strconv.FormatInt(v, 10)
+	}
+	sign := ""
+	u := uint64(v)
+	if v < 0 {
+		sign = "-"
+		u = uint64(-v)
+	}
+	scale := uint64(pow10(precision))
+	frac := strconv.FormatUint(u%scale, 10)
+	return sign + strconv.FormatUint(u/scale, 10) + "." + strings.Repeat("0", precision-len(frac)) + frac
+}
+
+// canonicalDecimal 末尾の0を除いた桁数によらない10進数表記(例 : 桁数2の1050は"10.5")
+func canonicalDecimal(v int64, precision int) string {
+	s := formatDecimal(v, precision)
+	if !strings.Contains(s, ".") {
+		return s
+	}
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+func pow10(n int) int64 {
+	v := int64(1)
+	for i := 0; i < n; i++ {
+		v *= 10
+	}
+	return v
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
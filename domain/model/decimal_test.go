@@ -0,0 +1,27 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestCoinDecimalString(t *testing.T) {
+	// コインの桁数で表記
+	tests := []struct {
+		coin Coin
+		v    int
+		want string
+	}{
+		{coin: Coin{Precision: 2}, v: 150, want: "1.50"},
+		{coin: Coin{Precision: 3}, v: 150, want: "0.150"},
+		{coin: Coin{Precision: 0}, v: 150, want: "150"},
+		{coin: Coin{Precision: 2}, v: -5, want: "-0.05"},
+	}
+	for _, tt := range tests {
+		if got := tt.coin.Decimal(tt.v).String(); got != tt.want {
+			t.Errorf("Coin{Precision: %d}.Decimal(%d) = %s, want %s", tt.coin.Precision, tt.v, got, tt.want)
+		}
+	}
+	if got, _ := (Coin{Precision: 2}).Decimal(150).Add(-200).MarshalJSON(); string(got) != `"-0.50"` {
+		t.Errorf("MarshalJSON() = %s, want \"-0.50\"", got)
+	}
+}
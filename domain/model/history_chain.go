@@ -0,0 +1,34 @@
+package model
+
+// ChainBreak ハッシュチェーンが壊れている最初の履歴
+type ChainBreak struct {
+	HistoryId uint
+	Reason    string
+}
+
+// VerifyChain ユーザーの全履歴(id昇順)のハッシュチェーンを先頭から検証(問題がない場合はnil、precisionは保存時の金額の桁数)
+func VerifyChain(histories []CoinHistory, precision int) *ChainBreak {
+	return verifyChain(histories, func(h *CoinHistory, prev string) string {
+		return h.ChainHash(prev, precision)
+	})
+}
+
+// VerifyLegacyChain 旧形式(金額を最小単位の整数で算出)のハッシュチェーンを検証(旧形式のチェーンの移行時の検証用)
+func VerifyLegacyChain(histories []CoinHistory) *ChainBreak {
+	return verifyChain(histories, (*CoinHistory).LegacyChainHash)
+}
+
+func verifyChain(histories []CoinHistory, hash func(h *CoinHistory, prev string) string) *ChainBreak {
+	prev := ""
+	for i := range histories {
+		h := &histories[i]
+		if h.PrevHash != prev {
+			return &ChainBreak{HistoryId: h.ID, Reason: "直前の履歴のハッシュと一致しません"}
+		}
+		if h.Hash != hash(h, prev) {
+			return &ChainBreak{HistoryId: h.ID, Reason: "履歴の内容とハッシュが一致しません"}
+		}
+		prev = h.Hash
+	}
+	return nil
+}
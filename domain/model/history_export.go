@@ -0,0 +1,7 @@
+package model
+
+// HistoryExportRow 取引後残高付きのコイン履歴(エクスポート用)
+type HistoryExportRow struct {
+	CoinHistory
+	BalanceAfter int `gorm:"column:balance_after"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type OutboxEvent struct {
+	gorm.Model
+	TenantId     string     `gorm:"column:tenant_id;not null;default:default;index"`
+	EventType    string     `gorm:"column:event_type"`
+	UserId       uint       `gorm:"column:userid"`
+	Payload      string     `gorm:"column:payload;type:jsonb"`
+	DispatchedAt *time.Time `gorm:"column:dispatched_at;index"`
+	PublishedAt  *time.Time `gorm:"column:published_at;index"`
+}
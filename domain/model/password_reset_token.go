@@ -0,0 +1,15 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// PasswordResetToken パスワード再設定用のワンタイムトークン(トークン自体は保存せずSHA-256のハッシュのみ保持)
+type PasswordResetToken struct {
+	gorm.Model
+	UserId    uint       `gorm:"column:userid;not null;index"`
+	TokenHash string     `gorm:"column:token_hash;not null;uniqueIndex"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;not null"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+}
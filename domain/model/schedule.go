@@ -0,0 +1,25 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type Schedule struct {
+	gorm.Model
+	Sender    uint       `gorm:"column:sender;index"`
+	Receiver  uint       `gorm:"column:receiver"`
+	Amount    int        `gorm:"column:amount"`
+	Interval  string     `gorm:"column:interval"`
+	Status    string     `gorm:"column:status"`
+	NextRunAt time.Time  `gorm:"column:next_run_at;index"`
+	LastRunAt *time.Time `gorm:"column:last_run_at"`
+}
+
+type ScheduleExecution struct {
+	gorm.Model
+	ScheduleId uint      `gorm:"column:schedule_id;index"`
+	ExecutedAt time.Time `gorm:"column:executed_at"`
+	Status     string    `gorm:"column:status"`
+	Message    string    `gorm:"column:message"`
+}
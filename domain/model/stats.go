@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+)
+
+// CoinHistoryRollup 1時間単位・ユーザー・区分ごとのコイン履歴の集計
+type CoinHistoryRollup struct {
+	Bucket    time.Time `gorm:"column:bucket;primaryKey"`
+	UserId    uint      `gorm:"column:userid;primaryKey"`
+	Operation string    `gorm:"column:operation;primaryKey"`
+	Amount    int       `gorm:"column:amount"`
+	Count     int       `gorm:"column:count"`
+}
+
+// RollupState ロールアップ済みの履歴IDの位置(1行のみ)
+type RollupState struct {
+	ID            uint      `gorm:"primaryKey"`
+	LastHistoryId uint      `gorm:"column:last_history_id"`
+	UpdatedAt     time.Time `gorm:"column:updated_at"`
+}
+
+// Circulation 現在のコイン流通量
+type Circulation struct {
+	Users     int64 `gorm:"column:users"`
+	Available int   `gorm:"column:available"`
+	Held      int   `gorm:"column:held"`
+}
+
+// OperationBucket 時間区切り・区分ごとの集計
+type OperationBucket struct {
+	Bucket    time.Time `gorm:"column:bucket"`
+	Operation string    `gorm:"column:operation"`
+	Amount    int       `gorm:"column:amount"`
+	Count     int       `gorm:"column:count"`
+}
+
+// ActiveUsersBucket 時間区切りごとの操作のあったユーザー数
+type ActiveUsersBucket struct {
+	Bucket time.Time `gorm:"column:bucket"`
+	Users  int       `gorm:"column:users"`
+}
+
+// UserTotal ユーザーごとの合計金額
+type UserTotal struct {
+	UserId uint `gorm:"column:userid"`
+	Amount int  `gorm:"column:amount"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type Transfer struct {
+	gorm.Model
+	Sender     uint       `gorm:"column:sender"`
+	Receiver   uint       `gorm:"column:receiver"`
+	Amount     int        `gorm:"column:amount"`
+	Status     string     `gorm:"column:status;index"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at"`
+	ResolvedAt *time.Time `gorm:"column:resolved_at"`
+}
@@ -2,11 +2,16 @@ package model
 
 import (
 	"gorm.io/gorm"
+	"time"
 )
 
 type User struct {
 	gorm.Model
-	Username    string `gorm:"column:username"`
-	Password    string `gorm:"column:password"`
-	CoinBalance *int   `gorm:"column:coinbalance"`
+	TenantId     string     `gorm:"column:tenant_id;not null;default:default;index"`
+	Username     string     `gorm:"column:username"`
+	Password     string     `gorm:"column:password"`
+	CoinBalance  *int       `gorm:"column:coinbalance"`
+	HeldBalance  *int       `gorm:"column:heldbalance;not null;default:0"`
+	FailedLogins int        `gorm:"column:failed_logins;not null;default:0"`
+	LockedUntil  *time.Time `gorm:"column:locked_until"`
 }
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type WebhookSubscription struct {
+	gorm.Model
+	TenantId   string `gorm:"column:tenant_id;not null;default:default;index"`
+	URL        string `gorm:"column:url"`
+	Secret     string `gorm:"column:secret"`
+	EventTypes string `gorm:"column:event_types"`
+	Active     bool   `gorm:"column:active"`
+}
+
+type WebhookDelivery struct {
+	gorm.Model
+	SubscriptionId uint      `gorm:"column:subscription_id;index"`
+	EventId        uint      `gorm:"column:event_id"`
+	EventType      string    `gorm:"column:event_type"`
+	Payload        string    `gorm:"column:payload;type:jsonb"`
+	Status         string    `gorm:"column:status;index"`
+	Attempts       int       `gorm:"column:attempts"`
+	NextAttemptAt  time.Time `gorm:"column:next_attempt_at"`
+	ResponseStatus int       `gorm:"column:response_status"`
+	LastError      string    `gorm:"column:last_error"`
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"coin-api/domain/model"
+	"time"
+)
+
+type IApiKeyRepository interface {
+	SelectById(id uint) (*model.ApiKey, error)
+	SelectAll() ([]model.ApiKey, error)
+	// SelectActiveByHash 失効していないAPIキーを取得(存在しない場合はnil)
+	SelectActiveByHash(keyHash string) (*model.ApiKey, error)
+	Insert(key *model.ApiKey) (*model.ApiKey, error)
+	Revoke(key *model.ApiKey, now time.Time) (*model.ApiKey, error)
+	UpdateLastUsed(id uint, now time.Time) error
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"coin-api/domain/model"
+	"context"
+	"time"
+)
+
+// AuditFilter 監査ログの検索条件(ゼロ値の項目は条件に含めない)
+type AuditFilter struct {
+	Actor     string
+	Endpoint  string
+	Result    string
+	RequestId string
+	From      *time.Time
+	To        *time.Time
+	Limit     int
+}
+
+type IAuditRepository interface {
+	Select(filter *AuditFilter) ([]model.AuditLog, error)
+	Insert(ctx context.Context, entry *model.AuditLog) (*model.AuditLog, error)
+}
@@ -3,10 +3,35 @@ package repository
 import (
 	"coin-api/domain/model"
 	"context"
+	"errors"
+	"time"
 )
 
+// ErrAlreadyReversed 取消済みの履歴の取消(並行する取消の一意制約違反を含む)
+var ErrAlreadyReversed = errors.New("取消済みの履歴です")
+
+// ExportFilter コイン履歴エクスポートの条件(nilの項目は条件に含めない)
+type ExportFilter struct {
+	UserId *uint
+	From   *time.Time
+	To     *time.Time
+}
+
 type ICoinRepository interface {
+	SelectById(id uint) (*model.CoinHistory, error)
 	SelectHistoriesByUserId(uid uint) ([]model.CoinHistory, error)
+	SelectChainByUserId(uid uint) ([]model.CoinHistory, error)
+	SelectHistoryUserIds() ([]uint, error)
+	SelectCounterpartLeg(history *model.CoinHistory, operation string) (*model.CoinHistory, error)
+	// ExistsReversalOf トランザクション内で呼び出した場合はトランザクション内で確認
+	ExistsReversalOf(ctx context.Context, ids ...uint) (bool, error)
+	SelectHistoriesByUserIdBetween(uid uint, from time.Time, to time.Time) ([]model.CoinHistory, error)
+	SumAmountByUserIdUntil(uid uint, until time.Time) (int, error)
+	ExportHistories(ctx context.Context, filter *ExportFilter, fn func(row *model.HistoryExportRow) error) error
+	// SumAmountByUserIdSince、CountByUserIdSince トランザクション内で呼び出した場合はトランザクション内で集計
+	SumAmountByUserIdSince(ctx context.Context, uid uint, since time.Time, operations ...string) (int, error)
+	CountByUserIdSince(ctx context.Context, uid uint, since time.Time, operations ...string) (int, error)
 	Insert(ctx context.Context, history *model.CoinHistory) (*model.CoinHistory, error)
+	// BatchInsert 取消済みの履歴を打ち消す履歴を登録した場合はErrAlreadyReversed
 	BatchInsert(ctx context.Context, histories []*model.CoinHistory) ([]*model.CoinHistory, error)
 }
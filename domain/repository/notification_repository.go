@@ -0,0 +1,10 @@
+package repository
+
+import (
+	"coin-api/domain/event"
+	"context"
+)
+
+type INotificationRepository interface {
+	NotifyBalanceChanged(ctx context.Context, e *event.BalanceChanged) error
+}
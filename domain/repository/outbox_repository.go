@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"coin-api/domain/model"
+	"context"
+	"time"
+)
+
+type IOutboxRepository interface {
+	SelectUndispatched(limit int) ([]model.OutboxEvent, error)
+	SelectUnpublished(limit int) ([]model.OutboxEvent, error)
+	BatchInsert(ctx context.Context, events []*model.OutboxEvent) ([]*model.OutboxEvent, error)
+	MarkDispatched(ctx context.Context, event *model.OutboxEvent, dispatchedAt time.Time) (*model.OutboxEvent, error)
+	MarkPublished(event *model.OutboxEvent, publishedAt time.Time) (*model.OutboxEvent, error)
+}
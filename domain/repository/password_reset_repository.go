@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"coin-api/domain/model"
+	"context"
+	"time"
+)
+
+type IPasswordResetRepository interface {
+	Insert(ctx context.Context, token *model.PasswordResetToken) (*model.PasswordResetToken, error)
+	// SelectValidByHash 未使用かつ期限内のトークンを更新ロックして取得(存在しない場合はnil)
+	SelectValidByHash(ctx context.Context, tokenHash string, now time.Time) (*model.PasswordResetToken, error)
+	// MarkUsedByUserId 対象ユーザーの未使用のトークンをすべて使用済みに更新
+	MarkUsedByUserId(ctx context.Context, uid uint, now time.Time) (int64, error)
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"coin-api/domain/model"
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrScheduleConflict 他の実行と競合した場合のエラー
+var ErrScheduleConflict = errors.New("スケジュールは既に更新されています")
+
+type IScheduleRepository interface {
+	SelectById(id uint) (*model.Schedule, error)
+	SelectBySender(uid uint) ([]model.Schedule, error)
+	SelectDue(now time.Time) ([]model.Schedule, error)
+	SelectExecutionsByScheduleId(id uint) ([]model.ScheduleExecution, error)
+	Insert(schedule *model.Schedule) (*model.Schedule, error)
+	Update(ctx context.Context, schedule *model.Schedule) (*model.Schedule, error)
+	ClaimNextRun(ctx context.Context, schedule *model.Schedule, next time.Time) (*model.Schedule, error)
+	InsertExecution(ctx context.Context, execution *model.ScheduleExecution) (*model.ScheduleExecution, error)
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"coin-api/domain/model"
+	"context"
+	"time"
+)
+
+// SnapshotFilter スナップショットの検索条件(nilの項目は条件に含めない)
+type SnapshotFilter struct {
+	UserId *uint
+	From   *time.Time
+	To     *time.Time
+}
+
+type ISnapshotRepository interface {
+	SelectLastAsOf() (*time.Time, error)
+	SelectFirstHistoryTimestamp() (*time.Time, error)
+	InsertDailySnapshots(ctx context.Context, from time.Time, asOf time.Time) (int64, error)
+	Count(filter *SnapshotFilter) (int64, error)
+	SelectMismatches(filter *SnapshotFilter) ([]model.SnapshotMismatch, error)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"coin-api/domain/model"
+	"context"
+	"time"
+)
+
+// StatsFilter 集計条件(intervalはhour,day,week,month、nilの項目は条件に含めない)
+type StatsFilter struct {
+	Interval string
+	TimeZone string
+	From     *time.Time
+	To       *time.Time
+}
+
+type IStatsRepository interface {
+	SelectCirculation() (*model.Circulation, error)
+	SelectOperationBuckets(filter *StatsFilter, operations ...string) ([]model.OperationBucket, error)
+	SelectActiveUsers(filter *StatsFilter) ([]model.ActiveUsersBucket, error)
+	SelectTopUsers(filter *StatsFilter, sign int, limit int, operations ...string) ([]model.UserTotal, error)
+	RefreshRollups(ctx context.Context, before time.Time) (int64, error)
+}
@@ -2,8 +2,40 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 )
 
+// ErrTxRetryable 入れ子のトランザクションで発生した直列化失敗・デッドロック(外側のトランザクション全体の再試行で解消しうるエラー)
+var ErrTxRetryable = errors.New("トランザクションが競合しました")
+
+// ErrUnavailable DBへの接続を遮断しているため処理できないエラー
+var ErrUnavailable = errors.New("データベースに接続できません")
+
 type ITxRepository interface {
-	DoInTx(ctx context.Context, f func(ctx context.Context) (interface{}, error)) (interface{}, error)
+	// DoInTx fをトランザクション内で実行(トランザクション内で呼び出した場合はセーブポイントを作成して入れ子で実行)
+	DoInTx(ctx context.Context, f func(ctx context.Context) (interface{}, error), opts ...TxOption) (interface{}, error)
+}
+
+// TxOptions トランザクションの分離レベル・読み取り専用の指定(未指定の場合はDBの既定の分離レベルで読み書き可能)
+type TxOptions struct {
+	Isolation sql.IsolationLevel
+	ReadOnly  bool
+}
+
+// TxOption DoInTxのオプション
+type TxOption func(*TxOptions)
+
+// WithIsolation トランザクションの分離レベルを指定
+func WithIsolation(level sql.IsolationLevel) TxOption {
+	return func(o *TxOptions) {
+		o.Isolation = level
+	}
+}
+
+// WithReadOnly 読み取り専用のトランザクションとする
+func WithReadOnly() TxOption {
+	return func(o *TxOptions) {
+		o.ReadOnly = true
+	}
 }
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"coin-api/domain/model"
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrTransferStatusConflict 送金ステータスが想定と異なる場合のエラー
+var ErrTransferStatusConflict = errors.New("送金ステータスが更新対象外です")
+
+type ITransferRepository interface {
+	SelectById(id uint) (*model.Transfer, error)
+	SelectExpiredPending(now time.Time) ([]model.Transfer, error)
+	Insert(ctx context.Context, transfer *model.Transfer) (*model.Transfer, error)
+	UpdateStatus(ctx context.Context, transfer *model.Transfer, from string, to string, resolvedAt time.Time) (*model.Transfer, error)
+}
@@ -3,10 +3,26 @@ package repository
 import (
 	"coin-api/domain/model"
 	"context"
+	"errors"
 )
 
+// ErrDuplicateUsername テナント内のユーザー名(大文字小文字を区別しない)の重複
+var ErrDuplicateUsername = errors.New("ユーザー名は既に使用されています")
+
 type IUserRepository interface {
 	SelectById(id uint) (*model.User, error)
-	Insert(user *model.User) (*model.User, error)
+	// SelectByUsername テナント内で大文字小文字を区別せずに検索(存在しない場合はnil)
+	SelectByUsername(username string) (*model.User, error)
+	Insert(ctx context.Context, user *model.User) (*model.User, error)
 	Update(ctx context.Context, user *model.User) (*model.User, error)
+	// SelectForUpdate トランザクション内でユーザーを行ロックして取得(デッドロック回避のためID順にロック、存在しないIDがある場合はエラー)
+	SelectForUpdate(ctx context.Context, ids ...uint) (map[uint]*model.User, error)
+	// UpdateBalances コイン残高と保留残高のみ更新
+	UpdateBalances(ctx context.Context, user *model.User) (*model.User, error)
+	// UpdateProfile ユーザー名とパスワードのみ更新
+	UpdateProfile(ctx context.Context, user *model.User) (*model.User, error)
+	// IncrementFailedLogins ログイン失敗回数を加算し、加算後の回数を返却
+	IncrementFailedLogins(ctx context.Context, uid uint) (int, error)
+	// UpdateLoginState ログイン失敗回数とロック期限のみ更新
+	UpdateLoginState(ctx context.Context, user *model.User) (*model.User, error)
 }
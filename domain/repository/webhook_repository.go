@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"coin-api/domain/model"
+	"context"
+	"time"
+)
+
+type IWebhookRepository interface {
+	SelectSubscriptionById(id uint) (*model.WebhookSubscription, error)
+	SelectSubscriptions() ([]model.WebhookSubscription, error)
+	// SelectActiveSubscriptionsByEventType イベントのテナントの有効な購読を取得
+	SelectActiveSubscriptionsByEventType(tenantId string, eventType string) ([]model.WebhookSubscription, error)
+	InsertSubscription(subscription *model.WebhookSubscription) (*model.WebhookSubscription, error)
+	UpdateSubscription(subscription *model.WebhookSubscription) (*model.WebhookSubscription, error)
+	SelectDeliveriesBySubscriptionId(id uint) ([]model.WebhookDelivery, error)
+	SelectDueDeliveries(now time.Time, limit int) ([]model.WebhookDelivery, error)
+	BatchInsertDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) ([]*model.WebhookDelivery, error)
+	UpdateDelivery(delivery *model.WebhookDelivery) (*model.WebhookDelivery, error)
+}
@@ -0,0 +1,40 @@
+package drivers
+
+import (
+	"coin-api/common/enum"
+)
+
+// apiKeyScopes APIキーで利用できるルートと必要なスコープ(いずれか)("METHOD パス"をキーとし、定義のないルートはAPIキーで利用不可)
+// PUT /v1/coinは区分(ADD/USE)に応じたスコープをユースケースで確認する。管理者APIは管理者スコープのAPIキーのみ利用可能
+var apiKeyScopes = map[string][]enum.ApiKeyScope{
+	// userAPI
+	"GET " + userApiRoot:                        {enum.SCOPE_USER_READ},
+	"GET " + userApiRoot + "/:userid":           {enum.SCOPE_USER_READ},
+	"GET " + userApiRoot + "/:userid/events":    {enum.SCOPE_USER_READ},
+	"GET " + userApiRoot + "/:userid/balance":   {enum.SCOPE_USER_READ},
+	"GET " + userApiRoot + "/:userid/statement": {enum.SCOPE_USER_READ},
+
+	// tenantAPI
+	"GET " + tenantApiRoot: {enum.SCOPE_COIN_ADD, enum.SCOPE_COIN_USE, enum.SCOPE_COIN_SEND, enum.SCOPE_COIN_READ, enum.SCOPE_USER_READ},
+
+	// coinAPI
+	"PUT " + coinApiRoot:                     {enum.SCOPE_COIN_ADD, enum.SCOPE_COIN_USE},
+	"PUT " + coinApiRoot + "/send":           {enum.SCOPE_COIN_SEND},
+	"POST " + coinApiRoot + "/send":          {enum.SCOPE_COIN_SEND},
+	"GET " + coinApiRoot + "/:userid":        {enum.SCOPE_COIN_READ},
+	"GET " + coinApiRoot + "/:userid/verify": {enum.SCOPE_COIN_READ},
+	"GET " + coinApiRoot + "/:userid/export": {enum.SCOPE_COIN_READ},
+
+	// adminAPI
+	"GET " + adminApiRoot + "/audit":              {enum.SCOPE_ADMIN},
+	"GET " + adminApiRoot + "/export":             {enum.SCOPE_ADMIN},
+	"GET " + adminApiRoot + "/stats/circulation":  {enum.SCOPE_ADMIN},
+	"GET " + adminApiRoot + "/stats/supply":       {enum.SCOPE_ADMIN},
+	"GET " + adminApiRoot + "/stats/transfers":    {enum.SCOPE_ADMIN},
+	"GET " + adminApiRoot + "/stats/active-users": {enum.SCOPE_ADMIN},
+	"GET " + adminApiRoot + "/stats/top":          {enum.SCOPE_ADMIN},
+	"POST " + adminApiRoot + "/api-keys":          {enum.SCOPE_ADMIN},
+	"GET " + adminApiRoot + "/api-keys":           {enum.SCOPE_ADMIN},
+	"DELETE " + adminApiRoot + "/api-keys/:id":    {enum.SCOPE_ADMIN},
+	"GET " + adminApiRoot + "/snapshots/verify":   {enum.SCOPE_ADMIN},
+}
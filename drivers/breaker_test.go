@@ -0,0 +1,62 @@
+package drivers
+
+import (
+	"coin-api/adapters/gateways/stream"
+	"coin-api/config"
+	"coin-api/database"
+	"coin-api/usecase/model"
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpenedDuringHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 port=1"}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	con := &database.PostgreSQLConnector{Conn: db}
+	if err := con.UseCircuitBreaker(&config.CircuitBreakerInfo{FailureThreshold: 1, OpenTimeout: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+
+	// ミドルウェアの判定後、ハンドラーのSQL実行前に他のSQLの接続エラーで遮断
+	var once sync.Once
+	if err := db.Callback().Query().Before("breaker:before_query").Register("test:open_breaker", func(*gorm.DB) {
+		once.Do(func() { db.Exec("SELECT 1") })
+	}); err != nil {
+		t.Fatal(err)
+	}
+	g := newRouter(context.Background(), con, nil, stream.NewHub())
+
+	// 遮断によるエラーは500ではなく503
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, userApiRoot+"/1", nil))
+	var res model.ErrorResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &res)
+	if w.Code != http.StatusServiceUnavailable || res.ErrorCode != http.StatusServiceUnavailable || res.Message != database.ErrUnavailable.Error() {
+		t.Errorf("status = %d, body = %s, want 503", w.Code, w.Body.String())
+	}
+	if con.RetryAfter() <= 0 {
+		t.Error("RetryAfter() = 0, want open")
+	}
+
+	// 遮断中の以降のリクエストはミドルウェアで503
+	w = httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, userApiRoot+"/1", nil))
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Errorf("status = %d, Retry-After = %q, want 503", w.Code, w.Header().Get("Retry-After"))
+	}
+}
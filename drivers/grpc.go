@@ -0,0 +1,32 @@
+package drivers
+
+import (
+	"coin-api/adapters/gateways/notifier"
+	"coin-api/adapters/gateways/rdb"
+	"coin-api/adapters/grpc"
+	"coin-api/adapters/grpc/pb"
+	"coin-api/config"
+	"coin-api/database"
+	"coin-api/usecase/interactor"
+	"google.golang.org/grpc"
+)
+
+func InitGrpcServer(con *database.PostgreSQLConnector) *grpc.Server {
+	// テナントの解決、状態変更RPCの監査ログ記録
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		services.CircuitBreakerInterceptor(con.RetryAfter),
+		services.TenantInterceptor(config.LoadConfig().TenantsInfo),
+		services.ReadYourWritesInterceptor(config.LoadConfig().ReplicaInfo),
+		services.AuditInterceptor(rdb.NewAuditRepository(con.Conn)),
+	))
+
+	// UserService(残高変更ストリームはSSEのみ提供のため購読なし)
+	us := services.NewUserService(interactor.NewUserUseCase, rdb.NewUserRepository, rdb.NewOutboxRepository, rdb.NewTxRepository, rdb.NewPasswordResetRepository, nil, notifier.NewPasswordResetNotifier(config.LoadConfig().PasswordInfo.ResetNotify), con)
+	pb.RegisterUserServiceServer(s, us)
+
+	// CoinService
+	cs := services.NewCoinService(interactor.NewCoinUseCase, rdb.NewCoinRepository, rdb.NewUserRepository, rdb.NewTransferRepository, rdb.NewOutboxRepository, rdb.NewNotificationRepository, rdb.NewTxRepository, con)
+	pb.RegisterCoinServiceServer(s, cs)
+
+	return s
+}
@@ -0,0 +1,198 @@
+package drivers
+
+import (
+	"coin-api/adapters/openapi"
+	"coin-api/usecase/model"
+)
+
+const (
+	openapiPath  = apiVersion + "/openapi.json"
+	openapiTitle = "coin-api"
+)
+
+// apiOperations InitRouterで登録する全ルートのAPI定義("METHOD パス"をキーとする)
+var apiOperations = map[string]openapi.Operation{
+	// userAPI
+	"POST " + userApiRoot: {
+		Summary: "ユーザー登録", Tag: "user",
+		Request: model.UserAddForm{}, Response: model.UserResponse{},
+	},
+	"POST " + userApiRoot + "/login": {
+		Summary: "ログイン(失敗回数が上限に達した場合は一定時間ロック)", Tag: "user",
+		Request: model.UserLoginForm{}, Response: model.UserProfileResponse{},
+	},
+	"POST " + userApiRoot + "/password-reset": {
+		Summary: "パスワード再設定トークン発行(トークンは設定した通知先へ送信、ユーザーの存在有無によらず202を返却)", Tag: "user",
+		Request: model.PasswordResetRequestForm{},
+	},
+	"POST " + userApiRoot + "/password-reset/confirm": {
+		Summary: "パスワード再設定(トークンは1回のみ使用可能)", Tag: "user",
+		Request: model.PasswordResetConfirmForm{}, Response: model.UserProfileResponse{},
+	},
+	"GET " + userApiRoot: {
+		Summary: "テナント内でユーザー名(大文字小文字を区別しない)でユーザー検索", Tag: "user",
+		Query:    []string{"username"},
+		Response: model.UserProfileResponse{},
+	},
+	"GET " + userApiRoot + "/:userid": {
+		Summary: "対象ユーザー残高取得", Tag: "user",
+		Response: model.UserBalanceResponse{},
+	},
+	"PATCH " + userApiRoot + "/:userid": {
+		Summary: "ユーザー名・パスワード変更(old_passwordで現在のパスワードを確認)", Tag: "user",
+		Request: model.UserUpdateForm{}, Response: model.UserProfileResponse{},
+	},
+	"GET " + userApiRoot + "/:userid/events": {
+		Summary: "対象ユーザー残高変更ストリーム(Server-Sent Events)", Tag: "user",
+		Response: model.BalanceEventResponse{}, Stream: true,
+	},
+	"GET " + userApiRoot + "/:userid/balance": {
+		Summary: "対象ユーザーの指定日時時点の残高取得", Tag: "user",
+		Optional: []string{"at"},
+		Response: model.BalanceAtResponse{},
+	},
+	"GET " + userApiRoot + "/:userid/statement": {
+		Summary: "対象ユーザーの取引明細取得(format=json/csv/pdfまたはAcceptヘッダーで形式指定)", Tag: "user",
+		Query: []string{"from"}, Optional: []string{"to", "format"},
+		Response: model.StatementResponse{}, Formats: []string{"text/csv", "application/pdf"},
+	},
+
+	// coinAPI
+	"PUT " + coinApiRoot: {
+		Summary: "コイン追加消費", Tag: "coin",
+		Request: model.CoinAddUseForm{}, Response: model.CoinResponse{},
+	},
+	"PUT " + coinApiRoot + "/send": {
+		Summary: "コイン送金", Tag: "coin",
+		Request: model.CoinSendForm{}, Response: model.CoinSendResponse{},
+	},
+	"POST " + coinApiRoot + "/send": {
+		Summary: "コイン送金(承認要の場合は承認待ち送金を返却)", Tag: "coin",
+		Request: model.CoinSendForm{}, Response: openapi.OneOf{model.CoinSendResponse{}, model.CoinTransferResponse{}},
+	},
+	"POST " + coinApiRoot + "/transfers/:id/accept": {
+		Summary: "承認待ち送金の承認", Tag: "coin",
+		Request: model.TransferResolveForm{}, Response: model.CoinTransferResponse{},
+	},
+	"POST " + coinApiRoot + "/transfers/:id/reject": {
+		Summary: "承認待ち送金の拒否", Tag: "coin",
+		Request: model.TransferResolveForm{}, Response: model.CoinTransferResponse{},
+	},
+	"POST " + coinApiRoot + "/history/:id/reverse": {
+		Summary: "コイン履歴取消", Tag: "coin",
+		Request: model.CoinReverseForm{}, Response: model.CoinReversalResponse{},
+	},
+	"GET " + coinApiRoot + "/:userid": {
+		Summary: "コイン履歴確認", Tag: "coin",
+		Response: []*model.CoinHistoryResponse(nil),
+	},
+	"GET " + coinApiRoot + "/:userid/verify": {
+		Summary: "コイン履歴ハッシュチェーン検証", Tag: "coin",
+		Response: model.ChainVerificationResponse{},
+	},
+	"GET " + coinApiRoot + "/:userid/export": {
+		Summary: "コイン履歴エクスポート(format=csv/ndjsonまたはAcceptヘッダーで形式指定)", Tag: "coin",
+		Optional: []string{"from", "to", "format"},
+		Formats:  []string{"text/csv", "application/x-ndjson"},
+	},
+
+	// scheduleAPI
+	"POST " + scheduleApiRoot: {
+		Summary: "定期送金スケジュール登録", Tag: "schedule",
+		Request: model.ScheduleAddForm{}, Response: model.ScheduleResponse{},
+	},
+	"GET " + scheduleApiRoot: {
+		Summary: "定期送金スケジュール一覧", Tag: "schedule", Query: []string{"userid"},
+		Response: []*model.ScheduleResponse(nil),
+	},
+	"GET " + scheduleApiRoot + "/:id/executions": {
+		Summary: "定期送金スケジュール実行履歴", Tag: "schedule",
+		Response: []*model.ScheduleExecutionResponse(nil),
+	},
+	"DELETE " + scheduleApiRoot + "/:id": {
+		Summary: "定期送金スケジュール取消", Tag: "schedule", Query: []string{"userid"},
+		Response: model.ScheduleResponse{},
+	},
+
+	// webhookAPI
+	"POST " + webhookApiRoot: {
+		Summary: "Webhook購読登録", Tag: "webhook",
+		Request: model.WebhookAddForm{}, Response: model.WebhookSubscriptionResponse{},
+	},
+	"GET " + webhookApiRoot: {
+		Summary: "Webhook購読一覧", Tag: "webhook",
+		Response: []*model.WebhookSubscriptionResponse(nil),
+	},
+	"DELETE " + webhookApiRoot + "/:id": {
+		Summary: "Webhook購読解除", Tag: "webhook",
+		Response: model.WebhookSubscriptionResponse{},
+	},
+	"GET " + webhookApiRoot + "/:id/deliveries": {
+		Summary: "Webhook配信履歴", Tag: "webhook",
+		Response: []*model.WebhookDeliveryResponse(nil),
+	},
+
+	// tenantAPI
+	"GET " + tenantApiRoot: {
+		Summary: "リクエストのテナント(X-Tenant-Id、APIキーの場合はキーのテナント)の設定取得", Tag: "tenant",
+		Response: model.TenantResponse{},
+	},
+
+	// adminAPI
+	"GET " + adminApiRoot + "/audit": {
+		Summary: "監査ログ検索", Tag: "admin",
+		Optional: []string{"actor", "endpoint", "result", "request_id", "from", "to", "limit"},
+		Response: []*model.AuditLogResponse(nil),
+	},
+	"GET " + adminApiRoot + "/stats/circulation": {
+		Summary: "コイン流通量(テナントの全ユーザーの残高合計)", Tag: "admin",
+		Response: model.CirculationResponse{},
+	},
+	"GET " + adminApiRoot + "/stats/supply": {
+		Summary: "時間区切りごとのコイン発行量(ADD)・消費量(USE)", Tag: "admin",
+		Optional: []string{"interval", "from", "to"},
+		Response: []*model.SupplyStatsResponse(nil),
+	},
+	"GET " + adminApiRoot + "/stats/transfers": {
+		Summary: "時間区切りごとの送金量", Tag: "admin",
+		Optional: []string{"interval", "from", "to"},
+		Response: []*model.TransferStatsResponse(nil),
+	},
+	"GET " + adminApiRoot + "/stats/active-users": {
+		Summary: "時間区切りごとのアクティブユーザー数", Tag: "admin",
+		Optional: []string{"interval", "from", "to"},
+		Response: []*model.ActiveUsersResponse(nil),
+	},
+	"GET " + adminApiRoot + "/stats/top": {
+		Summary: "送金額・受取額の上位ユーザー", Tag: "admin",
+		Query: []string{"direction"}, Optional: []string{"from", "to", "limit"},
+		Response: []*model.TopUserResponse(nil),
+	},
+	"POST " + adminApiRoot + "/api-keys": {
+		Summary: "APIキー発行(APIキー自体は発行時のみ返却)", Tag: "admin",
+		Request: model.ApiKeyAddForm{}, Response: model.ApiKeyCreatedResponse{},
+	},
+	"GET " + adminApiRoot + "/api-keys": {
+		Summary: "APIキー一覧取得", Tag: "admin",
+		Response: []*model.ApiKeyResponse(nil),
+	},
+	"DELETE " + adminApiRoot + "/api-keys/:id": {
+		Summary: "APIキー失効", Tag: "admin",
+		Response: model.ApiKeyResponse{},
+	},
+	"GET " + adminApiRoot + "/snapshots/verify": {
+		Summary: "日次残高スナップショットと履歴の照合", Tag: "admin",
+		Optional: []string{"userid", "from", "to"},
+		Response: model.SnapshotVerificationResponse{},
+	},
+	"GET " + adminApiRoot + "/export": {
+		Summary: "全ユーザーのコイン履歴エクスポート(format=csv/ndjsonまたはAcceptヘッダーで形式指定)", Tag: "admin",
+		Optional: []string{"from", "to", "format"},
+		Formats:  []string{"text/csv", "application/x-ndjson"},
+	},
+
+	// openapi
+	"GET " + openapiPath: {
+		Summary: "OpenAPI定義", Tag: "openapi",
+	},
+}
@@ -0,0 +1,122 @@
+package drivers
+
+import (
+	"coin-api/adapters/gateways/stream"
+	"coin-api/adapters/openapi"
+	"coin-api/database"
+	"coin-api/usecase/model"
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestRouter DBに接続せずInitRouterと同じルートを登録したルーター(ワーカーは起動しない)
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 port=1"}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newRouter(context.Background(), &database.PostgreSQLConnector{Conn: db}, nil, stream.NewHub())
+}
+
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	g := newTestRouter(t)
+
+	// 全ルートにAPI定義があり、全API定義にルートがあること
+	missing, unused := openapi.Unmatched(g.Routes(), apiOperations)
+	for _, key := range missing {
+		t.Errorf("OpenAPI定義なしのルート : %s (drivers/openapi.goのapiOperationsに追加してください)", key)
+	}
+	for _, key := range unused {
+		t.Errorf("ルートが存在しないOpenAPI定義 : %s", key)
+	}
+
+	// 生成したドキュメントに全ルートが含まれること
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, openapiPath, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s status = %d", openapiPath, w.Code)
+	}
+	var doc openapi.Document
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range g.Routes() {
+		path := r.Path
+		for _, s := range strings.Split(r.Path, "/") {
+			if strings.HasPrefix(s, ":") {
+				path = strings.Replace(path, s, "{"+strings.TrimPrefix(s, ":")+"}", 1)
+			}
+		}
+		if doc.Paths[path][strings.ToLower(r.Method)] == nil {
+			t.Errorf("ドキュメントにないルート : %s %s", r.Method, path)
+		}
+	}
+}
+
+func TestApiKeyScopesMatchRoutes(t *testing.T) {
+	g := newTestRouter(t)
+
+	// APIキーのスコープ定義のキーが登録済みのルートであること
+	registered := make(map[string]bool)
+	for _, r := range g.Routes() {
+		registered[r.Method+" "+r.Path] = true
+	}
+	for key := range apiKeyScopes {
+		if !registered[key] {
+			t.Errorf("ルートが存在しないAPIキーのスコープ定義 : %s", key)
+		}
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	g := newTestRouter(t)
+
+	// API定義の型・必須のクエリパラメータに一致しないJSONのリクエストは処理せず400
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   string
+	}{
+		{name: "amount boolean", method: http.MethodPost, path: coinApiRoot + "/send", body: `{"sender": "1", "receiver": "2", "amount": true}`, want: "amountの型が不正です"},
+		{name: "require_acceptance string", method: http.MethodPost, path: coinApiRoot + "/send", body: `{"sender": "1", "receiver": "2", "amount": "1.00", "require_acceptance": "yes"}`, want: "require_accept"},
+		{name: "body array", method: http.MethodPost, path: coinApiRoot + "/send", body: `[]`, want: "リクエストボディの型が不正です"},
+		{name: "body not json", method: http.MethodPost, path: coinApiRoot + "/send", body: `{"amount": 1`, want: "リクエストボディがJSONではありません"},
+		{name: "username null", method: http.MethodPost, path: userApiRoot, body: `{"username": null, "password": "p"}`, want: "usernameにnullは指定できません"},
+		{name: "missing query", method: http.MethodGet, path: userApiRoot, want: "クエリパラメータusernameを指定してください"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		g.ServeHTTP(w, req)
+		var res model.ErrorResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &res)
+		if w.Code != http.StatusBadRequest || !strings.Contains(res.Message, tt.want) {
+			t.Errorf("%s: status = %d, body = %s, want 400 %q", tt.name, w.Code, w.Body.String(), tt.want)
+		}
+	}
+
+	// API定義に一致するリクエストは検証を通過(DBに接続できないためハンドラーで失敗)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, coinApiRoot+"/send", strings.NewReader(`{"sender": 1, "receiver": "2", "amount": 1.5}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	g.ServeHTTP(w, req)
+	if w.Code == http.StatusBadRequest {
+		t.Errorf("valid request status = %d, body = %s", w.Code, w.Body.String())
+	}
+}
@@ -2,37 +2,127 @@ package drivers
 
 import (
 	"coin-api/adapters/controller"
+	"coin-api/adapters/gateways/notifier"
+	"coin-api/adapters/gateways/ratelimit"
 	"coin-api/adapters/gateways/rdb"
+	"coin-api/adapters/gateways/stream"
+	"coin-api/adapters/gateways/webhook"
+	"coin-api/adapters/middleware"
+	"coin-api/adapters/openapi"
+	"coin-api/config"
 	"coin-api/database"
 	"coin-api/usecase/interactor"
+	"coin-api/usecase/port"
 	"coin-api/usecase/presenter"
 	"context"
 	"github.com/gin-gonic/gin"
 )
 
 const (
-	apiVersion  = "/v1"
-	userApiRoot = apiVersion + "/user"
-	coinApiRoot = apiVersion + "/coin"
+	apiVersion      = "/v1"
+	userApiRoot     = apiVersion + "/user"
+	coinApiRoot     = apiVersion + "/coin"
+	scheduleApiRoot = coinApiRoot + "/schedules"
+	webhookApiRoot  = apiVersion + "/webhooks"
+	adminApiRoot    = apiVersion + "/admin"
+	tenantApiRoot   = apiVersion + "/tenant"
 )
 
-func InitRouter() *gin.Engine {
+func InitRouter(con *database.PostgreSQLConnector) *gin.Engine {
+	ctx := context.Background()
+	ws := webhook.NewHTTPSender(config.LoadConfig().WebhookInfo.Timeout)
+	hub := stream.NewHub()
+	g := newRouter(ctx, con, ws, hub)
+
+	// レプリカの遅延測定開始
+	go con.MonitorReplicas(ctx)
+	// 承認待ち送金の期限切れ返金ワーカー起動
+	go runTransferExpiryWorker(ctx, con)
+	// 定期送金スケジュール実行ワーカー起動
+	go runScheduleWorker(ctx, con)
+	// Webhook配信ワーカー起動
+	go runWebhookWorker(ctx, con, ws)
+	// イベント発行リレー起動
+	go runEventRelayWorker(ctx, con)
+	// 集計のロールアップ更新ワーカー起動
+	go runStatsRollupWorker(ctx, con)
+	// 日次残高スナップショット作成ワーカー起動
+	go runSnapshotWorker(ctx, con)
+	// 残高変更通知の受信開始(LISTEN/NOTIFY)
+	go stream.NewPgListener(database.PostgresDSN(), rdb.BalanceChannel, hub).Run(ctx)
+
+	return g
+}
+
+// newRouter ミドルウェアと全ルートを登録したルーター(ワーカーは起動しない)
+func newRouter(ctx context.Context, con *database.PostgreSQLConnector, ws ports.WebhookSender, hub *stream.Hub) *gin.Engine {
 	// Gin
 	g := gin.Default()
-	ctx := context.Background()
 
-	// DB接続
-	con := database.NewPostgreSQLConnector()
+	// DB接続の遮断中は503を即時返却(DBを利用するミドルウェアより先に実行)
+	g.Use(middleware.CircuitBreaker(con.RetryAfter))
+
+	// APIキー認証(レート制限・監査ログで実行者を識別するため先に実行)
+	akr := rdb.NewApiKeyRepository
+	g.Use(middleware.ApiKeyAuth(akr(con.Conn), apiKeyScopes))
+
+	// テナントの解決(APIキーのテナントを使用するためAPIキー認証の後に実行)
+	g.Use(middleware.Tenant(config.LoadConfig().TenantsInfo))
+
+	// レプリカ参照時の自身の更新の反映保証(X-Read-After)
+	g.Use(middleware.ReadYourWrites(config.LoadConfig().ReplicaInfo))
+
+	// レート制限
+	rateLimitInfo := config.LoadConfig().RateLimitInfo
+	rls, err := ratelimit.NewRateLimitStore(rateLimitInfo)
+	if err != nil {
+		panic(err)
+	}
+	g.Use(middleware.RateLimit(rls, rateLimitInfo))
+
+	// 監査ログ
+	ar := rdb.NewAuditRepository
+	g.Use(middleware.Audit(ar(con.Conn)))
+
+	// API定義に一致しないリクエストの拒否(ドキュメントは全ルートの登録後に生成)
+	doc := openapi.Lazy(g, openapiTitle, apiVersion, apiOperations)
+	g.Use(middleware.RequestValidator(doc))
 
 	// User
 	uop := presenter.NewUserOutputPort
 	uip := interactor.NewUserUseCase
 	ur := rdb.NewUserRepository
+	prr := rdb.NewPasswordResetRepository
+	rn := notifier.NewPasswordResetNotifier(config.LoadConfig().PasswordInfo.ResetNotify)
 
 	// Coin
 	cop := presenter.NewCoinOutputPort
 	cip := interactor.NewCoinUseCase
 	cr := rdb.NewCoinRepository
+	tfr := rdb.NewTransferRepository
+
+	// Statement
+	stop := presenter.NewStatementOutputPort
+	stip := interactor.NewStatementUseCase
+
+	// Export
+	eop := presenter.NewExportOutputPort
+	eip := interactor.NewExportUseCase
+	ec := controllers.NewExportController(eop, eip, cr, ur, con)
+
+	// Schedule
+	sop := presenter.NewScheduleOutputPort
+	sip := interactor.NewScheduleUseCase
+	sr := rdb.NewScheduleRepository
+
+	// Webhook
+	wop := presenter.NewWebhookOutputPort
+	wip := interactor.NewWebhookUseCase
+	wr := rdb.NewWebhookRepository
+	obr := rdb.NewOutboxRepository
+
+	// Stream
+	nr := rdb.NewNotificationRepository
 
 	// Transaction
 	tr := rdb.NewTxRepository
@@ -40,24 +130,124 @@ func InitRouter() *gin.Engine {
 	// userAPI
 	ug := g.Group(userApiRoot)
 	{
-		uc := controllers.NewUserController(uop, uip, ur, con)
+		uc := controllers.NewUserController(uop, uip, ur, obr, tr, prr, hub, rn, con)
 		// POST RegisterUserAPI
-		ug.POST("", uc.CreateUser())
+		ug.POST("", uc.CreateUser(ctx))
+		// POST LoginAPI
+		ug.POST("/login", uc.Login(ctx))
+		// POST RequestPasswordResetAPI
+		ug.POST("/password-reset", uc.RequestPasswordReset(ctx))
+		// POST ResetPasswordAPI
+		ug.POST("/password-reset/confirm", uc.ResetPassword(ctx))
+		// GET LookupUserAPI
+		ug.GET("", uc.LookupUser())
 		// GET GetBalanceByUserIdAPI
 		ug.GET("/:userid", uc.GetBalanceById())
+		// PATCH UpdateUserAPI
+		ug.PATCH("/:userid", uc.UpdateUser(ctx))
+		// GET StreamBalanceEventsAPI(SSE)
+		ug.GET("/:userid/events", uc.StreamEvents())
+
+		stc := controllers.NewStatementController(stop, stip, cr, ur, con)
+		// GET GetBalanceAtAPI
+		ug.GET("/:userid/balance", stc.GetBalanceAt())
+		// GET GetStatementAPI(JSON/CSV/PDF)
+		ug.GET("/:userid/statement", stc.GetStatement())
 	}
 
 	// coinAPI
 	cg := g.Group(coinApiRoot)
 	{
-		cc := controllers.NewCoinController(cop, cip, cr, ur, tr, con)
+		cc := controllers.NewCoinController(cop, cip, cr, ur, tfr, obr, nr, tr, con)
 		// PUT AddUseCoinAPI
 		cg.PUT("", cc.AddUseCoin(ctx))
-		// PUT SendCoinAPI
+		// PUT,POST SendCoinAPI
 		cg.PUT("/send", cc.SendCoin(ctx))
+		cg.POST("/send", cc.SendCoin(ctx))
+		// POST AcceptTransferAPI
+		cg.POST("/transfers/:id/accept", cc.AcceptTransfer(ctx))
+		// POST RejectTransferAPI
+		cg.POST("/transfers/:id/reject", cc.RejectTransfer(ctx))
+		// POST ReverseHistoryAPI
+		cg.POST("/history/:id/reverse", cc.ReverseHistory(ctx))
 		// GET GetHistoriesById
 		cg.GET("/:userid", cc.GetHistoryByUserId())
+		// GET VerifyHistoryChainAPI
+		cg.GET("/:userid/verify", cc.VerifyHistoryChain())
+		// GET ExportUserHistoriesAPI(CSV/NDJSON)
+		cg.GET("/:userid/export", ec.ExportUserHistories())
+	}
+
+	// scheduleAPI
+	sg := g.Group(scheduleApiRoot)
+	{
+		sc := controllers.NewScheduleController(sop, sip, sr, ur, obr, tr, con)
+		// POST CreateScheduleAPI
+		sg.POST("", sc.CreateSchedule())
+		// GET GetSchedulesByUserIdAPI
+		sg.GET("", sc.GetSchedulesByUserId())
+		// GET GetScheduleExecutionsAPI
+		sg.GET("/:id/executions", sc.GetExecutionsByScheduleId())
+		// DELETE CancelScheduleAPI
+		sg.DELETE("/:id", sc.CancelSchedule())
+	}
+
+	// webhookAPI
+	wg := g.Group(webhookApiRoot)
+	{
+		wc := controllers.NewWebhookController(wop, wip, wr, obr, tr, ws, con)
+		// POST CreateWebhookAPI
+		wg.POST("", wc.CreateSubscription())
+		// GET GetWebhooksAPI
+		wg.GET("", wc.GetSubscriptions())
+		// DELETE DeleteWebhookAPI
+		wg.DELETE("/:id", wc.DeleteSubscription())
+		// GET GetWebhookDeliveriesAPI
+		wg.GET("/:id/deliveries", wc.GetDeliveries())
 	}
 
+	// tenantAPI
+	tnc := controllers.NewTenantController(presenter.NewTenantOutputPort, interactor.NewTenantUseCase)
+	// GET GetTenantAPI
+	g.GET(tenantApiRoot, tnc.GetTenant())
+
+	// adminAPI
+	ag := g.Group(adminApiRoot)
+	ag.Use(middleware.RequireAdmin())
+	{
+		ac := controllers.NewAuditController(presenter.NewAuditOutputPort, interactor.NewAuditUseCase, ar, con)
+		// GET GetAuditLogsAPI
+		ag.GET("/audit", ac.GetAuditLogs())
+		// GET ExportAllHistoriesAPI(CSV/NDJSON)
+		ag.GET("/export", ec.ExportAllHistories())
+
+		stc := controllers.NewStatsController(presenter.NewStatsOutputPort, interactor.NewStatsUseCase, rdb.NewStatsRepository, con)
+		// GET GetCirculationAPI
+		ag.GET("/stats/circulation", stc.GetCirculation())
+		// GET GetSupplyAPI
+		ag.GET("/stats/supply", stc.GetSupply())
+		// GET GetTransferVolumeAPI
+		ag.GET("/stats/transfers", stc.GetTransferVolume())
+		// GET GetActiveUsersAPI
+		ag.GET("/stats/active-users", stc.GetActiveUsers())
+		// GET GetTopUsersAPI
+		ag.GET("/stats/top", stc.GetTopUsers())
+
+		akc := controllers.NewApiKeyController(presenter.NewApiKeyOutputPort, interactor.NewApiKeyUseCase, akr, ur, con)
+		// POST CreateApiKeyAPI
+		ag.POST("/api-keys", akc.CreateApiKey())
+		// GET GetApiKeysAPI
+		ag.GET("/api-keys", akc.GetApiKeys())
+		// DELETE RevokeApiKeyAPI
+		ag.DELETE("/api-keys/:id", akc.RevokeApiKey())
+
+		snc := controllers.NewSnapshotController(presenter.NewSnapshotOutputPort, interactor.NewSnapshotUseCase, rdb.NewSnapshotRepository, con)
+		// GET VerifySnapshotsAPI
+		ag.GET("/snapshots/verify", snc.VerifySnapshots())
+	}
+
+	// GET OpenAPIDocumentAPI
+	g.GET(openapiPath, openapi.Handler(doc))
+
 	return g
 }
@@ -0,0 +1,178 @@
+package drivers
+
+import (
+	"coin-api/adapters/gateways/publisher"
+	"coin-api/adapters/gateways/rdb"
+	"coin-api/config"
+	"coin-api/database"
+	"coin-api/usecase/interactor"
+	"coin-api/usecase/port"
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+func runTransferExpiryWorker(ctx context.Context, con *database.PostgreSQLConnector) {
+	interval := config.LoadConfig().PendingTransferInfo.CheckInterval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// バックグラウンド処理のためOutputPortは使用しない
+	cip := interactor.NewCoinUseCase(nil, rdb.NewCoinRepository(con.Conn), rdb.NewUserRepository(con.Conn), rdb.NewTransferRepository(con.Conn), rdb.NewOutboxRepository(con.Conn), rdb.NewNotificationRepository(con.Conn), rdb.NewTxRepository(con.Conn))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// 期限切れ送金の返金処理
+			n, err := cip.ExpirePendingTransfers(ctx)
+			if err != nil {
+				log.Error().Stack().Err(err).Send()
+				continue
+			}
+			if n > 0 {
+				log.Log().Msg(fmt.Sprintf("期限切れ送金を返金 件数 : %d", n))
+			}
+		}
+	}
+}
+
+func runScheduleWorker(ctx context.Context, con *database.PostgreSQLConnector) {
+	interval := config.LoadConfig().ScheduleInfo.CheckInterval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// バックグラウンド処理のためOutputPortは使用しない
+	sip := interactor.NewScheduleUseCase(nil, rdb.NewScheduleRepository(con.Conn), rdb.NewUserRepository(con.Conn), rdb.NewOutboxRepository(con.Conn), rdb.NewTxRepository(con.Conn))
+	coinInput := func(op ports.CoinOutputPort) ports.CoinInputPort {
+		return interactor.NewCoinUseCase(op, rdb.NewCoinRepository(con.Conn), rdb.NewUserRepository(con.Conn), rdb.NewTransferRepository(con.Conn), rdb.NewOutboxRepository(con.Conn), rdb.NewNotificationRepository(con.Conn), rdb.NewTxRepository(con.Conn))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// 実行日時を過ぎたスケジュールの送金処理
+			n, err := sip.RunDueSchedules(ctx, coinInput)
+			if err != nil {
+				log.Error().Stack().Err(err).Send()
+				continue
+			}
+			if n > 0 {
+				log.Log().Msg(fmt.Sprintf("定期送金スケジュールを実行 件数 : %d", n))
+			}
+		}
+	}
+}
+
+func runWebhookWorker(ctx context.Context, con *database.PostgreSQLConnector, sender ports.WebhookSender) {
+	interval := config.LoadConfig().WebhookInfo.DispatchInterval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// バックグラウンド処理のためOutputPortは使用しない
+	wip := interactor.NewWebhookUseCase(nil, rdb.NewWebhookRepository(con.Conn), rdb.NewOutboxRepository(con.Conn), rdb.NewTxRepository(con.Conn), sender)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// 未配信イベントから配信を作成
+			if _, err := wip.DispatchEvents(ctx); err != nil {
+				log.Error().Stack().Err(err).Send()
+			}
+			// 送信予定日時を過ぎた配信を送信
+			if _, err := wip.DeliverPending(ctx); err != nil {
+				log.Error().Stack().Err(err).Send()
+			}
+		}
+	}
+}
+
+func runEventRelayWorker(ctx context.Context, con *database.PostgreSQLConnector) {
+	conf := config.LoadConfig().EventRelayInfo
+
+	// 設定に応じた発行先の生成(未設定の場合は起動しない)
+	pub, err := publisher.NewEventPublisher(conf)
+	if err != nil {
+		log.Error().Stack().Err(err).Send()
+		return
+	}
+	if pub == nil {
+		return
+	}
+	defer pub.Close()
+
+	ticker := time.NewTicker(conf.Interval)
+	defer ticker.Stop()
+
+	eip := interactor.NewEventRelayUseCase(rdb.NewOutboxRepository(con.Conn), pub)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// 未発行イベントをメッセージバスへ発行
+			if _, err := eip.RelayEvents(ctx); err != nil {
+				log.Error().Stack().Err(err).Send()
+			}
+		}
+	}
+}
+
+func runStatsRollupWorker(ctx context.Context, con *database.PostgreSQLConnector) {
+	interval := config.LoadConfig().StatsInfo.RollupInterval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// バックグラウンド処理のためOutputPortは使用しない
+	sip := interactor.NewStatsUseCase(nil, rdb.NewStatsRepository(con.Conn))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// 未集計の履歴をロールアップへ加算
+			n, err := sip.RefreshRollups(ctx)
+			if err != nil {
+				log.Error().Stack().Err(err).Send()
+				continue
+			}
+			if n > 0 {
+				log.Log().Msg(fmt.Sprintf("集計のロールアップを更新 件数 : %d", n))
+			}
+		}
+	}
+}
+
+func runSnapshotWorker(ctx context.Context, con *database.PostgreSQLConnector) {
+	interval := config.LoadConfig().SnapshotInfo.CheckInterval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// バックグラウンド処理のためOutputPortは使用しない
+	sip := interactor.NewSnapshotUseCase(nil, rdb.NewSnapshotRepository(con.Conn))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// 確定した日付の残高スナップショット作成
+			n, err := sip.TakeSnapshots(ctx)
+			if err != nil {
+				log.Error().Stack().Err(err).Send()
+				continue
+			}
+			if n > 0 {
+				log.Log().Msg(fmt.Sprintf("残高スナップショットを作成 件数 : %d", n))
+			}
+		}
+	}
+}
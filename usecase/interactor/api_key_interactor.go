@@ -0,0 +1,142 @@
+package interactor
+
+import (
+	"coin-api/common"
+	models "coin-api/domain/model"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// apiKeyPrefix APIキーの先頭の固定文字列(ck_<プレフィックス>_<秘密部分>の形式)
+const apiKeyPrefix = "ck_"
+
+type ApiKeyUseCase struct {
+	op         ports.ApiKeyOutputPort
+	apiKeyRepo repository.IApiKeyRepository
+	userRepo   repository.IUserRepository
+}
+
+func NewApiKeyUseCase(aop ports.ApiKeyOutputPort, ar repository.IApiKeyRepository, ur repository.IUserRepository) ports.ApiKeyInputPort {
+	return &ApiKeyUseCase{
+		op:         aop,
+		apiKeyRepo: ar,
+		userRepo:   ur,
+	}
+}
+
+func (a *ApiKeyUseCase) CreateApiKey(form *model.ApiKeyAddForm) error {
+	// formのバリデーション
+	if err := form.ValidateApiKeyAddForm(); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー ApiKeyAddForm : %s", common.CreateJsonString(&form)))
+		return a.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// 発行対象のユーザーの存在確認(指定した場合のみ)
+	var uid *uint
+	if form.UserId != "" {
+		u, err := a.userRepo.SelectById(common.StringToUint(string(form.UserId)))
+		if err != nil {
+			log.Error().Stack().Err(err)
+			return a.op.OutputError(model.CreateServerErrorResponse(err), err)
+		}
+		uid = &u.ID
+	}
+
+	// APIキー生成(DBにはハッシュのみ保存)
+	key, prefix, err := newApiKey()
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return a.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	// Insert対象データ作成
+	target := models.ApiKey{
+		Name:    form.Name,
+		Prefix:  prefix,
+		KeyHash: models.ApiKeyHash(key),
+		Scopes:  strings.Join(form.Scopes, ","),
+		UserId:  uid,
+	}
+
+	// APIキー登録処理実行
+	apiKey, err := a.apiKeyRepo.Insert(&target)
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return a.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	// APIキー自体は発行時のみ返却
+	return a.op.OutputApiKeyCreated(&model.ApiKeyCreatedResponse{
+		ApiKeyResponse: *model.ApiKeyResponseFromDomainModel(apiKey),
+		Key:            key,
+	})
+}
+
+func (a *ApiKeyUseCase) SelectApiKeys() error {
+	// 全APIキー取得
+	keys, err := a.apiKeyRepo.SelectAll()
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return a.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	// response用に詰め替え
+	response := make([]*model.ApiKeyResponse, 0)
+	for i := range keys {
+		response = append(response, model.ApiKeyResponseFromDomainModel(&keys[i]))
+	}
+
+	return a.op.OutputApiKeys(response)
+}
+
+func (a *ApiKeyUseCase) RevokeApiKey(id string) error {
+	// idのバリデーション
+	if err := validation.Validate(id, validation.Required, is.Digit); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー APIキーID : %s", id))
+		return a.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// APIキー取得
+	apiKey, err := a.apiKeyRepo.SelectById(common.StringToUint(id))
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return a.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	// 利用履歴を残すため失効のみ行う(失効済みの場合はそのまま返却)
+	if apiKey.RevokedAt == nil {
+		now := time.Now()
+		if _, err := a.apiKeyRepo.Revoke(apiKey, now); err != nil {
+			log.Error().Stack().Err(err)
+			return a.op.OutputError(model.CreateServerErrorResponse(err), err)
+		}
+		apiKey.RevokedAt = &now
+	}
+
+	return a.op.OutputApiKey(model.ApiKeyResponseFromDomainModel(apiKey))
+}
+
+// newApiKey ランダムなAPIキーと識別用のプレフィックスを生成
+func newApiKey() (string, string, error) {
+	p := make([]byte, 4)
+	if _, err := rand.Read(p); err != nil {
+		return "", "", err
+	}
+	s := make([]byte, 32)
+	if _, err := rand.Read(s); err != nil {
+		return "", "", err
+	}
+	prefix := apiKeyPrefix + hex.EncodeToString(p)
+	return prefix + "_" + base64.RawURLEncoding.EncodeToString(s), prefix, nil
+}
@@ -0,0 +1,77 @@
+package interactor
+
+import (
+	"coin-api/common"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"time"
+)
+
+const (
+	// defaultAuditLimit 監査ログの取得件数(未指定の場合)
+	defaultAuditLimit = 100
+	// maxAuditLimit 監査ログの1回の取得件数上限
+	maxAuditLimit = 1000
+)
+
+type AuditUseCase struct {
+	op        ports.AuditOutputPort
+	auditRepo repository.IAuditRepository
+}
+
+func NewAuditUseCase(aop ports.AuditOutputPort, ar repository.IAuditRepository) ports.AuditInputPort {
+	return &AuditUseCase{
+		op:        aop,
+		auditRepo: ar,
+	}
+}
+
+func (a *AuditUseCase) SelectAuditLogs(form *model.AuditSearchForm) error {
+	// formのバリデーション
+	if err := form.ValidateAuditSearchForm(); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー AuditSearchForm : %s", common.CreateJsonString(&form)))
+		return a.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// 検索条件作成
+	filter := &repository.AuditFilter{
+		Actor:     form.Actor,
+		Endpoint:  form.Endpoint,
+		Result:    form.Result,
+		RequestId: form.RequestId,
+		Limit:     defaultAuditLimit,
+	}
+	if form.From != "" {
+		from, _ := time.Parse(time.RFC3339, form.From)
+		filter.From = &from
+	}
+	if form.To != "" {
+		to, _ := time.Parse(time.RFC3339, form.To)
+		filter.To = &to
+	}
+	if form.Limit != "" {
+		filter.Limit = int(common.StringToUint(form.Limit))
+		if filter.Limit <= 0 || filter.Limit > maxAuditLimit {
+			filter.Limit = maxAuditLimit
+		}
+	}
+
+	// 監査ログ取得
+	entries, err := a.auditRepo.Select(filter)
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return a.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	// response用に詰め替え
+	response := make([]*model.AuditLogResponse, 0)
+	for i := range entries {
+		response = append(response, model.AuditLogResponseFromDomainModel(&entries[i]))
+	}
+
+	return a.op.OutputAuditLogs(response)
+}
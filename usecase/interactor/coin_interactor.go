@@ -2,94 +2,128 @@ package interactor
 
 import (
 	"coin-api/common"
+	"coin-api/common/apikey"
 	"coin-api/common/enum"
+	"coin-api/config"
+	"coin-api/domain/event"
 	models "coin-api/domain/model"
 	"coin-api/domain/repository"
 	"coin-api/usecase/model"
 	"coin-api/usecase/port"
+	"coin-api/usecase/rule"
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/go-ozzo/ozzo-validation/is"
 	"github.com/rs/zerolog/log"
 	"net/http"
-	"strconv"
 	"time"
 )
 
+// errInsufficientBalance トランザクション内でロックした残高が不足している場合のエラー
+var errInsufficientBalance = errors.New("コイン残高不足エラー")
+
+// errApiKeyUser ユーザーに発行したAPIキーで他のユーザーを操作した場合のエラー
+var errApiKeyUser = errors.New("APIキーの発行対象のユーザー以外は操作できません")
+
+// transferTxOptions 送金・保留・承認・拒否・期限切れのトランザクションの設定
+// (残高とルール評価の集計を他の送金と直列化するため直列化可能で実行し、直列化失敗の場合はDoInTxで再試行する)
+var transferTxOptions = []repository.TxOption{repository.WithIsolation(sql.LevelSerializable)}
+
 type CoinUseCase struct {
-	op       ports.CoinOutputPort
-	coinRepo repository.ICoinRepository
-	userRepo repository.IUserRepository
-	tranRepo repository.ITxRepository
+	op             ports.CoinOutputPort
+	coinRepo       repository.ICoinRepository
+	userRepo       repository.IUserRepository
+	transferRepo   repository.ITransferRepository
+	outboxRepo     repository.IOutboxRepository
+	notifyRepo     repository.INotificationRepository
+	tranRepo       repository.ITxRepository
+	rules          *rule.TransferRuleEngine
+	pendingTimeout time.Duration
 }
 
-func NewCoinUseCase(uop ports.CoinOutputPort, cr repository.ICoinRepository, ur repository.IUserRepository, tr repository.ITxRepository) ports.CoinInputPort {
+func NewCoinUseCase(uop ports.CoinOutputPort, cr repository.ICoinRepository, ur repository.IUserRepository, tfr repository.ITransferRepository, obr repository.IOutboxRepository, nr repository.INotificationRepository, tr repository.ITxRepository) ports.CoinInputPort {
+	conf := config.LoadConfig()
 	return &CoinUseCase{
-		op:       uop,
-		coinRepo: cr,
-		userRepo: ur,
-		tranRepo: tr,
+		op:             uop,
+		coinRepo:       cr,
+		userRepo:       ur,
+		transferRepo:   tfr,
+		outboxRepo:     obr,
+		notifyRepo:     nr,
+		tranRepo:       tr,
+		rules:          rule.NewTransferRuleEngine(conf, cr),
+		pendingTimeout: conf.PendingTransferInfo.Timeout,
 	}
 }
 
 func (c *CoinUseCase) AddUseCoin(ctx context.Context, form *model.CoinAddUseForm) error {
 	// formのバリデーション
-	if err := form.ValidateCoinAddUseForm(); err != nil {
+	if err := form.ValidateCoinAddUseForm(coinOf(ctx)); err != nil {
 		log.Log().Msg(fmt.Sprintf("バリデーションエラー CoinAddUseForm : %s", common.CreateJsonString(&form)))
 		log.Error().Stack().Err(err)
 
 		return c.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
 	}
 
-	// coin残高処理対象ユーザーの取得
-	uidUint := common.StringToUint(form.UserId)
-	user, err := c.userRepo.SelectById(uidUint)
-	if err != nil {
-		log.Error().Stack().Err(err)
-		return c.op.OutputError(model.CreateErrorResponse(http.StatusInternalServerError, err.Error()), err)
-	}
-
-	// 残高不足確認
-	amountInt, _ := strconv.Atoi(form.Amount)
+	// APIキーの場合は区分に応じたスコープを確認
+	scope := enum.SCOPE_COIN_ADD
 	if form.Operation == string(enum.USE) {
-		if *user.CoinBalance < amountInt {
-			// 消費量が残高を上回る場合はエラー
-			log.Log().Msg(fmt.Sprintf("コイン残高不足エラー user : %s", common.CreateJsonString(&user)))
-			log.Error().Stack().Err(err)
-
-			return c.op.OutputError(model.CreateErrorResponse(http.StatusInternalServerError, "コイン残高不足エラー"), err)
-		}
-		// 区分がUSEの場合は符号を-に変換
-		amountInt = -amountInt
+		scope = enum.SCOPE_COIN_USE
+	}
+	if !apikey.Allowed(ctx, scope) {
+		err := fmt.Errorf("APIキーにスコープ%sがありません", scope)
+		return c.op.OutputError(model.CreateErrorResponse(http.StatusForbidden, err.Error()), err)
+	}
+	if !apikey.ActsFor(ctx, common.StringToUint(string(form.UserId))) {
+		return c.op.OutputError(model.CreateErrorResponse(http.StatusForbidden, errApiKeyUser.Error()), errApiKeyUser)
 	}
 
-	// 残高の算出
-	balance := *user.CoinBalance + amountInt
-	user.CoinBalance = &balance
-
-	// 履歴オブジェクト生成
-	operationTime := time.Now()
+	// 履歴オブジェクト生成(区分がUSEの場合は符号を-に変換)
+	amount, _ := coinOf(ctx).ParseAmount(string(form.Amount))
 	target := &models.CoinHistory{
 		Operation:          form.Operation,
-		OperationTimestamp: operationTime,
-		UserId:             uidUint,
-		Amount:             amountInt,
+		OperationTimestamp: time.Now(),
+		UserId:             common.StringToUint(string(form.UserId)),
+		Amount:             amount.Int(),
+	}
+	if form.Operation == string(enum.USE) {
+		target.Amount = -amount.Int()
 	}
 
-	// 同一transaction内で残高の更新と履歴の追加を実行
-	if _, err := c.tranRepo.DoInTx(ctx, c.AddUseCoinAndUpdateBalance(user, target)); err != nil {
+	// 同一transaction内で残高の確認・更新と履歴の追加を実行
+	v, err := c.tranRepo.DoInTx(ctx, c.AddUseCoinAndUpdateBalance(target, amount))
+	if err != nil {
 		log.Error().Stack().Err(err)
-		return c.op.OutputError(model.CreateErrorResponse(http.StatusInternalServerError, err.Error()), err)
+		return c.op.OutputError(coinTxErrorResponse(err), err)
 	}
 
-	return c.op.OutputCoin(model.CoinResponseFromDomainModel(target, balance))
+	return c.op.OutputCoin(model.CoinResponseFromDomainModel(target, *v.(*models.User).CoinBalance, coinOf(ctx)))
 }
 
-func (c *CoinUseCase) AddUseCoinAndUpdateBalance(user *models.User, history *models.CoinHistory) func(ctx context.Context) (interface{}, error) {
+func (c *CoinUseCase) AddUseCoinAndUpdateBalance(history *models.CoinHistory, amount models.Amount) func(ctx context.Context) (interface{}, error) {
 	return func(ctx context.Context) (interface{}, error) {
+		// 対象ユーザーをロックして最新の残高を取得
+		users, err := c.userRepo.SelectForUpdate(ctx, history.UserId)
+		if err != nil {
+			return nil, err
+		}
+		user := users[history.UserId]
+
+		// 残高の算出(消費の場合は残高不足、追加の場合は桁あふれを確認)
+		if history.Operation == string(enum.USE) {
+			err = debit(user, amount.Int())
+		} else {
+			err = credit(user, amount)
+		}
+		if err != nil {
+			return nil, err
+		}
+
 		// 残高更新
-		if _, err := c.userRepo.Update(ctx, user); err != nil {
+		if _, err := c.userRepo.UpdateBalances(ctx, user); err != nil {
 			log.Error().Err(err).Send()
 			return nil, err
 		}
@@ -99,108 +133,726 @@ func (c *CoinUseCase) AddUseCoinAndUpdateBalance(user *models.User, history *mod
 			log.Error().Err(err).Send()
 			return nil, err
 		}
-		return nil, nil
+
+		// イベント追加
+		var e event.DomainEvent = &event.CoinAdded{UserId: user.ID, Amount: history.Amount, Balance: *user.CoinBalance, OccurredAt: history.OperationTimestamp}
+		if history.Operation == string(enum.USE) {
+			e = &event.CoinUsed{UserId: user.ID, Amount: -history.Amount, Balance: *user.CoinBalance, OccurredAt: history.OperationTimestamp}
+		}
+		if _, err := c.outboxRepo.BatchInsert(ctx, []*models.OutboxEvent{newOutboxEvent(e)}); err != nil {
+			log.Error().Err(err).Send()
+			return nil, err
+		}
+
+		// 残高変更通知(commit時に配信)
+		if err := c.notifyBalanceChanged(ctx, user, history); err != nil {
+			return nil, err
+		}
+		return user, nil
 	}
 }
 
 func (c *CoinUseCase) SendCoin(ctx context.Context, form *model.CoinSendForm) error {
 	// formのバリデーション
-	if err := form.ValidateCoinSendForm(); err != nil {
+	if err := form.ValidateCoinSendForm(coinOf(ctx)); err != nil {
 		log.Log().Msg(fmt.Sprintf("バリデーションエラー CoinSendForm : %s", common.CreateJsonString(&form)))
 		log.Error().Stack().Err(err)
 
 		return c.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
 	}
 
-	// Senderの残高取得
-	senderUidUint := common.StringToUint(form.Sender)
+	// APIキーの場合は発行対象のユーザーからの送金のみ許可
+	senderUidUint := common.StringToUint(string(form.Sender))
+	if !apikey.ActsFor(ctx, senderUidUint) {
+		return c.op.OutputError(model.CreateErrorResponse(http.StatusForbidden, errApiKeyUser.Error()), errApiKeyUser)
+	}
+
+	// Senderの取得
 	sender, err := c.userRepo.SelectById(senderUidUint)
 	if err != nil {
 		log.Log().Msg(fmt.Sprintf("Senderユーザー取得に失敗 user : %s", common.CreateJsonString(&sender)))
 		log.Error().Stack().Err(err)
 
-		return c.op.OutputError(model.CreateErrorResponse(http.StatusInternalServerError, err.Error()), err)
+		return c.op.OutputError(model.CreateServerErrorResponse(err), err)
 	}
 
-	// Receiverの残高取得
-	receiverUidUint := common.StringToUint(form.Receiver)
-	receiver, err := c.userRepo.SelectById(receiverUidUint)
+	// Receiverの取得(ユーザー名指定の場合はユーザー名で検索)
+	receiver, err := c.selectReceiver(form)
 	if err != nil {
-		log.Log().Msg(fmt.Sprintf("Receiverユーザー取得に失敗 user : %s", common.CreateJsonString(&receiver)))
+		log.Log().Msg(fmt.Sprintf("Receiverユーザー取得に失敗 form : %s", common.CreateJsonString(&form)))
 
 		log.Error().Stack().Err(err)
-		return c.op.OutputError(model.CreateErrorResponse(http.StatusInternalServerError, err.Error()), err)
+		return c.op.OutputError(model.CreateServerErrorResponse(err), err)
 	}
+	if receiver == nil {
+		err := fmt.Errorf("受取人のユーザーが存在しません ユーザー名 : %s", form.ReceiverUsername)
+		return c.op.OutputError(model.CreateErrorResponse(http.StatusNotFound, err.Error()), err)
+	}
+	if receiver.ID == sender.ID {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー CoinSendForm : %s", common.CreateJsonString(&form)))
+		return c.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, model.ErrSelfSend.Error()), model.ErrSelfSend)
+	}
+	receiverUidUint := receiver.ID
 
-	// Sender残高の確認
-	amountInt, _ := strconv.Atoi(form.Amount)
-	if *sender.CoinBalance < amountInt {
-		// 消費量が残高を上回る場合はエラー
-		log.Log().Msg(fmt.Sprintf("コイン残高不足エラー user : %s", common.CreateJsonString(&sender)))
-		return c.op.OutputError(model.CreateErrorResponse(http.StatusInternalServerError, "コイン残高不足エラー"), err)
+	// 承認要の場合はエスクローに保留
+	amount, _ := coinOf(ctx).ParseAmount(string(form.Amount))
+	amountInt := amount.Int()
+	if form.RequireAcceptance {
+		return c.holdCoin(ctx, senderUidUint, receiverUidUint, amount)
 	}
 
-	// Sender残高の設定
-	senderBalance := *sender.CoinBalance + (-amountInt)
-	sender.CoinBalance = &senderBalance
+	// 同一transaction内で残高・送金ルールの確認、残高の更新と履歴の追加を実行
+	v, err := c.tranRepo.DoInTx(ctx, c.SendCoinAndUpdateBalances(senderUidUint, receiverUidUint, amount, form.ScheduleId, time.Now()), transferTxOptions...)
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return c.op.OutputError(coinTxErrorResponse(err), err)
+	}
 
-	// Receiver残高の設定
-	receiverBalance := *receiver.CoinBalance + amountInt
-	receiver.CoinBalance = &receiverBalance
+	return c.op.OutputCoinSend(model.CoinSendResponseFromDomainModel(senderUidUint, receiverUidUint, amountInt, *v.(*models.User).CoinBalance, coinOf(ctx)))
+}
 
-	// Sender履歴作成
-	operationTime := time.Now()
-	senderInsertion := models.CoinHistory{
-		Operation:          string(enum.SEND),
-		OperationTimestamp: operationTime,
-		UserId:             senderUidUint,
-		Amount:             -amountInt,
-	}
-	// Receiver履歴作成
-	receiverInsertion := models.CoinHistory{
-		Operation:          string(enum.RECEIVE),
-		OperationTimestamp: operationTime,
-		UserId:             receiverUidUint,
-		Amount:             amountInt,
+func (c *CoinUseCase) SendCoinAndUpdateBalances(senderId uint, receiverId uint, amount models.Amount, scheduleId *uint, now time.Time) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		// Sender、Receiverをロックして最新の残高を取得
+		users, err := c.userRepo.SelectForUpdate(ctx, senderId, receiverId)
+		if err != nil {
+			return nil, err
+		}
+		sender, receiver := users[senderId], users[receiverId]
+
+		// Sender残高の減算(残高不足を確認)
+		if err := debit(sender, amount.Int()); err != nil {
+			return nil, err
+		}
+
+		// 送金ルールの評価(Senderのロック中に集計するため並行する送金も上限に含まれる)
+		if err := c.rules.Evaluate(ctx, &rule.TransferRequest{Sender: sender, Receiver: receiver, Amount: amount.Int(), Now: now}); err != nil {
+			return nil, err
+		}
+
+		// Receiver残高の加算(桁あふれを確認)
+		if err := credit(receiver, amount); err != nil {
+			return nil, err
+		}
+
+		// Sender残高更新
+		if _, err := c.userRepo.UpdateBalances(ctx, sender); err != nil {
+			log.Error().Err(err).Send()
+			return nil, err
+		}
+
+		// Receiver残高更新
+		if _, err := c.userRepo.UpdateBalances(ctx, receiver); err != nil {
+			log.Error().Err(err).Send()
+			return nil, err
+		}
+
+		// Sender、Receiver履歴作成
+		histories := []*models.CoinHistory{
+			{
+				Operation:          string(enum.SEND),
+				OperationTimestamp: now,
+				UserId:             senderId,
+				Amount:             -amount.Int(),
+				Counterparty:       &receiverId,
+				ScheduleId:         scheduleId,
+			},
+			{
+				Operation:          string(enum.RECEIVE),
+				OperationTimestamp: now,
+				UserId:             receiverId,
+				Amount:             amount.Int(),
+				Counterparty:       &senderId,
+				ScheduleId:         scheduleId,
+			},
+		}
+
+		// 履歴一括追加
+		if _, err := c.coinRepo.BatchInsert(ctx, histories); err != nil {
+			log.Error().Err(err).Send()
+			return nil, err
+		}
+
+		// 送金イベント追加
+		e := &event.CoinTransferred{
+			Sender:          sender.ID,
+			Receiver:        receiver.ID,
+			Amount:          amount.Int(),
+			SenderBalance:   *sender.CoinBalance,
+			ReceiverBalance: *receiver.CoinBalance,
+			OccurredAt:      now,
+		}
+		if _, err := c.outboxRepo.BatchInsert(ctx, []*models.OutboxEvent{newOutboxEvent(e)}); err != nil {
+			log.Error().Err(err).Send()
+			return nil, err
+		}
+
+		// 残高変更通知(commit時に配信)
+		if err := c.notifyBalanceChanged(ctx, sender, histories[0]); err != nil {
+			return nil, err
+		}
+		if err := c.notifyBalanceChanged(ctx, receiver, histories[1]); err != nil {
+			return nil, err
+		}
+		return sender, nil
 	}
+}
 
-	// 履歴まとめ
-	histories := make([]*models.CoinHistory, 0)
-	histories = append(histories, &senderInsertion, &receiverInsertion)
+// selectReceiver 受取人の取得(ユーザー名指定で存在しない場合はnil)
+func (c *CoinUseCase) selectReceiver(form *model.CoinSendForm) (*models.User, error) {
+	if form.ReceiverUsername != "" {
+		return c.userRepo.SelectByUsername(form.ReceiverUsername)
+	}
+	return c.userRepo.SelectById(common.StringToUint(string(form.Receiver)))
+}
 
-	// 同一transaction内で残高の更新と履歴の追加を実行
-	if _, err := c.tranRepo.DoInTx(ctx, c.SendCoinAndUpdateBalances(sender, receiver, histories)); err != nil {
+func (c *CoinUseCase) holdCoin(ctx context.Context, senderId uint, receiverId uint, amount models.Amount) error {
+	// 同一transaction内で残高・送金ルールの確認、残高の更新、履歴の追加、送金の作成を実行
+	v, err := c.tranRepo.DoInTx(ctx, c.HoldCoinAndCreateTransfer(senderId, receiverId, amount, time.Now()), transferTxOptions...)
+	if err != nil {
 		log.Error().Stack().Err(err)
-		return c.op.OutputError(model.CreateErrorResponse(http.StatusInternalServerError, err.Error()), err)
+		return c.op.OutputError(coinTxErrorResponse(err), err)
 	}
 
-	return c.op.OutputCoinSend(model.CoinSendResponseFromDomainModel(senderUidUint, receiverUidUint, amountInt, senderBalance))
+	return c.op.OutputCoinTransfer(model.CoinTransferResponseFromDomainModel(v.(*models.Transfer), coinOf(ctx)))
 }
 
-func (c *CoinUseCase) SendCoinAndUpdateBalances(sender *models.User, receiver *models.User, histories []*models.CoinHistory) func(ctx context.Context) (interface{}, error) {
+func (c *CoinUseCase) HoldCoinAndCreateTransfer(senderId uint, receiverId uint, amount models.Amount, now time.Time) func(ctx context.Context) (interface{}, error) {
 	return func(ctx context.Context) (interface{}, error) {
+		// Sender、Receiverをロックして最新の残高を取得
+		users, err := c.userRepo.SelectForUpdate(ctx, senderId, receiverId)
+		if err != nil {
+			return nil, err
+		}
+		sender, receiver := users[senderId], users[receiverId]
+
+		// Sender残高から保留残高へ移動(残高不足を確認)
+		if err := debit(sender, amount.Int()); err != nil {
+			return nil, err
+		}
+
+		// 送金ルールの評価(Senderのロック中に集計するため並行する送金も上限に含まれる)
+		if err := c.rules.Evaluate(ctx, &rule.TransferRequest{Sender: sender, Receiver: receiver, Amount: amount.Int(), Now: now}); err != nil {
+			return nil, err
+		}
+		senderHeld := *sender.HeldBalance + amount.Int()
+		sender.HeldBalance = &senderHeld
+
 		// Sender残高更新
-		if _, err := c.userRepo.Update(ctx, sender); err != nil {
+		if _, err := c.userRepo.UpdateBalances(ctx, sender); err != nil {
 			log.Error().Err(err).Send()
 			return nil, err
 		}
 
-		// Receiver残高更新
-		if _, err := c.userRepo.Update(ctx, receiver); err != nil {
+		// 保留履歴追加
+		history := &models.CoinHistory{
+			Operation:          string(enum.HOLD),
+			OperationTimestamp: now,
+			UserId:             senderId,
+			Amount:             -amount.Int(),
+			Counterparty:       &receiverId,
+		}
+		if _, err := c.coinRepo.Insert(ctx, history); err != nil {
 			log.Error().Err(err).Send()
 			return nil, err
 		}
 
-		// 履歴一括追加
-		if _, err := c.coinRepo.BatchInsert(ctx, histories); err != nil {
+		// 承認待ち送金登録
+		transfer := &models.Transfer{
+			Sender:    senderId,
+			Receiver:  receiverId,
+			Amount:    amount.Int(),
+			Status:    string(enum.PENDING),
+			ExpiresAt: now.Add(c.pendingTimeout),
+		}
+		if _, err := c.transferRepo.Insert(ctx, transfer); err != nil {
 			log.Error().Err(err).Send()
 			return nil, err
 		}
-		return nil, nil
+
+		// 保留イベント追加
+		e := &event.CoinHeld{
+			TransferId:    transfer.ID,
+			Sender:        senderId,
+			Receiver:      receiverId,
+			Amount:        amount.Int(),
+			SenderBalance: *sender.CoinBalance,
+			SenderHeld:    *sender.HeldBalance,
+			ExpiresAt:     transfer.ExpiresAt,
+			OccurredAt:    now,
+		}
+		if _, err := c.outboxRepo.BatchInsert(ctx, []*models.OutboxEvent{newOutboxEvent(e)}); err != nil {
+			log.Error().Err(err).Send()
+			return nil, err
+		}
+
+		// 残高変更通知(commit時に配信)
+		if err := c.notifyBalanceChanged(ctx, sender, history); err != nil {
+			return nil, err
+		}
+		return transfer, nil
 	}
 }
 
-func (c *CoinUseCase) SelectHistoriesByUserId(uid string) error {
+func (c *CoinUseCase) AcceptTransfer(ctx context.Context, id string, form *model.TransferResolveForm) error {
+	// 対象送金の取得と受取人の確認
+	transfer, res, err := c.findPendingTransfer(id, form)
+	if err != nil {
+		return c.op.OutputError(res, err)
+	}
+
+	// 同一transaction内でステータス更新、保留解除、受取、履歴追加を実行
+	if _, err := c.tranRepo.DoInTx(ctx, c.ReleaseTransferAndUpdateBalances(transfer, time.Now()), transferTxOptions...); err != nil {
+		log.Error().Stack().Err(err)
+		return c.op.OutputError(coinTxErrorResponse(err), err)
+	}
+
+	return c.op.OutputCoinTransfer(model.CoinTransferResponseFromDomainModel(transfer, coinOf(ctx)))
+}
+
+func (c *CoinUseCase) RejectTransfer(ctx context.Context, id string, form *model.TransferResolveForm) error {
+	// 対象送金の取得と受取人の確認
+	transfer, res, err := c.findPendingTransfer(id, form)
+	if err != nil {
+		return c.op.OutputError(res, err)
+	}
+
+	// 同一transaction内でステータス更新、返金、履歴追加を実行
+	if _, err := c.tranRepo.DoInTx(ctx, c.RefundTransferAndUpdateBalance(transfer, enum.REJECTED, time.Now()), transferTxOptions...); err != nil {
+		log.Error().Stack().Err(err)
+		return c.op.OutputError(coinTxErrorResponse(err), err)
+	}
+
+	return c.op.OutputCoinTransfer(model.CoinTransferResponseFromDomainModel(transfer, coinOf(ctx)))
+}
+
+func (c *CoinUseCase) ExpirePendingTransfers(ctx context.Context) (int, error) {
+	// 期限切れの承認待ち送金を取得
+	now := time.Now()
+	transfers, err := c.transferRepo.SelectExpiredPending(now)
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return 0, err
+	}
+
+	// 1件ずつ返金処理
+	refunded := 0
+	for i := range transfers {
+		transfer := &transfers[i]
+		if _, err := c.tranRepo.DoInTx(ctx, c.RefundTransferAndUpdateBalance(transfer, enum.EXPIRED, now), transferTxOptions...); err != nil {
+			// 承認、拒否と競合した場合はスキップ
+			log.Error().Stack().Err(err).Send()
+			continue
+		}
+		refunded++
+	}
+
+	return refunded, nil
+}
+
+func (c *CoinUseCase) ReleaseTransferAndUpdateBalances(transfer *models.Transfer, now time.Time) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		// ステータス更新(承認待ちの場合のみ)
+		if _, err := c.transferRepo.UpdateStatus(ctx, transfer, string(enum.PENDING), string(enum.ACCEPTED), now); err != nil {
+			return nil, err
+		}
+		transfer.Status = string(enum.ACCEPTED)
+		transfer.ResolvedAt = &now
+
+		// Sender、Receiverをロックして最新の残高を取得
+		users, err := c.userRepo.SelectForUpdate(ctx, transfer.Sender, transfer.Receiver)
+		if err != nil {
+			return nil, err
+		}
+		sender, receiver := users[transfer.Sender], users[transfer.Receiver]
+
+		// Sender保留残高の解除
+		senderHeld := *sender.HeldBalance - transfer.Amount
+		sender.HeldBalance = &senderHeld
+		if _, err := c.userRepo.UpdateBalances(ctx, sender); err != nil {
+			log.Error().Err(err).Send()
+			return nil, err
+		}
+
+		// Receiver残高の加算(桁あふれを確認)
+		if err := credit(receiver, models.Amount(transfer.Amount)); err != nil {
+			return nil, err
+		}
+		if _, err := c.userRepo.UpdateBalances(ctx, receiver); err != nil {
+			log.Error().Err(err).Send()
+			return nil, err
+		}
+
+		// Receiver受取履歴追加
+		history := &models.CoinHistory{
+			Operation:          string(enum.RELEASE),
+			OperationTimestamp: now,
+			UserId:             receiver.ID,
+			Amount:             transfer.Amount,
+			Counterparty:       &sender.ID,
+		}
+		if _, err := c.coinRepo.Insert(ctx, history); err != nil {
+			log.Error().Err(err).Send()
+			return nil, err
+		}
+
+		// 承認により送金が確定したため送金イベント追加
+		e := &event.CoinTransferred{
+			TransferId:      &transfer.ID,
+			Sender:          sender.ID,
+			Receiver:        receiver.ID,
+			Amount:          transfer.Amount,
+			SenderBalance:   *sender.CoinBalance,
+			ReceiverBalance: *receiver.CoinBalance,
+			OccurredAt:      now,
+		}
+		if _, err := c.outboxRepo.BatchInsert(ctx, []*models.OutboxEvent{newOutboxEvent(e)}); err != nil {
+			log.Error().Err(err).Send()
+			return nil, err
+		}
+
+		// 残高変更通知(commit時に配信)
+		if err := c.notifyBalanceChanged(ctx, sender, nil); err != nil {
+			return nil, err
+		}
+		if err := c.notifyBalanceChanged(ctx, receiver, history); err != nil {
+			return nil, err
+		}
+		return transfer, nil
+	}
+}
+
+func (c *CoinUseCase) RefundTransferAndUpdateBalance(transfer *models.Transfer, status enum.TransferStatus, now time.Time) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		// ステータス更新(承認待ちの場合のみ)
+		if _, err := c.transferRepo.UpdateStatus(ctx, transfer, string(enum.PENDING), string(status), now); err != nil {
+			return nil, err
+		}
+		transfer.Status = string(status)
+		transfer.ResolvedAt = &now
+
+		// Senderをロックして最新の残高を取得
+		users, err := c.userRepo.SelectForUpdate(ctx, transfer.Sender)
+		if err != nil {
+			return nil, err
+		}
+		sender := users[transfer.Sender]
+
+		// Sender保留残高を残高へ戻す(桁あふれを確認)
+		if err := credit(sender, models.Amount(transfer.Amount)); err != nil {
+			return nil, err
+		}
+		senderHeld := *sender.HeldBalance - transfer.Amount
+		sender.HeldBalance = &senderHeld
+		if _, err := c.userRepo.UpdateBalances(ctx, sender); err != nil {
+			log.Error().Err(err).Send()
+			return nil, err
+		}
+
+		// Sender返金履歴追加
+		history := &models.CoinHistory{
+			Operation:          string(enum.REFUND),
+			OperationTimestamp: now,
+			UserId:             sender.ID,
+			Amount:             transfer.Amount,
+			Counterparty:       &transfer.Receiver,
+		}
+		if _, err := c.coinRepo.Insert(ctx, history); err != nil {
+			log.Error().Err(err).Send()
+			return nil, err
+		}
+
+		// 返金イベント追加
+		e := &event.CoinRefunded{
+			TransferId:    transfer.ID,
+			Sender:        sender.ID,
+			Receiver:      transfer.Receiver,
+			Amount:        transfer.Amount,
+			Status:        string(status),
+			SenderBalance: *sender.CoinBalance,
+			SenderHeld:    *sender.HeldBalance,
+			OccurredAt:    now,
+		}
+		if _, err := c.outboxRepo.BatchInsert(ctx, []*models.OutboxEvent{newOutboxEvent(e)}); err != nil {
+			log.Error().Err(err).Send()
+			return nil, err
+		}
+
+		// 残高変更通知(commit時に配信)
+		if err := c.notifyBalanceChanged(ctx, sender, history); err != nil {
+			return nil, err
+		}
+		return transfer, nil
+	}
+}
+
+// debit ユーザーの残高から減算(残高不足の場合はエラー)
+func debit(user *models.User, amount int) error {
+	if *user.CoinBalance < amount {
+		// 消費量が残高を上回る場合はエラー
+		log.Log().Msg(fmt.Sprintf("コイン残高不足エラー user : %s", common.CreateJsonString(&user)))
+		return errInsufficientBalance
+	}
+	balance := *user.CoinBalance - amount
+	user.CoinBalance = &balance
+	return nil
+}
+
+// credit ユーザーの残高へ加算(桁あふれの場合はエラー)
+func credit(user *models.User, amount models.Amount) error {
+	balance, err := amount.AddTo(*user.CoinBalance)
+	if err != nil {
+		log.Log().Msg(fmt.Sprintf("残高上限超過エラー user : %s", common.CreateJsonString(&user)))
+		return err
+	}
+	user.CoinBalance = &balance
+	return nil
+}
+
+func (c *CoinUseCase) notifyBalanceChanged(ctx context.Context, user *models.User, history *models.CoinHistory) error {
+	if err := c.notifyRepo.NotifyBalanceChanged(ctx, event.NewBalanceChanged(user, history)); err != nil {
+		log.Error().Err(err).Send()
+		return err
+	}
+	return nil
+}
+
+func (c *CoinUseCase) findPendingTransfer(id string, form *model.TransferResolveForm) (*models.Transfer, *model.ErrorResponse, error) {
+	// id、formのバリデーション
+	if err := validation.Validate(id, validation.Required, is.Digit); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー 送金ID : %s", id))
+		return nil, model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err
+	}
+	if err := form.ValidateTransferResolveForm(); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー TransferResolveForm : %s", common.CreateJsonString(&form)))
+		return nil, model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err
+	}
+
+	// 送金取得
+	transfer, err := c.transferRepo.SelectById(common.StringToUint(id))
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return nil, model.CreateServerErrorResponse(err), err
+	}
+
+	// 受取人本人のみ操作可能
+	if transfer.Receiver != common.StringToUint(form.UserId) {
+		err := fmt.Errorf("送金ID %d の受取人ではありません", transfer.ID)
+		return nil, model.CreateErrorResponse(http.StatusForbidden, err.Error()), err
+	}
+
+	// 承認待ち以外は操作不可
+	if transfer.Status != string(enum.PENDING) {
+		return nil, model.CreateErrorResponse(http.StatusConflict, repository.ErrTransferStatusConflict.Error()), repository.ErrTransferStatusConflict
+	}
+
+	return transfer, nil, nil
+}
+
+// coinTxErrorResponse 残高を更新するトランザクションのエラーのレスポンス(DoInTxでラップされたエラーは元のエラーのメッセージで返却)
+func coinTxErrorResponse(err error) *model.ErrorResponse {
+	switch {
+	case errors.Is(err, repository.ErrTransferStatusConflict):
+		// 並行処理でステータスが変わった場合は409
+		return model.CreateErrorResponse(http.StatusConflict, repository.ErrTransferStatusConflict.Error())
+	case errors.Is(err, models.ErrBalanceOverflow):
+		return model.CreateErrorResponse(http.StatusUnprocessableEntity, models.ErrBalanceOverflow.Error())
+	case errors.Is(err, errInsufficientBalance):
+		return model.CreateErrorResponse(http.StatusInternalServerError, errInsufficientBalance.Error())
+	}
+
+	var violation *rule.Violation
+	if errors.As(err, &violation) {
+		// 送金ルール違反は422(残高の上限超過等と区別するためエラーコードと違反したルールを返却)
+		log.Log().Msg(violation.Error())
+		return model.CreateRuleViolationResponse(violation.Rule, violation.Error())
+	}
+	return model.CreateServerErrorResponse(err)
+}
+
+func (c *CoinUseCase) ReverseHistory(ctx context.Context, id string, form *model.CoinReverseForm) error {
+	// id、formのバリデーション
+	if err := validation.Validate(id, validation.Required, is.Digit); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー 履歴ID : %s", id))
+		return c.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+	if err := form.ValidateCoinReverseForm(); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー CoinReverseForm : %s", common.CreateJsonString(&form)))
+		return c.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// 取消対象履歴の取得
+	original, err := c.coinRepo.SelectById(common.StringToUint(id))
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return c.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	// 取消対象の履歴(送金の場合は送受両方)を決定
+	legs := []*models.CoinHistory{original}
+	switch original.Operation {
+	case string(enum.ADD), string(enum.USE):
+	case string(enum.SEND), string(enum.RECEIVE):
+		counterpartOp := string(enum.RECEIVE)
+		if original.Operation == string(enum.RECEIVE) {
+			counterpartOp = string(enum.SEND)
+		}
+		leg, err := c.coinRepo.SelectCounterpartLeg(original, counterpartOp)
+		if err != nil {
+			log.Error().Stack().Err(err)
+			return c.op.OutputError(model.CreateServerErrorResponse(err), err)
+		}
+		legs = append(legs, leg)
+	default:
+		err := fmt.Errorf("区分 %s の履歴は取消できません", original.Operation)
+		return c.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// 同一transaction内で二重取消・残高の確認、残高の更新と打消し履歴の追加を実行
+	v, err := c.tranRepo.DoInTx(ctx, c.ReverseHistoryAndUpdateBalances(legs, form.Reason, time.Now()))
+	if errors.Is(err, repository.ErrAlreadyReversed) {
+		err := fmt.Errorf("履歴ID %d は取消済みです", original.ID)
+		return c.op.OutputError(model.CreateErrorResponse(http.StatusConflict, err.Error()), err)
+	}
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return c.op.OutputError(coinTxErrorResponse(err), err)
+	}
+	result := v.(*coinReversal)
+
+	// response用に詰め替え
+	entries := make([]*model.CoinResponse, 0, len(result.reversals))
+	for _, v := range result.reversals {
+		entries = append(entries, model.CoinResponseFromDomainModel(v, *result.users[v.UserId].CoinBalance, coinOf(ctx)))
+	}
+
+	return c.op.OutputCoinReversal(&model.CoinReversalResponse{OriginalId: original.ID, Reason: form.Reason, Entries: entries})
+}
+
+// coinReversal 取消処理の結果(打消し履歴と更新後の対象ユーザー)
+type coinReversal struct {
+	users     map[uint]*models.User
+	reversals []*models.CoinHistory
+}
+
+func (c *CoinUseCase) ReverseHistoryAndUpdateBalances(legs []*models.CoinHistory, reason string, now time.Time) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		// 対象ユーザーをロックして最新の残高を取得
+		ids := make([]uint, 0, len(legs))
+		for _, leg := range legs {
+			ids = append(ids, leg.UserId)
+		}
+		users, err := c.userRepo.SelectForUpdate(ctx, ids...)
+		if err != nil {
+			return nil, err
+		}
+
+		// 二重取消の確認(並行する取消と同時に確認を通過した場合は打消し履歴の一意制約違反となる)
+		legIds := make([]uint, 0, len(legs))
+		for _, leg := range legs {
+			legIds = append(legIds, leg.ID)
+		}
+		reversed, err := c.coinRepo.ExistsReversalOf(ctx, legIds...)
+		if err != nil {
+			return nil, err
+		}
+		if reversed {
+			return nil, repository.ErrAlreadyReversed
+		}
+
+		// 打消し履歴の作成と残高の算出(取消により残高が負になる場合はエラー)
+		reversals := make([]*models.CoinHistory, 0, len(legs))
+		for _, leg := range legs {
+			user := users[leg.UserId]
+			if leg.Amount > 0 {
+				err = debit(user, leg.Amount)
+			} else {
+				err = credit(user, models.Amount(-leg.Amount))
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			legId := leg.ID
+			reversals = append(reversals, &models.CoinHistory{
+				Operation:          string(enum.REVERSAL),
+				OperationTimestamp: now,
+				UserId:             leg.UserId,
+				Amount:             -leg.Amount,
+				Counterparty:       leg.Counterparty,
+				ReversalOf:         &legId,
+				Reason:             reason,
+			})
+		}
+
+		// 対象ユーザー残高更新
+		for _, user := range users {
+			if _, err := c.userRepo.UpdateBalances(ctx, user); err != nil {
+				log.Error().Err(err).Send()
+				return nil, err
+			}
+		}
+
+		// 打消し履歴一括追加
+		if _, err := c.coinRepo.BatchInsert(ctx, reversals); err != nil {
+			log.Error().Err(err).Send()
+			return nil, err
+		}
+
+		// 取消イベント追加(打消し履歴ごと)
+		events := make([]*models.OutboxEvent, 0, len(reversals))
+		for _, v := range reversals {
+			events = append(events, newOutboxEvent(&event.CoinReversed{
+				HistoryId:    v.ID,
+				ReversalOf:   *v.ReversalOf,
+				UserId:       v.UserId,
+				Amount:       v.Amount,
+				Balance:      *users[v.UserId].CoinBalance,
+				Counterparty: v.Counterparty,
+				Reason:       v.Reason,
+				OccurredAt:   now,
+			}))
+		}
+		if _, err := c.outboxRepo.BatchInsert(ctx, events); err != nil {
+			log.Error().Err(err).Send()
+			return nil, err
+		}
+
+		// 残高変更通知(commit時に配信)
+		for _, v := range reversals {
+			if err := c.notifyBalanceChanged(ctx, users[v.UserId], v); err != nil {
+				return nil, err
+			}
+		}
+		return &coinReversal{users: users, reversals: reversals}, nil
+	}
+}
+
+func (c *CoinUseCase) VerifyHistoryChain(ctx context.Context, uid string) error {
+	// uidのバリデーション
+	if err := validation.Validate(uid, validation.Required, is.Digit); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー ユーザーID : %s", uid))
+		return c.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// チェーン順の全履歴取得
+	uidUint := common.StringToUint(uid)
+	histories, err := c.coinRepo.SelectChainByUserId(uidUint)
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return c.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	// ハッシュチェーンの検証
+	broken := models.VerifyChain(histories, coinOf(ctx).Precision)
+	if broken != nil {
+		log.Warn().Msg(fmt.Sprintf("ハッシュチェーン不整合 ユーザーID : %d 履歴ID : %d", uidUint, broken.HistoryId))
+	}
+
+	return c.op.OutputChainVerification(model.ChainVerificationResponseFromDomainModel(uidUint, len(histories), broken))
+}
+
+func (c *CoinUseCase) SelectHistoriesByUserId(ctx context.Context, uid string) error {
 	// uidのバリデーション
 	if err := validation.Validate(uid, validation.Required, is.Digit); err != nil {
 		log.Log().Msg(fmt.Sprintf("バリデーションエラー ユーザーID : %s", uid))
@@ -214,13 +866,13 @@ func (c *CoinUseCase) SelectHistoriesByUserId(uid string) error {
 	histories, err := c.coinRepo.SelectHistoriesByUserId(uidUint)
 	if err != nil {
 		log.Error().Stack().Err(err)
-		return c.op.OutputError(model.CreateErrorResponse(http.StatusInternalServerError, err.Error()), err)
+		return c.op.OutputError(model.CreateServerErrorResponse(err), err)
 	}
 
 	// response用に詰め替え
 	response := make([]*model.CoinHistoryResponse, 0)
 	for _, v := range histories {
-		entity := model.CoinHistoryResponseFromDomainModel(&v)
+		entity := model.CoinHistoryResponseFromDomainModel(&v, coinOf(ctx))
 		response = append(response, entity)
 	}
 
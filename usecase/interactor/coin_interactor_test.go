@@ -0,0 +1,38 @@
+package interactor
+
+import (
+	models "coin-api/domain/model"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"coin-api/usecase/rule"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCoinTxErrorResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		want     string
+		wantRule string
+	}{
+		// 送金ルール違反は上限超過を含めて全て422とエラーコード・ルール名で返却
+		{name: "max amount", err: fmt.Errorf("rollback: %w", &rule.Violation{Rule: "max_amount", Message: "上限"}), wantCode: http.StatusUnprocessableEntity, want: model.CodeTransferRuleViolation, wantRule: "max_amount"},
+		{name: "daily cap", err: fmt.Errorf("rollback: %w", &rule.Violation{Rule: "daily_cap", Message: "上限"}), wantCode: http.StatusUnprocessableEntity, want: model.CodeTransferRuleViolation, wantRule: "daily_cap"},
+		{name: "blocked pair", err: fmt.Errorf("rollback: %w", &rule.Violation{Rule: "blocked_pair", Message: "禁止"}), wantCode: http.StatusUnprocessableEntity, want: model.CodeTransferRuleViolation, wantRule: "blocked_pair"},
+		// 残高の上限超過はエラーコードなしの422
+		{name: "balance overflow", err: fmt.Errorf("rollback: %w", models.ErrBalanceOverflow), wantCode: http.StatusUnprocessableEntity},
+		// DBへの接続の遮断は503、それ以外のエラーは500
+		{name: "db unavailable", err: fmt.Errorf("rollback: %w", repository.ErrUnavailable), wantCode: http.StatusServiceUnavailable},
+		{name: "other error", err: errors.New("unexpected"), wantCode: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		res := coinTxErrorResponse(tt.err)
+		if res.ErrorCode != tt.wantCode || res.Code != tt.want || res.Rule != tt.wantRule {
+			t.Errorf("%s: coinTxErrorResponse() = %+v, want %d %q %q", tt.name, res, tt.wantCode, tt.want, tt.wantRule)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package interactor
+
+import (
+	"coin-api/common"
+	"coin-api/domain/event"
+	models "coin-api/domain/model"
+)
+
+// newOutboxEvent 残高更新と同一transactionで登録するイベントを生成
+func newOutboxEvent(e event.DomainEvent) *models.OutboxEvent {
+	return &models.OutboxEvent{
+		EventType: e.EventName(),
+		UserId:    e.AggregateId(),
+		Payload:   common.CreateJsonString(e),
+	}
+}
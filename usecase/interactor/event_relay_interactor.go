@@ -0,0 +1,72 @@
+package interactor
+
+import (
+	"coin-api/common"
+	"coin-api/config"
+	"coin-api/domain/event"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"strconv"
+	"time"
+)
+
+type EventRelayUseCase struct {
+	outboxRepo repository.IOutboxRepository
+	publisher  ports.EventPublisher
+	batchSize  int
+}
+
+func NewEventRelayUseCase(obr repository.IOutboxRepository, publisher ports.EventPublisher) ports.EventRelayInputPort {
+	return &EventRelayUseCase{
+		outboxRepo: obr,
+		publisher:  publisher,
+		batchSize:  config.LoadConfig().EventRelayInfo.BatchSize,
+	}
+}
+
+func (e *EventRelayUseCase) RelayEvents(ctx context.Context) (int, error) {
+	// 未発行イベント取得
+	events, err := e.outboxRepo.SelectUnpublished(e.batchSize)
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return 0, err
+	}
+
+	published := 0
+	for i := range events {
+		ev := &events[i]
+
+		// 内部イベントは発行せず発行済みとする
+		if event.IsInternal(ev.EventType) {
+			if _, err := e.outboxRepo.MarkPublished(ev, time.Now()); err != nil {
+				log.Error().Stack().Err(err)
+				return published, err
+			}
+			continue
+		}
+
+		// 発行順序を保つため失敗した時点で中断し、次回同じイベントから再発行
+		msg := &ports.EventMessage{
+			Name: ev.EventType,
+			Key:  strconv.FormatUint(uint64(ev.UserId), 10),
+			Body: []byte(common.CreateJsonString(model.EventEnvelopeFromDomainModel(ev))),
+		}
+		if err := e.publisher.Publish(ctx, msg); err != nil {
+			log.Error().Msg(fmt.Sprintf("イベント発行処理でエラー発生 イベントID : %d", ev.ID))
+			return published, err
+		}
+
+		// 発行済み更新(更新失敗時は再発行されるため受信側は冪等に処理する)
+		if _, err := e.outboxRepo.MarkPublished(ev, time.Now()); err != nil {
+			log.Error().Stack().Err(err)
+			return published, err
+		}
+		published++
+	}
+
+	return published, nil
+}
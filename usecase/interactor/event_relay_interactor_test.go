@@ -0,0 +1,163 @@
+package interactor
+
+import (
+	"coin-api/domain/event"
+	models "coin-api/domain/model"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeEventPublisher 発行したメッセージを記録し、指定回数だけ発行に失敗するEventPublisher
+type fakeEventPublisher struct {
+	messages []*ports.EventMessage
+	failures int
+}
+
+var errBrokerUnavailable = errors.New("broker unavailable")
+
+func (p *fakeEventPublisher) Publish(ctx context.Context, msg *ports.EventMessage) error {
+	if p.failures > 0 {
+		p.failures--
+		return errBrokerUnavailable
+	}
+	p.messages = append(p.messages, msg)
+	return nil
+}
+
+func (p *fakeEventPublisher) Close() error {
+	return nil
+}
+
+func newEventRelayTestUseCase(publisher ports.EventPublisher, batchSize int) (*EventRelayUseCase, *fakeOutboxRepository) {
+	obr := &fakeOutboxRepository{}
+	return &EventRelayUseCase{
+		outboxRepo: obr,
+		publisher:  publisher,
+		batchSize:  batchSize,
+	}, obr
+}
+
+func TestRelayEventsPublishesInOrder(t *testing.T) {
+	p := &fakeEventPublisher{}
+	e, obr := newEventRelayTestUseCase(p, 10)
+	ctx := context.Background()
+
+	_, _ = obr.BatchInsert(ctx, []*models.OutboxEvent{
+		newOutboxEvent(&event.CoinAdded{UserId: 1, Amount: 100, Balance: 100}),
+		newOutboxEvent(&event.CoinTransferred{Sender: 2, Receiver: 1, Amount: 50, SenderBalance: 0, ReceiverBalance: 150}),
+	})
+
+	if n, err := e.RelayEvents(ctx); err != nil || n != 2 {
+		t.Fatalf("RelayEvents() = %d, %v, want 2, nil", n, err)
+	}
+
+	// イベント名・集約ID(ユーザーID)をキーとしてoutboxの登録順に発行
+	want := []struct {
+		name string
+		key  string
+	}{{event.CoinAddedName, "1"}, {event.CoinTransferredName, "2"}}
+	if len(p.messages) != len(want) {
+		t.Fatalf("messages = %d, want %d", len(p.messages), len(want))
+	}
+	for i, w := range want {
+		msg := p.messages[i]
+		if msg.Name != w.name || msg.Key != w.key {
+			t.Errorf("message %d = %s/%s, want %s/%s", i, msg.Name, msg.Key, w.name, w.key)
+		}
+		var envelope model.EventEnvelope
+		if err := json.Unmarshal(msg.Body, &envelope); err != nil {
+			t.Fatalf("message %d body = %s: %v", i, msg.Body, err)
+		}
+		if envelope.EventId != obr.events[i].ID || envelope.EventName != w.name || envelope.TenantId != "default" || string(envelope.Payload) != obr.events[i].Payload {
+			t.Errorf("message %d envelope = %s", i, msg.Body)
+		}
+		if obr.events[i].PublishedAt == nil {
+			t.Errorf("event %d is not marked published", i)
+		}
+	}
+
+	// 発行済みのイベントは再発行しない
+	if n, err := e.RelayEvents(ctx); err != nil || n != 0 {
+		t.Errorf("RelayEvents() = %d, %v, want 0, nil", n, err)
+	}
+}
+
+func TestRelayEventsStopsAtFailureAndResumes(t *testing.T) {
+	p := &fakeEventPublisher{}
+	e, obr := newEventRelayTestUseCase(p, 10)
+	ctx := context.Background()
+
+	_, _ = obr.BatchInsert(ctx, []*models.OutboxEvent{newOutboxEvent(&event.CoinAdded{UserId: 1, Amount: 100, Balance: 100})})
+	if n, err := e.RelayEvents(ctx); err != nil || n != 1 {
+		t.Fatalf("RelayEvents() = %d, %v, want 1, nil", n, err)
+	}
+	_, _ = obr.BatchInsert(ctx, []*models.OutboxEvent{
+		newOutboxEvent(&event.CoinUsed{UserId: 1, Amount: 30, Balance: 70}),
+		newOutboxEvent(&event.CoinAdded{UserId: 1, Amount: 10, Balance: 80}),
+	})
+
+	// 発行に失敗した場合は以降のイベントを発行せず中断
+	p.failures = 1
+	n, err := e.RelayEvents(ctx)
+	if !errors.Is(err, errBrokerUnavailable) || n != 0 {
+		t.Fatalf("RelayEvents() = %d, %v, want 0, %v", n, err, errBrokerUnavailable)
+	}
+	if obr.events[1].PublishedAt != nil || obr.events[2].PublishedAt != nil {
+		t.Error("events after the failure are marked published")
+	}
+	if len(p.messages) != 1 {
+		t.Errorf("messages = %d, want 1", len(p.messages))
+	}
+
+	// 次回は失敗したイベントから順に再発行
+	if n, err := e.RelayEvents(ctx); err != nil || n != 2 {
+		t.Fatalf("RelayEvents() = %d, %v, want 2, nil", n, err)
+	}
+	if len(p.messages) != 3 || p.messages[1].Name != event.CoinUsedName || p.messages[2].Name != event.CoinAddedName {
+		t.Errorf("messages = %+v, want CoinAdded, CoinUsed, CoinAdded", p.messages)
+	}
+}
+
+func TestRelayEventsSkipsInternalEvents(t *testing.T) {
+	p := &fakeEventPublisher{}
+	e, obr := newEventRelayTestUseCase(p, 10)
+	ctx := context.Background()
+
+	_, _ = obr.BatchInsert(ctx, []*models.OutboxEvent{
+		newOutboxEvent(&event.PasswordResetRequested{UserId: 1, Username: "alice", ExpiresAt: time.Now().Add(time.Hour)}),
+		newOutboxEvent(&event.UserCreated{UserId: 2}),
+	})
+
+	// 内部イベントは発行せず発行済みとする
+	if n, err := e.RelayEvents(ctx); err != nil || n != 1 {
+		t.Fatalf("RelayEvents() = %d, %v, want 1, nil", n, err)
+	}
+	if len(p.messages) != 1 || p.messages[0].Name != event.UserCreatedName {
+		t.Errorf("messages = %+v, want only UserCreated", p.messages)
+	}
+	if obr.events[0].PublishedAt == nil {
+		t.Error("internal event is not marked published")
+	}
+}
+
+func TestRelayEventsRespectsBatchSize(t *testing.T) {
+	p := &fakeEventPublisher{}
+	e, obr := newEventRelayTestUseCase(p, 2)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, _ = obr.BatchInsert(ctx, []*models.OutboxEvent{newOutboxEvent(&event.CoinAdded{UserId: 1, Amount: 1})})
+	}
+
+	if n, err := e.RelayEvents(ctx); err != nil || n != 2 {
+		t.Fatalf("RelayEvents() = %d, %v, want 2, nil", n, err)
+	}
+	if n, err := e.RelayEvents(ctx); err != nil || n != 1 {
+		t.Fatalf("RelayEvents() = %d, %v, want 1, nil", n, err)
+	}
+}
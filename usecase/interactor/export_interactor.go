@@ -0,0 +1,79 @@
+package interactor
+
+import (
+	"coin-api/common"
+	models "coin-api/domain/model"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"context"
+	"fmt"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+	"github.com/rs/zerolog/log"
+	"net/http"
+)
+
+type ExportUseCase struct {
+	op       ports.ExportOutputPort
+	coinRepo repository.ICoinRepository
+	userRepo repository.IUserRepository
+}
+
+func NewExportUseCase(eop ports.ExportOutputPort, cr repository.ICoinRepository, ur repository.IUserRepository) ports.ExportInputPort {
+	return &ExportUseCase{
+		op:       eop,
+		coinRepo: cr,
+		userRepo: ur,
+	}
+}
+
+func (e *ExportUseCase) ExportUserHistories(ctx context.Context, uid string, form *model.ExportForm) error {
+	// uid、formのバリデーション
+	if err := validation.Validate(uid, validation.Required, is.Digit); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー ユーザーID : %s", uid))
+		return e.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+	if err := form.ValidateExportForm(); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー ExportForm : %s", common.CreateJsonString(&form)))
+		return e.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// 対象ユーザーの存在確認
+	uidUint := common.StringToUint(uid)
+	if _, err := e.userRepo.SelectById(uidUint); err != nil {
+		log.Error().Stack().Err(err)
+		return e.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	filter := &repository.ExportFilter{UserId: &uidUint, From: form.FromTime(), To: form.ToTime()}
+	return e.export(ctx, fmt.Sprintf("coin_history_%d", uidUint), filter)
+}
+
+func (e *ExportUseCase) ExportAllHistories(ctx context.Context, form *model.ExportForm) error {
+	// formのバリデーション
+	if err := form.ValidateExportForm(); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー ExportForm : %s", common.CreateJsonString(&form)))
+		return e.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	filter := &repository.ExportFilter{From: form.FromTime(), To: form.ToTime()}
+	return e.export(ctx, "coin_history_all", filter)
+}
+
+// export 履歴を1行ずつ読み出しながら出力
+func (e *ExportUseCase) export(ctx context.Context, name string, filter *repository.ExportFilter) error {
+	if err := e.op.OutputExportStart(name); err != nil {
+		return err
+	}
+
+	err := e.coinRepo.ExportHistories(ctx, filter, func(row *models.HistoryExportRow) error {
+		return e.op.OutputExportRow(model.HistoryExportResponseFromDomainModel(row, coinOf(ctx)))
+	})
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return e.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	return e.op.OutputExportEnd()
+}
@@ -0,0 +1,323 @@
+package interactor
+
+import (
+	"coin-api/common"
+	"coin-api/common/enum"
+	"coin-api/common/tenant"
+	"coin-api/domain/event"
+	models "coin-api/domain/model"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"context"
+	"errors"
+	"fmt"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type ScheduleUseCase struct {
+	op           ports.ScheduleOutputPort
+	scheduleRepo repository.IScheduleRepository
+	userRepo     repository.IUserRepository
+	outboxRepo   repository.IOutboxRepository
+	tranRepo     repository.ITxRepository
+}
+
+func NewScheduleUseCase(sop ports.ScheduleOutputPort, sr repository.IScheduleRepository, ur repository.IUserRepository, obr repository.IOutboxRepository, tr repository.ITxRepository) ports.ScheduleInputPort {
+	return &ScheduleUseCase{
+		op:           sop,
+		scheduleRepo: sr,
+		userRepo:     ur,
+		outboxRepo:   obr,
+		tranRepo:     tr,
+	}
+}
+
+func (s *ScheduleUseCase) CreateSchedule(ctx context.Context, form *model.ScheduleAddForm) error {
+	// formのバリデーション
+	if err := form.ValidateScheduleAddForm(coinOf(ctx)); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー ScheduleAddForm : %s", common.CreateJsonString(&form)))
+		return s.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// Sender、Receiverの存在確認
+	senderUidUint := common.StringToUint(string(form.Sender))
+	if _, err := s.userRepo.SelectById(senderUidUint); err != nil {
+		log.Error().Stack().Err(err)
+		return s.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+	receiverUidUint := common.StringToUint(string(form.Receiver))
+	if _, err := s.userRepo.SelectById(receiverUidUint); err != nil {
+		log.Error().Stack().Err(err)
+		return s.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	// 初回実行日時の決定(未指定の場合は即時)
+	nextRunAt := time.Now()
+	if form.StartAt != "" {
+		startAt, err := time.Parse(time.RFC3339, form.StartAt)
+		if err != nil {
+			log.Log().Msg(fmt.Sprintf("バリデーションエラー 開始日時 : %s", form.StartAt))
+			return s.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+		}
+		nextRunAt = startAt
+	}
+
+	// Insert対象データ作成
+	amount, _ := coinOf(ctx).ParseAmount(string(form.Amount))
+	target := models.Schedule{
+		Sender:    senderUidUint,
+		Receiver:  receiverUidUint,
+		Amount:    amount.Int(),
+		Interval:  form.Interval,
+		Status:    string(enum.ACTIVE),
+		NextRunAt: nextRunAt,
+	}
+
+	// スケジュール登録処理実行
+	schedule, err := s.scheduleRepo.Insert(&target)
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return s.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	return s.op.OutputSchedule(model.ScheduleResponseFromDomainModel(schedule, coinOf(ctx)))
+}
+
+func (s *ScheduleUseCase) SelectSchedulesByUserId(ctx context.Context, uid string) error {
+	// uidのバリデーション
+	if err := validation.Validate(uid, validation.Required, is.Digit); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー ユーザーID : %s", uid))
+		return s.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// 送金者に紐づくスケジュール取得
+	schedules, err := s.scheduleRepo.SelectBySender(common.StringToUint(uid))
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return s.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	// response用に詰め替え
+	response := make([]*model.ScheduleResponse, 0)
+	for i := range schedules {
+		response = append(response, model.ScheduleResponseFromDomainModel(&schedules[i], coinOf(ctx)))
+	}
+
+	return s.op.OutputSchedules(response)
+}
+
+func (s *ScheduleUseCase) SelectExecutionsByScheduleId(id string) error {
+	// idのバリデーション
+	if err := validation.Validate(id, validation.Required, is.Digit); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー スケジュールID : %s", id))
+		return s.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// スケジュールに紐づく実行履歴取得
+	executions, err := s.scheduleRepo.SelectExecutionsByScheduleId(common.StringToUint(id))
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return s.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	// response用に詰め替え
+	response := make([]*model.ScheduleExecutionResponse, 0)
+	for i := range executions {
+		response = append(response, model.ScheduleExecutionResponseFromDomainModel(&executions[i]))
+	}
+
+	return s.op.OutputScheduleExecutions(response)
+}
+
+func (s *ScheduleUseCase) CancelSchedule(ctx context.Context, id string, uid string) error {
+	// id、uidのバリデーション
+	if err := validation.Validate(id, validation.Required, is.Digit); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー スケジュールID : %s", id))
+		return s.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+	if err := validation.Validate(uid, validation.Required, is.Digit); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー ユーザーID : %s", uid))
+		return s.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// スケジュール取得
+	schedule, err := s.scheduleRepo.SelectById(common.StringToUint(id))
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return s.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	// 送金者本人のみ取消可能
+	if schedule.Sender != common.StringToUint(uid) {
+		err := fmt.Errorf("スケジュールID %d の送金者ではありません", schedule.ID)
+		return s.op.OutputError(model.CreateErrorResponse(http.StatusForbidden, err.Error()), err)
+	}
+
+	// ステータス更新
+	schedule.Status = string(enum.CANCELLED)
+	if _, err := s.scheduleRepo.Update(ctx, schedule); err != nil {
+		log.Error().Stack().Err(err)
+		return s.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	return s.op.OutputSchedule(model.ScheduleResponseFromDomainModel(schedule, coinOf(ctx)))
+}
+
+func (s *ScheduleUseCase) RunDueSchedules(ctx context.Context, coinInput ports.CoinInputPortFactory) (int, error) {
+	// 実行予定日時を過ぎたスケジュール取得
+	now := time.Now()
+	schedules, err := s.scheduleRepo.SelectDue(now)
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return 0, err
+	}
+
+	executed := 0
+	for i := range schedules {
+		schedule := &schedules[i]
+
+		// 次回実行日時の確定、送金、実行結果の記録を同一transactionで実行(他の実行と競合した場合はスキップ)
+		v, err := s.tranRepo.DoInTx(ctx, s.RunSchedule(schedule, now, coinInput), transferTxOptions...)
+		if err != nil {
+			log.Error().Stack().Err(err).Send()
+			continue
+		}
+		if execution := v.(*models.ScheduleExecution); execution.Status == string(enum.SKIPPED) {
+			log.Warn().Msg(fmt.Sprintf("スケジュール送金をスキップ スケジュールID : %d 理由 : %s", schedule.ID, execution.Message))
+		}
+		executed++
+	}
+
+	return executed, nil
+}
+
+func (s *ScheduleUseCase) RunSchedule(schedule *models.Schedule, now time.Time, coinInput ports.CoinInputPortFactory) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		// 再試行時も取得時点の実行予定日時で確定するため複製して更新
+		target := *schedule
+
+		// 次回実行日時を確定(実行予定日時が取得時点から変わっている場合は競合エラー)
+		if _, err := s.scheduleRepo.ClaimNextRun(ctx, &target, nextRunAt(schedule.NextRunAt, schedule.Interval, now)); err != nil {
+			return nil, err
+		}
+
+		// 送金者のテナントのコインで金額を指定するため送金者を取得
+		sender, err := s.userRepo.SelectById(target.Sender)
+		if err != nil {
+			return nil, err
+		}
+
+		// SendCoinと同じ処理で送金を実行(セーブポイント内で実行されるため、失敗した場合は送金のみロールバック)
+		scheduleId := target.ID
+		form := &model.CoinSendForm{
+			Sender:     model.NumericString(strconv.FormatUint(uint64(target.Sender), 10)),
+			Receiver:   model.NumericString(strconv.FormatUint(uint64(target.Receiver), 10)),
+			Amount:     model.NumericString(model.CoinOf(sender.TenantId).Decimal(target.Amount).String()),
+			ScheduleId: &scheduleId,
+		}
+		recorder := &coinSendRecorder{}
+		sendCtx := tenant.WithTenant(ctx, sender.TenantId)
+		if err := coinInput(recorder).SendCoin(sendCtx, form); errors.Is(err, repository.ErrTxRetryable) {
+			// 直列化失敗・デッドロックはスキップとせずtransaction全体を再試行
+			return nil, err
+		}
+
+		// 実行結果の記録
+		execution := &models.ScheduleExecution{
+			ScheduleId: target.ID,
+			ExecutedAt: now,
+			Status:     string(enum.SUCCEEDED),
+		}
+
+		// 残高不足等の場合はスキップとして記録し、スキップイベントを追加
+		if recorder.errorResponse != nil {
+			execution.Status = string(enum.SKIPPED)
+			execution.Message = recorder.errorResponse.Message
+			e := &event.ScheduleSkipped{
+				ScheduleId: target.ID,
+				Sender:     target.Sender,
+				Receiver:   target.Receiver,
+				Amount:     target.Amount,
+				Reason:     execution.Message,
+				NextRunAt:  target.NextRunAt,
+				OccurredAt: now,
+			}
+			if _, err := s.outboxRepo.BatchInsert(ctx, []*models.OutboxEvent{newOutboxEvent(e)}); err != nil {
+				log.Error().Err(err).Send()
+				return nil, err
+			}
+		}
+
+		// 前回実行日時更新
+		target.LastRunAt = &now
+		if _, err := s.scheduleRepo.Update(ctx, &target); err != nil {
+			log.Error().Err(err).Send()
+			return nil, err
+		}
+
+		// 実行履歴追加
+		if _, err := s.scheduleRepo.InsertExecution(ctx, execution); err != nil {
+			log.Error().Err(err).Send()
+			return nil, err
+		}
+		return execution, nil
+	}
+}
+
+// nextRunAt 基準日時から間隔分進め、現在日時より後となる最初の日時を返却
+func nextRunAt(from time.Time, interval string, now time.Time) time.Time {
+	next := from
+	for !next.After(now) {
+		switch interval {
+		case string(enum.DAILY):
+			next = next.AddDate(0, 0, 1)
+		case string(enum.WEEKLY):
+			next = next.AddDate(0, 0, 7)
+		default:
+			next = next.AddDate(0, 1, 0)
+		}
+	}
+	return next
+}
+
+// coinSendRecorder スケジュール実行時に送金結果を受け取るOutputPort
+type coinSendRecorder struct {
+	response      *model.CoinSendResponse
+	errorResponse *model.ErrorResponse
+}
+
+func (r *coinSendRecorder) OutputCoin(coin *model.CoinResponse) error {
+	return nil
+}
+
+func (r *coinSendRecorder) OutputCoinSend(coin *model.CoinSendResponse) error {
+	r.response = coin
+	return nil
+}
+
+func (r *coinSendRecorder) OutputCoinTransfer(transfer *model.CoinTransferResponse) error {
+	return nil
+}
+
+func (r *coinSendRecorder) OutputCoinReversal(reversal *model.CoinReversalResponse) error {
+	return nil
+}
+
+func (r *coinSendRecorder) OutputCoinHistory(histories []*model.CoinHistoryResponse) error {
+	return nil
+}
+
+func (r *coinSendRecorder) OutputChainVerification(verification *model.ChainVerificationResponse) error {
+	return nil
+}
+
+func (r *coinSendRecorder) OutputError(res *model.ErrorResponse, err error) error {
+	r.errorResponse = res
+	return err
+}
@@ -0,0 +1,334 @@
+package interactor
+
+import (
+	"coin-api/common"
+	"coin-api/common/enum"
+	"coin-api/common/tenant"
+	"coin-api/domain/event"
+	models "coin-api/domain/model"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// retryingTxRepository 指定されたオプションを記録し、repository.ErrTxRetryableの場合はfを再実行するITxRepository
+type retryingTxRepository struct {
+	options []repository.TxOptions
+	// rollback 再実行前に呼び出すロールバック処理
+	rollback func()
+}
+
+func (r *retryingTxRepository) DoInTx(ctx context.Context, f func(ctx context.Context) (interface{}, error), opts ...repository.TxOption) (interface{}, error) {
+	var options repository.TxOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	r.options = append(r.options, options)
+	for {
+		v, err := f(ctx)
+		if !errors.Is(err, repository.ErrTxRetryable) {
+			return v, err
+		}
+		if r.rollback != nil {
+			r.rollback()
+		}
+	}
+}
+
+// fakeScheduleRepository メモリ上のスケジュール・実行履歴
+type fakeScheduleRepository struct {
+	schedules  []*models.Schedule
+	executions []*models.ScheduleExecution
+	updateCtx  context.Context
+}
+
+func (r *fakeScheduleRepository) SelectById(id uint) (*models.Schedule, error) {
+	s := *r.schedules[id-1]
+	return &s, nil
+}
+
+func (r *fakeScheduleRepository) SelectBySender(uid uint) ([]models.Schedule, error) {
+	return nil, nil
+}
+
+func (r *fakeScheduleRepository) SelectDue(now time.Time) ([]models.Schedule, error) {
+	schedules := make([]models.Schedule, 0)
+	for _, s := range r.schedules {
+		if s.Status == string(enum.ACTIVE) && !s.NextRunAt.After(now) {
+			schedules = append(schedules, *s)
+		}
+	}
+	return schedules, nil
+}
+
+func (r *fakeScheduleRepository) SelectExecutionsByScheduleId(id uint) ([]models.ScheduleExecution, error) {
+	return nil, nil
+}
+
+func (r *fakeScheduleRepository) Insert(schedule *models.Schedule) (*models.Schedule, error) {
+	schedule.ID = uint(len(r.schedules) + 1)
+	r.schedules = append(r.schedules, schedule)
+	return schedule, nil
+}
+
+func (r *fakeScheduleRepository) Update(ctx context.Context, schedule *models.Schedule) (*models.Schedule, error) {
+	r.updateCtx = ctx
+	s := *schedule
+	r.schedules[schedule.ID-1] = &s
+	return schedule, nil
+}
+
+func (r *fakeScheduleRepository) ClaimNextRun(ctx context.Context, schedule *models.Schedule, next time.Time) (*models.Schedule, error) {
+	s := r.schedules[schedule.ID-1]
+	if !s.NextRunAt.Equal(schedule.NextRunAt) || s.Status != string(enum.ACTIVE) {
+		return nil, repository.ErrScheduleConflict
+	}
+	s.NextRunAt = next
+	schedule.NextRunAt = next
+	return schedule, nil
+}
+
+func (r *fakeScheduleRepository) InsertExecution(ctx context.Context, execution *models.ScheduleExecution) (*models.ScheduleExecution, error) {
+	execution.ID = uint(len(r.executions) + 1)
+	r.executions = append(r.executions, execution)
+	return execution, nil
+}
+
+// fakeUserRepository 指定テナントのユーザーまたはエラーを返却するIUserRepository(SelectById以外は未使用)
+type fakeUserRepository struct {
+	repository.IUserRepository
+	tenantId string
+	err      error
+}
+
+func (r *fakeUserRepository) SelectById(id uint) (*models.User, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	user := &models.User{TenantId: r.tenantId}
+	user.ID = id
+	return user, nil
+}
+
+// fakeScheduleOutput 出力したスケジュールとエラーを記録するScheduleOutputPort
+type fakeScheduleOutput struct {
+	schedule *model.ScheduleResponse
+	err      *model.ErrorResponse
+}
+
+func (o *fakeScheduleOutput) OutputSchedule(schedule *model.ScheduleResponse) error {
+	o.schedule = schedule
+	return nil
+}
+
+func (o *fakeScheduleOutput) OutputSchedules(schedules []*model.ScheduleResponse) error { return nil }
+
+func (o *fakeScheduleOutput) OutputScheduleExecutions(executions []*model.ScheduleExecutionResponse) error {
+	return nil
+}
+
+func (o *fakeScheduleOutput) OutputError(res *model.ErrorResponse, err error) error {
+	o.err = res
+	return err
+}
+
+// coinSends SendCoinに指定されたformとcontextのテナント
+type coinSends struct {
+	forms   []*model.CoinSendForm
+	tenants []string
+}
+
+// fakeCoinInput 送金結果を順に返却するCoinInputPort(nilの場合は送金成功)
+type fakeCoinInput struct {
+	op      ports.CoinOutputPort
+	results *[]error
+	sends   *coinSends
+}
+
+func (c *fakeCoinInput) SendCoin(ctx context.Context, form *model.CoinSendForm) error {
+	c.sends.forms = append(c.sends.forms, form)
+	c.sends.tenants = append(c.sends.tenants, tenant.FromContext(ctx))
+	err := (*c.results)[0]
+	*c.results = (*c.results)[1:]
+	if err != nil {
+		return c.op.OutputError(model.CreateErrorResponse(http.StatusInternalServerError, err.Error()), err)
+	}
+	return c.op.OutputCoinSend(&model.CoinSendResponse{})
+}
+
+func (c *fakeCoinInput) SelectHistoriesByUserId(ctx context.Context, uid string) error {
+	return nil
+}
+func (c *fakeCoinInput) VerifyHistoryChain(ctx context.Context, uid string) error { return nil }
+func (c *fakeCoinInput) AddUseCoin(ctx context.Context, form *model.CoinAddUseForm) error {
+	return nil
+}
+func (c *fakeCoinInput) AcceptTransfer(ctx context.Context, id string, form *model.TransferResolveForm) error {
+	return nil
+}
+func (c *fakeCoinInput) RejectTransfer(ctx context.Context, id string, form *model.TransferResolveForm) error {
+	return nil
+}
+func (c *fakeCoinInput) ExpirePendingTransfers(ctx context.Context) (int, error) { return 0, nil }
+func (c *fakeCoinInput) ReverseHistory(ctx context.Context, id string, form *model.CoinReverseForm) error {
+	return nil
+}
+
+func newScheduleTestUseCase(results ...error) (*ScheduleUseCase, *fakeScheduleRepository, *fakeOutboxRepository, *retryingTxRepository, ports.CoinInputPortFactory, *coinSends) {
+	sr := &fakeScheduleRepository{}
+	obr := &fakeOutboxRepository{}
+	tr := &retryingTxRepository{}
+	s := &ScheduleUseCase{
+		scheduleRepo: sr,
+		userRepo:     &fakeUserRepository{tenantId: "default"},
+		outboxRepo:   obr,
+		tranRepo:     tr,
+	}
+	sends := &coinSends{}
+	coinInput := func(op ports.CoinOutputPort) ports.CoinInputPort {
+		return &fakeCoinInput{op: op, results: &results, sends: sends}
+	}
+	return s, sr, obr, tr, coinInput, sends
+}
+
+func TestRunDueSchedulesRecordsExecution(t *testing.T) {
+	s, sr, obr, tr, coinInput, sends := newScheduleTestUseCase(nil)
+	start := time.Now().Add(-time.Minute)
+	_, _ = sr.Insert(&models.Schedule{Sender: 1, Receiver: 2, Amount: 150, Interval: string(enum.DAILY), Status: string(enum.ACTIVE), NextRunAt: start})
+
+	if n, err := s.RunDueSchedules(context.Background(), coinInput); err != nil || n != 1 {
+		t.Fatalf("RunDueSchedules() = %d, %v, want 1, nil", n, err)
+	}
+
+	// 送金・実行履歴・次回実行日時を直列化可能なtransactionで記録
+	if len(tr.options) != 1 || tr.options[0].Isolation != sql.LevelSerializable {
+		t.Errorf("DoInTx() options = %+v, want serializable", tr.options)
+	}
+	// 送金者のテナントで、テナントのコインの桁数の金額を指定して送金
+	if len(sends.forms) != 1 || sends.forms[0].Amount != "1.50" || *sends.forms[0].ScheduleId != 1 || sends.tenants[0] != "default" {
+		t.Errorf("SendCoin() forms = %s, tenants = %v", common.CreateJsonString(sends.forms), sends.tenants)
+	}
+	if len(sr.executions) != 1 || sr.executions[0].Status != string(enum.SUCCEEDED) {
+		t.Errorf("executions = %s, want one SUCCEEDED", common.CreateJsonString(sr.executions))
+	}
+	if got := sr.schedules[0]; !got.NextRunAt.Equal(start.AddDate(0, 0, 1)) || got.LastRunAt == nil {
+		t.Errorf("schedule = %s, want next run tomorrow", common.CreateJsonString(got))
+	}
+	if len(obr.events) != 0 {
+		t.Errorf("events = %d, want 0", len(obr.events))
+	}
+}
+
+func TestRunDueSchedulesEmitsSkippedEvent(t *testing.T) {
+	errShortage := errors.New("コイン残高不足エラー")
+	s, sr, obr, _, coinInput, _ := newScheduleTestUseCase(errShortage)
+	_, _ = sr.Insert(&models.Schedule{Sender: 1, Receiver: 2, Amount: 150, Interval: string(enum.WEEKLY), Status: string(enum.ACTIVE), NextRunAt: time.Now().Add(-time.Minute)})
+
+	if n, err := s.RunDueSchedules(context.Background(), coinInput); err != nil || n != 1 {
+		t.Fatalf("RunDueSchedules() = %d, %v, want 1, nil", n, err)
+	}
+
+	// スキップを実行履歴に記録し、同一transactionでスキップイベントを追加
+	if len(sr.executions) != 1 || sr.executions[0].Status != string(enum.SKIPPED) || sr.executions[0].Message != errShortage.Error() {
+		t.Fatalf("executions = %s, want one SKIPPED", common.CreateJsonString(sr.executions))
+	}
+	if len(obr.events) != 1 || obr.events[0].EventType != event.ScheduleSkippedName || obr.events[0].UserId != 1 {
+		t.Fatalf("events = %s, want one ScheduleSkipped", common.CreateJsonString(obr.events))
+	}
+	e, err := event.Decode(obr.events[0].EventType, []byte(obr.events[0].Payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	skipped := e.(*event.ScheduleSkipped)
+	if skipped.ScheduleId != 1 || skipped.Receiver != 2 || skipped.Amount != 150 || skipped.Reason != errShortage.Error() || !skipped.NextRunAt.Equal(sr.schedules[0].NextRunAt) {
+		t.Errorf("ScheduleSkipped = %s", obr.events[0].Payload)
+	}
+}
+
+func TestRunDueSchedulesRetriesConflictWithoutSkipping(t *testing.T) {
+	conflict := fmt.Errorf("rollback to savepoint: %w", repository.ErrTxRetryable)
+	s, sr, obr, tr, coinInput, sends := newScheduleTestUseCase(conflict, nil)
+	start := time.Now().Add(-time.Minute)
+	_, _ = sr.Insert(&models.Schedule{Sender: 1, Receiver: 2, Amount: 100, Interval: string(enum.MONTHLY), Status: string(enum.ACTIVE), NextRunAt: start})
+	tr.rollback = func() { sr.schedules[0].NextRunAt = start }
+
+	if n, err := s.RunDueSchedules(context.Background(), coinInput); err != nil || n != 1 {
+		t.Fatalf("RunDueSchedules() = %d, %v, want 1, nil", n, err)
+	}
+
+	// 直列化失敗はスキップとせず、取得時点の実行予定日時から再実行
+	if len(sends.forms) != 2 {
+		t.Errorf("SendCoin() calls = %d, want 2", len(sends.forms))
+	}
+	if len(sr.executions) != 1 || sr.executions[0].Status != string(enum.SUCCEEDED) {
+		t.Errorf("executions = %s, want one SUCCEEDED", common.CreateJsonString(sr.executions))
+	}
+	if len(obr.events) != 0 {
+		t.Errorf("events = %d, want 0", len(obr.events))
+	}
+}
+
+func TestRunDueSchedulesSkipsClaimedSchedule(t *testing.T) {
+	s, sr, _, _, coinInput, sends := newScheduleTestUseCase()
+	_, _ = sr.Insert(&models.Schedule{Sender: 1, Receiver: 2, Amount: 100, Interval: string(enum.DAILY), Status: string(enum.ACTIVE), NextRunAt: time.Now().Add(-time.Minute)})
+	due, _ := sr.SelectDue(time.Now())
+
+	// 取得後に他の実行が次回実行日時を確定した場合は送金しない
+	sr.schedules[0].NextRunAt = time.Now().Add(time.Hour)
+	if _, err := s.RunSchedule(&due[0], time.Now(), coinInput)(context.Background()); !errors.Is(err, repository.ErrScheduleConflict) {
+		t.Fatalf("RunSchedule() error = %v, want %v", err, repository.ErrScheduleConflict)
+	}
+	if len(sends.forms) != 0 || len(sr.executions) != 0 {
+		t.Errorf("SendCoin() calls = %d, executions = %d, want 0", len(sends.forms), len(sr.executions))
+	}
+}
+
+func TestCreateScheduleRejectsInvalidStartAt(t *testing.T) {
+	s, sr, _, _, _, _ := newScheduleTestUseCase()
+	op := &fakeScheduleOutput{}
+	s.op = op
+
+	// 開始日時がRFC3339でない場合は400で登録しない
+	form := &model.ScheduleAddForm{Sender: "1", Receiver: "2", Amount: "1.50", Interval: string(enum.DAILY), StartAt: "2023-04-15 10:00"}
+	if err := s.CreateSchedule(context.Background(), form); err == nil || op.err == nil || op.err.ErrorCode != http.StatusBadRequest {
+		t.Fatalf("CreateSchedule() error = %v, response = %+v, want 400", err, op.err)
+	}
+	if len(sr.schedules) != 0 {
+		t.Errorf("schedules = %d, want 0", len(sr.schedules))
+	}
+
+	// 指定した開始日時を初回実行日時として登録
+	form.StartAt = "2023-04-15T10:00:00+09:00"
+	if err := s.CreateSchedule(context.Background(), form); err != nil {
+		t.Fatalf("CreateSchedule() error = %v", err)
+	}
+	if want, _ := time.Parse(time.RFC3339, form.StartAt); len(sr.schedules) != 1 || !sr.schedules[0].NextRunAt.Equal(want) {
+		t.Errorf("schedules = %s, want next run %v", common.CreateJsonString(sr.schedules), want)
+	}
+}
+
+func TestCancelScheduleUsesRequestContext(t *testing.T) {
+	s, sr, _, _, _, _ := newScheduleTestUseCase()
+	op := &fakeScheduleOutput{}
+	s.op = op
+	_, _ = sr.Insert(&models.Schedule{Sender: 1, Receiver: 2, Amount: 100, Interval: string(enum.DAILY), Status: string(enum.ACTIVE), NextRunAt: time.Now()})
+
+	// リクエストのcontext(テナント)で更新
+	ctx := tenant.WithTenant(context.Background(), "shop")
+	if err := s.CancelSchedule(ctx, "1", "1"); err != nil {
+		t.Fatalf("CancelSchedule() error = %v", err)
+	}
+	if sr.updateCtx == nil || tenant.FromContext(sr.updateCtx) != "shop" {
+		t.Errorf("Update() ctx tenant = %v, want shop", sr.updateCtx)
+	}
+	if sr.schedules[0].Status != string(enum.CANCELLED) || op.schedule == nil || op.schedule.Status != string(enum.CANCELLED) {
+		t.Errorf("schedule = %s, want CANCELLED", common.CreateJsonString(sr.schedules[0]))
+	}
+}
@@ -0,0 +1,98 @@
+package interactor
+
+import (
+	"coin-api/common"
+	"coin-api/config"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"time"
+)
+
+type SnapshotUseCase struct {
+	op           ports.SnapshotOutputPort
+	snapshotRepo repository.ISnapshotRepository
+	lag          time.Duration
+}
+
+func NewSnapshotUseCase(sop ports.SnapshotOutputPort, sr repository.ISnapshotRepository) ports.SnapshotInputPort {
+	return &SnapshotUseCase{
+		op:           sop,
+		snapshotRepo: sr,
+		lag:          config.LoadConfig().SnapshotInfo.Lag,
+	}
+}
+
+func (s *SnapshotUseCase) TakeSnapshots(ctx context.Context) (int64, error) {
+	// 作成済みの日付の翌日から開始(未作成の場合は最も古い履歴の日付から)
+	last, err := s.snapshotRepo.SelectLastAsOf()
+	if err != nil {
+		return 0, err
+	}
+	if last == nil {
+		first, err := s.snapshotRepo.SelectFirstHistoryTimestamp()
+		if err != nil || first == nil {
+			return 0, err
+		}
+		day := startOfDay(*first)
+		last = &day
+	}
+
+	// 猶予を過ぎて確定した日付まで1日ずつ作成
+	end := startOfDay(time.Now().Add(-s.lag))
+	var total int64
+	for day := last.In(time.Local); day.Before(end); day = day.AddDate(0, 0, 1) {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		n, err := s.snapshotRepo.InsertDailySnapshots(ctx, day, day.AddDate(0, 0, 1))
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+
+	return total, nil
+}
+
+func (s *SnapshotUseCase) VerifySnapshots(form *model.SnapshotVerifyForm) error {
+	// formのバリデーション
+	if err := form.ValidateSnapshotVerifyForm(); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー SnapshotVerifyForm : %s", common.CreateJsonString(&form)))
+		return s.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// 検索条件作成
+	filter := &repository.SnapshotFilter{From: form.FromTime(), To: form.ToTime()}
+	if form.UserId != "" {
+		uid := common.StringToUint(form.UserId)
+		filter.UserId = &uid
+	}
+
+	// 検証対象件数と、履歴から算出した残高と一致しないスナップショットの取得
+	checked, err := s.snapshotRepo.Count(filter)
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return s.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+	mismatches, err := s.snapshotRepo.SelectMismatches(filter)
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return s.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+	if len(mismatches) > 0 {
+		log.Warn().Msg(fmt.Sprintf("スナップショット不整合 件数 : %d", len(mismatches)))
+	}
+
+	return s.op.OutputSnapshotVerification(model.SnapshotVerificationResponseFromDomainModel(checked, mismatches))
+}
+
+// startOfDay サーバーのタイムゾーンでの日付の始まり
+func startOfDay(t time.Time) time.Time {
+	l := t.In(time.Local)
+	return time.Date(l.Year(), l.Month(), l.Day(), 0, 0, 0, 0, time.Local)
+}
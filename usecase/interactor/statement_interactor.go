@@ -0,0 +1,115 @@
+package interactor
+
+import (
+	"coin-api/common"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"context"
+	"errors"
+	"fmt"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+)
+
+// errStatementFailed DBのエラーの代わりに返却するエラー
+var errStatementFailed = errors.New("残高の算出に失敗しました")
+
+type StatementUseCase struct {
+	op       ports.StatementOutputPort
+	coinRepo repository.ICoinRepository
+	userRepo repository.IUserRepository
+}
+
+func NewStatementUseCase(sop ports.StatementOutputPort, cr repository.ICoinRepository, ur repository.IUserRepository) ports.StatementInputPort {
+	return &StatementUseCase{
+		op:       sop,
+		coinRepo: cr,
+		userRepo: ur,
+	}
+}
+
+func (s *StatementUseCase) GetBalanceAt(ctx context.Context, uid string, form *model.BalanceAtForm) error {
+	// uid、formのバリデーション
+	if err := validation.Validate(uid, validation.Required, is.Digit); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー ユーザーID : %s", uid))
+		return s.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+	if err := form.ValidateBalanceAtForm(); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー BalanceAtForm : %s", common.CreateJsonString(&form)))
+		return s.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// 対象ユーザーの存在確認
+	uidUint := common.StringToUint(uid)
+	if _, err := s.userRepo.SelectById(uidUint); err != nil {
+		log.Error().Stack().Err(err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err := fmt.Errorf("ユーザーが存在しません ユーザーID : %d", uidUint)
+			return s.op.OutputError(model.CreateErrorResponse(http.StatusNotFound, err.Error()), err)
+		}
+		return s.op.OutputError(statementErrorResponse(err), err)
+	}
+
+	// 指定日時までの履歴から残高を算出
+	at := form.AtTime(time.Now())
+	balance, err := s.coinRepo.SumAmountByUserIdUntil(uidUint, at)
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return s.op.OutputError(statementErrorResponse(err), err)
+	}
+
+	return s.op.OutputBalanceAt(model.BalanceAtResponseFromDomainModel(uidUint, at, balance, coinOf(ctx)))
+}
+
+func (s *StatementUseCase) GetStatement(ctx context.Context, uid string, form *model.StatementForm) error {
+	// uid、formのバリデーション
+	if err := validation.Validate(uid, validation.Required, is.Digit); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー ユーザーID : %s", uid))
+		return s.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+	if err := form.ValidateStatementForm(); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー StatementForm : %s", common.CreateJsonString(&form)))
+		return s.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// 対象ユーザーの存在確認
+	uidUint := common.StringToUint(uid)
+	if _, err := s.userRepo.SelectById(uidUint); err != nil {
+		log.Error().Stack().Err(err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err := fmt.Errorf("ユーザーが存在しません ユーザーID : %d", uidUint)
+			return s.op.OutputError(model.CreateErrorResponse(http.StatusNotFound, err.Error()), err)
+		}
+		return s.op.OutputError(statementErrorResponse(err), err)
+	}
+
+	// 期首残高(開始日時より前の履歴の合計)の算出
+	from, to := form.FromTime(), form.ToTime(time.Now())
+	opening, err := s.coinRepo.SumAmountByUserIdUntil(uidUint, from.Add(-time.Microsecond))
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return s.op.OutputError(statementErrorResponse(err), err)
+	}
+
+	// 期間内の履歴取得
+	histories, err := s.coinRepo.SelectHistoriesByUserIdBetween(uidUint, from, to)
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return s.op.OutputError(statementErrorResponse(err), err)
+	}
+
+	return s.op.OutputStatement(model.StatementResponseFromDomainModel(uidUint, from, to, opening, histories, coinOf(ctx)))
+}
+
+// statementErrorResponse 残高・明細の算出中のエラーのレスポンス(DBのエラー内容はクライアントへ返却しない)
+func statementErrorResponse(err error) *model.ErrorResponse {
+	if errors.Is(err, repository.ErrUnavailable) {
+		return model.CreateServerErrorResponse(err)
+	}
+	return model.CreateErrorResponse(http.StatusInternalServerError, errStatementFailed.Error())
+}
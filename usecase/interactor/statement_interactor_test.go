@@ -0,0 +1,100 @@
+package interactor
+
+import (
+	models "coin-api/domain/model"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeStatementCoinRepository 集計結果またはエラーを返却するICoinRepository
+type fakeStatementCoinRepository struct {
+	repository.ICoinRepository
+	sum int
+	err error
+}
+
+func (r *fakeStatementCoinRepository) SumAmountByUserIdUntil(uid uint, until time.Time) (int, error) {
+	return r.sum, r.err
+}
+
+func (r *fakeStatementCoinRepository) SelectHistoriesByUserIdBetween(uid uint, from time.Time, to time.Time) ([]models.CoinHistory, error) {
+	return nil, r.err
+}
+
+// fakeStatementOutput 出力した残高・明細とエラーを記録するStatementOutputPort
+type fakeStatementOutput struct {
+	balance   *model.BalanceAtResponse
+	statement *model.StatementResponse
+	err       *model.ErrorResponse
+}
+
+func (o *fakeStatementOutput) OutputBalanceAt(balance *model.BalanceAtResponse) error {
+	o.balance = balance
+	return nil
+}
+
+func (o *fakeStatementOutput) OutputStatement(statement *model.StatementResponse) error {
+	o.statement = statement
+	return nil
+}
+
+func (o *fakeStatementOutput) OutputError(res *model.ErrorResponse, err error) error {
+	o.err = res
+	return err
+}
+
+func TestStatementErrorResponses(t *testing.T) {
+	dbErr := errors.New(`ERROR: relation "coin_histories" does not exist (SQLSTATE 42P01)`)
+	tests := []struct {
+		name     string
+		userErr  error
+		coinErr  error
+		wantCode int
+	}{
+		// 存在しないユーザーは404、DBのエラーは内容を返却せず500、DBへの接続の遮断は503
+		{name: "user not found", userErr: gorm.ErrRecordNotFound, wantCode: http.StatusNotFound},
+		{name: "user db error", userErr: dbErr, wantCode: http.StatusInternalServerError},
+		{name: "coin db error", coinErr: dbErr, wantCode: http.StatusInternalServerError},
+		{name: "db unavailable", coinErr: repository.ErrUnavailable, wantCode: http.StatusServiceUnavailable},
+		{name: "ok", wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		calls := map[string]func(s *StatementUseCase) error{
+			"GetBalanceAt": func(s *StatementUseCase) error {
+				return s.GetBalanceAt(context.Background(), "1", &model.BalanceAtForm{At: "2023-03-01"})
+			},
+			"GetStatement": func(s *StatementUseCase) error {
+				return s.GetStatement(context.Background(), "1", &model.StatementForm{From: "2023-03-01", To: "2023-03-31"})
+			},
+		}
+		for method, call := range calls {
+			op := &fakeStatementOutput{}
+			s := &StatementUseCase{
+				op:       op,
+				coinRepo: &fakeStatementCoinRepository{sum: 150, err: tt.coinErr},
+				userRepo: &fakeUserRepository{tenantId: "default", err: tt.userErr},
+			}
+			err := call(s)
+			if tt.wantCode == http.StatusOK {
+				if err != nil || op.err != nil || (op.balance == nil && op.statement == nil) {
+					t.Errorf("%s %s: error = %v, response = %+v", tt.name, method, err, op.err)
+				}
+				continue
+			}
+			if err == nil || op.err == nil || op.err.ErrorCode != tt.wantCode {
+				t.Errorf("%s %s: error = %v, response = %+v, want %d", tt.name, method, err, op.err, tt.wantCode)
+				continue
+			}
+			if strings.Contains(op.err.Message, "SQLSTATE") || strings.Contains(op.err.Message, gorm.ErrRecordNotFound.Error()) {
+				t.Errorf("%s %s: message = %q, want no database error", tt.name, method, op.err.Message)
+			}
+		}
+	}
+}
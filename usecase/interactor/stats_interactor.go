@@ -0,0 +1,196 @@
+package interactor
+
+import (
+	"coin-api/common"
+	"coin-api/common/enum"
+	"coin-api/config"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"time"
+)
+
+// maxTopLimit ランキングの1回の取得件数上限
+const maxTopLimit = 100
+
+type StatsUseCase struct {
+	op        ports.StatsOutputPort
+	statsRepo repository.IStatsRepository
+	loc       *time.Location
+	lag       time.Duration
+	topLimit  int
+}
+
+func NewStatsUseCase(sop ports.StatsOutputPort, sr repository.IStatsRepository) ports.StatsInputPort {
+	conf := config.LoadConfig().StatsInfo
+
+	// 集計のタイムゾーン(読込できない場合はサーバーのタイムゾーン)
+	loc, err := time.LoadLocation(conf.TimeZone)
+	if err != nil {
+		log.Warn().Msg(fmt.Sprintf("集計のタイムゾーンを読込できません : %s", conf.TimeZone))
+		loc = time.Local
+	}
+
+	return &StatsUseCase{
+		op:        sop,
+		statsRepo: sr,
+		loc:       loc,
+		lag:       conf.RollupLag,
+		topLimit:  conf.TopLimit,
+	}
+}
+
+func (s *StatsUseCase) GetCirculation(ctx context.Context) error {
+	// 全ユーザーの残高合計取得
+	circulation, err := s.statsRepo.SelectCirculation()
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return s.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	return s.op.OutputCirculation(model.CirculationResponseFromDomainModel(circulation, coinOf(ctx)))
+}
+
+func (s *StatsUseCase) GetSupply(ctx context.Context, form *model.StatsForm) error {
+	// formのバリデーション
+	if err := form.ValidateStatsForm(); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー StatsForm : %s", common.CreateJsonString(&form)))
+		return s.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// 追加(発行)・消費(焼却)の時間区切りごとの集計
+	buckets, err := s.statsRepo.SelectOperationBuckets(s.filter(form), string(enum.ADD), string(enum.USE))
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return s.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	// 時間区切りごとに詰め替え(消費は負の値で記録されているため符号を反転)
+	coin := coinOf(ctx)
+	response := make([]*model.SupplyStatsResponse, 0)
+	for _, b := range buckets {
+		if len(response) == 0 || !response[len(response)-1].Bucket.Equal(b.Bucket) {
+			response = append(response, &model.SupplyStatsResponse{Bucket: b.Bucket.In(s.loc), Minted: coin.Decimal(0), Burned: coin.Decimal(0), Net: coin.Decimal(0)})
+		}
+		r := response[len(response)-1]
+		switch enum.Operation(b.Operation) {
+		case enum.ADD:
+			r.Minted = r.Minted.Add(b.Amount)
+		case enum.USE:
+			r.Burned = r.Burned.Add(-b.Amount)
+		}
+		r.Net = r.Net.Add(b.Amount)
+	}
+
+	return s.op.OutputSupply(response)
+}
+
+func (s *StatsUseCase) GetTransferVolume(ctx context.Context, form *model.StatsForm) error {
+	// formのバリデーション
+	if err := form.ValidateStatsForm(); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー StatsForm : %s", common.CreateJsonString(&form)))
+		return s.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// 確定した送金(即時送金の送金側、承認された送金の受取側)の時間区切りごとの集計
+	buckets, err := s.statsRepo.SelectOperationBuckets(s.filter(form), string(enum.SEND), string(enum.RELEASE))
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return s.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	// 時間区切りごとに詰め替え(送金は負の値で記録されているため符号を反転)
+	coin := coinOf(ctx)
+	response := make([]*model.TransferStatsResponse, 0)
+	for _, b := range buckets {
+		if len(response) == 0 || !response[len(response)-1].Bucket.Equal(b.Bucket) {
+			response = append(response, &model.TransferStatsResponse{Bucket: b.Bucket.In(s.loc), Volume: coin.Decimal(0)})
+		}
+		r := response[len(response)-1]
+		if enum.Operation(b.Operation) == enum.SEND {
+			r.Volume = r.Volume.Add(-b.Amount)
+		} else {
+			r.Volume = r.Volume.Add(b.Amount)
+		}
+		r.Count += b.Count
+	}
+
+	return s.op.OutputTransferVolume(response)
+}
+
+func (s *StatsUseCase) GetActiveUsers(ctx context.Context, form *model.StatsForm) error {
+	// formのバリデーション
+	if err := form.ValidateStatsForm(); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー StatsForm : %s", common.CreateJsonString(&form)))
+		return s.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// 時間区切りごとの操作のあったユーザー数
+	buckets, err := s.statsRepo.SelectActiveUsers(s.filter(form))
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return s.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	// response用に詰め替え
+	response := make([]*model.ActiveUsersResponse, 0)
+	for i := range buckets {
+		response = append(response, model.ActiveUsersResponseFromDomainModel(&buckets[i], s.loc))
+	}
+
+	return s.op.OutputActiveUsers(response)
+}
+
+func (s *StatsUseCase) GetTopUsers(ctx context.Context, form *model.TopUsersForm) error {
+	// formのバリデーション
+	if err := form.ValidateTopUsersForm(); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー TopUsersForm : %s", common.CreateJsonString(&form)))
+		return s.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	limit := s.topLimit
+	if form.Limit != "" {
+		limit = int(common.StringToUint(form.Limit))
+		if limit <= 0 || limit > maxTopLimit {
+			limit = maxTopLimit
+		}
+	}
+
+	// 送金側は返金を差し引いた送金額(負の値で記録)、受取側は受取額の合計
+	filter := &repository.StatsFilter{From: form.FromTime(), To: form.ToTime()}
+	sign, operations := -1, []string{string(enum.SEND), string(enum.HOLD), string(enum.REFUND)}
+	if form.Direction == model.TopReceivers {
+		sign, operations = 1, []string{string(enum.RECEIVE), string(enum.RELEASE)}
+	}
+	totals, err := s.statsRepo.SelectTopUsers(filter, sign, limit, operations...)
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return s.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	// response用に詰め替え
+	response := make([]*model.TopUserResponse, 0)
+	for i := range totals {
+		response = append(response, model.TopUserResponseFromDomainModel(&totals[i], coinOf(ctx)))
+	}
+
+	return s.op.OutputTopUsers(response)
+}
+
+func (s *StatsUseCase) RefreshRollups(ctx context.Context) (int64, error) {
+	// コミット前の履歴を取りこぼさないよう直近の履歴は次回以降に集計
+	return s.statsRepo.RefreshRollups(ctx, time.Now().Add(-s.lag))
+}
+
+func (s *StatsUseCase) filter(form *model.StatsForm) *repository.StatsFilter {
+	return &repository.StatsFilter{
+		Interval: form.IntervalOrDefault(),
+		TimeZone: s.loc.String(),
+		From:     form.FromTime(),
+		To:       form.ToTime(),
+	}
+}
@@ -0,0 +1,41 @@
+package interactor
+
+import (
+	"coin-api/common/tenant"
+	"coin-api/config"
+	models "coin-api/domain/model"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"context"
+	"fmt"
+	"net/http"
+)
+
+type TenantUseCase struct {
+	op   ports.TenantOutputPort
+	conf *config.AppConfig
+}
+
+func NewTenantUseCase(top ports.TenantOutputPort) ports.TenantInputPort {
+	return &TenantUseCase{
+		op:   top,
+		conf: config.LoadConfig(),
+	}
+}
+
+func (t *TenantUseCase) GetTenant(ctx context.Context) error {
+	// リクエストのテナントの設定取得
+	id := tenant.FromContext(ctx)
+	info := t.conf.TenantsInfo.Find(id)
+	if info == nil {
+		err := fmt.Errorf("テナントが存在しません テナントID : %s", id)
+		return t.op.OutputError(model.CreateErrorResponse(http.StatusNotFound, err.Error()), err)
+	}
+
+	return t.op.OutputTenant(model.TenantResponseFromConfig(info, t.conf.TransferRuleInfo, model.CoinOf(info.Id)))
+}
+
+// coinOf リクエストのテナントで扱うコイン(ワーカーなどテナント未指定の場合は既定のテナントのコイン)
+func coinOf(ctx context.Context) models.Coin {
+	return model.CoinOf(tenant.FromContext(ctx))
+}
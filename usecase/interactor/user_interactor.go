@@ -2,55 +2,127 @@ package interactor
 
 import (
 	"coin-api/common"
+	"coin-api/common/audit"
+	"coin-api/config"
+	"coin-api/domain/event"
 	models "coin-api/domain/model"
 	"coin-api/domain/repository"
 	"coin-api/usecase/model"
 	"coin-api/usecase/port"
+	"coin-api/usecase/rule"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/go-ozzo/ozzo-validation/is"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/crypto/bcrypt"
 	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	errInvalidCredentials = errors.New("ユーザー名またはパスワードが一致しません")
+	errOldPassword        = errors.New("現在のパスワードが一致しません")
+	errInvalidResetToken  = errors.New("パスワード再設定トークンが無効または期限切れです")
+)
+
+var (
+	// dummyHashOnce 存在しないユーザーのログインでも照合時間を揃えるためのハッシュ
+	dummyHashOnce sync.Once
+	dummyHash     []byte
 )
 
 type UserUseCase struct {
-	op ports.UserOutputPort
-	ur repository.IUserRepository
+	op        ports.UserOutputPort
+	ur        repository.IUserRepository
+	obr       repository.IOutboxRepository
+	tr        repository.ITxRepository
+	prr       repository.IPasswordResetRepository
+	bs        ports.BalanceSubscriber
+	rn        ports.PasswordResetNotifier
+	policy    *rule.PasswordPolicy
+	conf      *config.PasswordInfo
+	keepAlive time.Duration
 }
 
-func NewUserUseCase(uop ports.UserOutputPort, ur repository.IUserRepository) ports.UserInputPort {
+func NewUserUseCase(uop ports.UserOutputPort, ur repository.IUserRepository, obr repository.IOutboxRepository, tr repository.ITxRepository, prr repository.IPasswordResetRepository, bs ports.BalanceSubscriber, rn ports.PasswordResetNotifier) ports.UserInputPort {
+	conf := config.LoadConfig()
 	return &UserUseCase{
-		op: uop,
-		ur: ur,
+		op:        uop,
+		ur:        ur,
+		obr:       obr,
+		tr:        tr,
+		prr:       prr,
+		bs:        bs,
+		rn:        rn,
+		policy:    rule.NewPasswordPolicy(conf.PasswordInfo),
+		conf:      conf.PasswordInfo,
+		keepAlive: conf.StreamInfo.KeepAlive,
 	}
 }
 
-func (u *UserUseCase) RegisterUser(form *model.UserAddForm) error {
+func (u *UserUseCase) RegisterUser(ctx context.Context, form *model.UserAddForm) error {
 	// formのバリデーション
 	if err := form.ValidateUserAddForm(); err != nil {
-		log.Log().Msg(fmt.Sprintf("バリデーションエラー UserAddForm : %s", common.CreateJsonString(&form)))
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー UserAddForm ユーザー名 : %s", form.UserName))
 		log.Error().Stack().Err(err)
 
 		return u.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
 	}
 
+	// パスワードの強度確認
+	if err := u.policy.Check(form.Password); err != nil {
+		return u.outputPolicyViolation(err)
+	}
+
 	// パスワードハッシュ化
-	pwHash, _ := bcrypt.GenerateFromPassword([]byte(form.Password), bcrypt.DefaultCost)
+	pwHash, err := u.hashPassword(form.Password)
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return u.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
 	// Insert対象データ作成
 	target := models.User{
 		Username: form.UserName,
-		Password: string(pwHash),
+		Password: pwHash,
 	}
 
-	// ユーザー登録処理実行
-	user, err := u.ur.Insert(&target)
-	if err != nil {
+	// 同一transaction内でユーザー登録とイベントの追加を実行
+	if _, err := u.tr.DoInTx(ctx, u.InsertUserAndEvent(&target)); err != nil {
+		if errors.Is(err, repository.ErrDuplicateUsername) {
+			// ユーザー名重複の場合は409を返却
+			log.Log().Msg(fmt.Sprintf("ユーザー名重複エラー ユーザー名 : %s", form.UserName))
+			return u.op.OutputError(model.CreateErrorResponse(http.StatusConflict, err.Error()), err)
+		}
 		log.Error().Stack().Err(err)
-		return u.op.OutputError(model.CreateErrorResponse(http.StatusInternalServerError, err.Error()), err)
+		return u.op.OutputError(model.CreateServerErrorResponse(err), err)
 	}
 
-	return u.op.OutputUser(model.UserFromDomainModel(user))
+	return u.op.OutputUser(model.UserFromDomainModel(&target, model.CoinOf(target.TenantId)))
+}
+
+func (u *UserUseCase) InsertUserAndEvent(user *models.User) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		// ユーザー登録
+		if _, err := u.ur.Insert(ctx, user); err != nil {
+			log.Error().Err(err).Send()
+			return nil, err
+		}
+
+		// ユーザー登録イベント追加
+		e := &event.UserCreated{UserId: user.ID, Username: user.Username, OccurredAt: user.CreatedAt}
+		if _, err := u.obr.BatchInsert(ctx, []*models.OutboxEvent{newOutboxEvent(e)}); err != nil {
+			log.Error().Err(err).Send()
+			return nil, err
+		}
+		return nil, nil
+	}
 }
 
 func (u *UserUseCase) GetBalanceByUserId(uid string) error {
@@ -67,8 +139,377 @@ func (u *UserUseCase) GetBalanceByUserId(uid string) error {
 	user, err := u.ur.SelectById(uidUint)
 	if err != nil {
 		log.Error().Stack().Err(err)
-		return u.op.OutputError(model.CreateErrorResponse(http.StatusInternalServerError, err.Error()), err)
+		return u.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	return u.op.OutputUserBalance(model.UserBalanceFromDomainModel(user, model.CoinOf(user.TenantId)))
+}
+
+func (u *UserUseCase) LookupUser(form *model.UserLookupForm) error {
+	// formのバリデーション
+	if err := form.ValidateUserLookupForm(); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー UserLookupForm : %s", common.CreateJsonString(&form)))
+		return u.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// ユーザー名(大文字小文字を区別しない)でユーザー取得
+	user, err := u.ur.SelectByUsername(form.UserName)
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return u.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+	if user == nil {
+		err := fmt.Errorf("ユーザーが存在しません ユーザー名 : %s", form.UserName)
+		return u.op.OutputError(model.CreateErrorResponse(http.StatusNotFound, err.Error()), err)
+	}
+
+	return u.op.OutputUserProfile(model.UserProfileFromDomainModel(user))
+}
+
+func (u *UserUseCase) UpdateUser(ctx context.Context, uid string, form *model.UserUpdateForm) error {
+	// uid・formのバリデーション
+	if err := validation.Validate(uid, validation.Required, is.Digit); err != nil {
+		log.Error().Msg(fmt.Sprintf("バリデーションエラー ユーザーID : %s", uid))
+		return u.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+	if err := form.ValidateUserUpdateForm(); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー UserUpdateForm ユーザーID : %s", uid))
+		return u.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// ユーザー取得処理実行
+	user, err := u.ur.SelectById(common.StringToUint(uid))
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return u.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	// 現在のパスワードを確認(ログインと同様に失敗回数を記録)
+	if res, err := u.verifyPassword(ctx, user, form.OldPassword, errOldPassword); err != nil {
+		return u.op.OutputError(res, err)
+	}
+
+	// 指定された項目のみ変更
+	if form.UserName != "" {
+		user.Username = form.UserName
+	}
+	if form.Password != "" {
+		if err := u.policy.Check(form.Password); err != nil {
+			return u.outputPolicyViolation(err)
+		}
+		pwHash, err := u.hashPassword(form.Password)
+		if err != nil {
+			log.Error().Stack().Err(err)
+			return u.op.OutputError(model.CreateServerErrorResponse(err), err)
+		}
+		user.Password = pwHash
+	}
+
+	// ユーザー名・パスワード更新
+	if _, err := u.tr.DoInTx(ctx, func(ctx context.Context) (interface{}, error) {
+		return u.ur.UpdateProfile(ctx, user)
+	}); err != nil {
+		if errors.Is(err, repository.ErrDuplicateUsername) {
+			// ユーザー名重複の場合は409を返却
+			log.Log().Msg(fmt.Sprintf("ユーザー名重複エラー ユーザー名 : %s", form.UserName))
+			return u.op.OutputError(model.CreateErrorResponse(http.StatusConflict, err.Error()), err)
+		}
+		log.Error().Stack().Err(err)
+		return u.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	return u.op.OutputUserProfile(model.UserProfileFromDomainModel(user))
+}
+
+func (u *UserUseCase) Login(ctx context.Context, form *model.UserLoginForm) error {
+	// formのバリデーション
+	if err := form.ValidateUserLoginForm(); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー UserLoginForm ユーザー名 : %s", form.UserName))
+		return u.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// ユーザー取得処理実行
+	user, err := u.ur.SelectByUsername(form.UserName)
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return u.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+	if user == nil {
+		// 存在しないユーザーの場合もパスワード不一致と区別できないよう照合してから返却
+		dummyHashOnce.Do(func() {
+			dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), u.conf.BcryptCost)
+		})
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(form.Password))
+		log.Log().Msg(fmt.Sprintf("ログイン失敗 ユーザー名 : %s", form.UserName))
+		return u.op.OutputError(model.CreateErrorResponse(http.StatusUnauthorized, errInvalidCredentials.Error()), errInvalidCredentials)
+	}
+
+	// パスワード照合
+	if res, err := u.verifyPassword(ctx, user, form.Password, errInvalidCredentials); err != nil {
+		return u.op.OutputError(res, err)
+	}
+
+	return u.op.OutputUserProfile(model.UserProfileFromDomainModel(user))
+}
+
+func (u *UserUseCase) RequestPasswordReset(ctx context.Context, form *model.PasswordResetRequestForm) error {
+	// formのバリデーション
+	if err := form.ValidatePasswordResetRequestForm(); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー PasswordResetRequestForm : %s", common.CreateJsonString(&form)))
+		return u.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// ユーザー取得処理実行
+	user, err := u.ur.SelectByUsername(form.UserName)
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return u.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+	if user == nil {
+		// ユーザー名の存在を推測されないよう存在する場合と同じレスポンスを返却
+		log.Log().Msg(fmt.Sprintf("パスワード再設定対象のユーザーが存在しません ユーザー名 : %s", form.UserName))
+		return u.op.OutputPasswordResetRequested()
+	}
+
+	// ワンタイムトークン発行(DBにはハッシュのみ保存)
+	token, err := newResetToken()
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return u.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+	now := time.Now()
+	target := &models.PasswordResetToken{
+		UserId:    user.ID,
+		TokenHash: hashResetToken(token),
+		ExpiresAt: now.Add(u.conf.ResetTokenTTL),
+	}
+
+	// 同一transaction内でトークンの登録とイベントの追加を実行(イベントにはトークンを含めない、監査ログには受付のステータスを記録)
+	audit.SetStatus(ctx, http.StatusAccepted)
+	e := &event.PasswordResetRequested{UserId: user.ID, Username: user.Username, ExpiresAt: target.ExpiresAt, OccurredAt: now}
+	if _, err := u.tr.DoInTx(ctx, func(ctx context.Context) (interface{}, error) {
+		if _, err := u.prr.Insert(ctx, target); err != nil {
+			return nil, err
+		}
+		return u.obr.BatchInsert(ctx, []*models.OutboxEvent{newOutboxEvent(e)})
+	}); err != nil {
+		log.Error().Stack().Err(err)
+		return u.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	// コミット後にトークンを通知(ユーザーの存在を推測されないよう失敗時もレスポンスは同じ、再度の発行で再通知)
+	n := &ports.PasswordResetNotification{UserId: user.ID, Username: user.Username, Token: token, ExpiresAt: target.ExpiresAt}
+	if err := u.rn.NotifyPasswordReset(ctx, n); err != nil {
+		log.Error().Err(err).Msg(fmt.Sprintf("パスワード再設定の通知でエラー発生 ユーザーID : %d", user.ID))
+	}
+
+	return u.op.OutputPasswordResetRequested()
+}
+
+func (u *UserUseCase) ResetPassword(ctx context.Context, form *model.PasswordResetConfirmForm) error {
+	// formのバリデーション
+	if err := form.ValidatePasswordResetConfirmForm(); err != nil {
+		log.Log().Msg("バリデーションエラー PasswordResetConfirmForm")
+		return u.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// パスワードの強度確認
+	if err := u.policy.Check(form.Password); err != nil {
+		return u.outputPolicyViolation(err)
+	}
+
+	// パスワードハッシュ化
+	pwHash, err := u.hashPassword(form.Password)
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return u.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	// 同一transaction内でトークンの確認・パスワード更新・ロック解除・トークンの使用済み更新を実行
+	v, err := u.tr.DoInTx(ctx, u.ResetPasswordByToken(hashResetToken(form.Token), pwHash, time.Now()))
+	if err != nil {
+		if errors.Is(err, errInvalidResetToken) {
+			log.Log().Msg("パスワード再設定トークンが無効")
+			return u.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, errInvalidResetToken.Error()), err)
+		}
+		log.Error().Stack().Err(err)
+		return u.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	return u.op.OutputUserProfile(model.UserProfileFromDomainModel(v.(*models.User)))
+}
+
+func (u *UserUseCase) ResetPasswordByToken(tokenHash string, pwHash string, now time.Time) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		// 未使用かつ期限内のトークン取得
+		token, err := u.prr.SelectValidByHash(ctx, tokenHash, now)
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, errInvalidResetToken
+		}
+
+		// パスワード更新とロック解除
+		user, err := u.ur.SelectById(token.UserId)
+		if err != nil {
+			return nil, err
+		}
+		user.Password = pwHash
+		if _, err := u.ur.UpdateProfile(ctx, user); err != nil {
+			return nil, err
+		}
+		user.FailedLogins = 0
+		user.LockedUntil = nil
+		if _, err := u.ur.UpdateLoginState(ctx, user); err != nil {
+			return nil, err
+		}
+
+		// 発行済みのトークンをすべて無効化
+		if _, err := u.prr.MarkUsedByUserId(ctx, user.ID, now); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+}
+
+// verifyPassword ロック状態を確認してパスワードを照合(失敗時はエラーレスポンスを返却)
+// 失敗回数が上限に達した場合はロックし、成功時は失敗回数のリセットと設定変更後のコストでの再ハッシュ化を行う
+func (u *UserUseCase) verifyPassword(ctx context.Context, user *models.User, password string, mismatch error) (*model.ErrorResponse, error) {
+	now := time.Now()
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		err := fmt.Errorf("ログイン失敗回数が上限に達したためロックされています 解除日時 : %s", user.LockedUntil.Format(time.RFC3339))
+		log.Log().Msg(fmt.Sprintf("ロック中のユーザー ユーザーID : %d", user.ID))
+		return model.CreateErrorResponse(http.StatusLocked, err.Error()), err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		log.Log().Msg(fmt.Sprintf("パスワード不一致 ユーザーID : %d", user.ID))
+		if u.conf.MaxFailedLogins <= 0 {
+			return model.CreateErrorResponse(http.StatusUnauthorized, mismatch.Error()), mismatch
+		}
+
+		// 失敗回数を加算し、上限に達した場合はロック
+		count, err := u.ur.IncrementFailedLogins(ctx, user.ID)
+		if err != nil {
+			log.Error().Stack().Err(err)
+			return model.CreateServerErrorResponse(err), err
+		}
+		if count >= u.conf.MaxFailedLogins {
+			until := now.Add(u.conf.LockoutDuration)
+			user.FailedLogins = 0
+			user.LockedUntil = &until
+			if _, err := u.ur.UpdateLoginState(ctx, user); err != nil {
+				log.Error().Stack().Err(err)
+				return model.CreateServerErrorResponse(err), err
+			}
+			log.Warn().Msg(fmt.Sprintf("ログイン失敗回数が上限に達したためロック ユーザーID : %d 解除日時 : %s", user.ID, until.Format(time.RFC3339)))
+		}
+		return model.CreateErrorResponse(http.StatusUnauthorized, mismatch.Error()), mismatch
+	}
+
+	// 失敗回数・ロック期限のリセット
+	if user.FailedLogins > 0 || user.LockedUntil != nil {
+		user.FailedLogins = 0
+		user.LockedUntil = nil
+		if _, err := u.ur.UpdateLoginState(ctx, user); err != nil {
+			log.Error().Stack().Err(err)
+			return model.CreateServerErrorResponse(err), err
+		}
+	}
+
+	// コストの設定が変更されている場合は再ハッシュ化(失敗しても照合結果には影響させない)
+	if cost, err := bcrypt.Cost([]byte(user.Password)); err == nil && cost != u.conf.BcryptCost {
+		pwHash, err := u.hashPassword(password)
+		if err == nil {
+			user.Password = pwHash
+			_, err = u.ur.UpdateProfile(ctx, user)
+		}
+		if err != nil {
+			log.Warn().Msg(fmt.Sprintf("パスワードの再ハッシュ化に失敗 ユーザーID : %d", user.ID))
+		} else {
+			log.Info().Msg(fmt.Sprintf("パスワードを再ハッシュ化 ユーザーID : %d コスト : %d -> %d", user.ID, cost, u.conf.BcryptCost))
+		}
+	}
+	return nil, nil
+}
+
+// hashPassword 設定のコストでbcryptのハッシュを生成
+func (u *UserUseCase) hashPassword(password string) (string, error) {
+	pwHash, err := bcrypt.GenerateFromPassword([]byte(password), u.conf.BcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(pwHash), nil
+}
+
+// outputPolicyViolation パスワードの強度要件を満たさない場合は400を返却
+func (u *UserUseCase) outputPolicyViolation(err error) error {
+	message := err.Error()
+	var violation *rule.PasswordViolation
+	if errors.As(err, &violation) {
+		message = violation.Message
+	}
+	log.Log().Msg(fmt.Sprintf("パスワードの強度要件エラー : %s", message))
+	return u.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, message), err)
+}
+
+// newResetToken パスワード再設定用のランダムなトークンを生成
+func newResetToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashResetToken DBに保存するトークンのハッシュ
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (u *UserUseCase) StreamEvents(ctx context.Context, uid string) error {
+	// uidのバリデーション
+	if err := validation.Validate(uid, validation.Required, is.Digit); err != nil {
+		log.Error().Msg(fmt.Sprintf("バリデーションエラー ユーザーID : %s", uid))
+		return u.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// 取りこぼし防止のため現在残高の取得前に購読開始
+	uidUint := common.StringToUint(uid)
+	events, cancel := u.bs.Subscribe(uidUint)
+	defer cancel()
+
+	// 現在残高を初回イベントとして送信
+	user, err := u.ur.SelectById(uidUint)
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return u.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+	coin := model.CoinOf(user.TenantId)
+	if err := u.op.OutputBalanceEvent(model.BalanceEventFromDomainModel(user, coin)); err != nil {
+		return err
 	}
 
-	return u.op.OutputUserBalance(model.UserBalanceFromDomainModel(user))
+	// 切断されるまで残高変更を送信
+	ticker := time.NewTicker(u.keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := u.op.OutputBalanceEvent(model.BalanceEventFromDomainEvent(e, coin)); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			if err := u.op.OutputKeepAlive(); err != nil {
+				return err
+			}
+		}
+	}
 }
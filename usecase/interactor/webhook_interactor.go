@@ -0,0 +1,300 @@
+package interactor
+
+import (
+	"coin-api/common"
+	"coin-api/common/enum"
+	"coin-api/config"
+	"coin-api/domain/event"
+	models "coin-api/domain/model"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"context"
+	"encoding/json"
+	"fmt"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type WebhookUseCase struct {
+	op          ports.WebhookOutputPort
+	webhookRepo repository.IWebhookRepository
+	outboxRepo  repository.IOutboxRepository
+	tranRepo    repository.ITxRepository
+	sender      ports.WebhookSender
+	conf        *config.WebhookInfo
+}
+
+func NewWebhookUseCase(wop ports.WebhookOutputPort, wr repository.IWebhookRepository, obr repository.IOutboxRepository, tr repository.ITxRepository, sender ports.WebhookSender) ports.WebhookInputPort {
+	return &WebhookUseCase{
+		op:          wop,
+		webhookRepo: wr,
+		outboxRepo:  obr,
+		tranRepo:    tr,
+		sender:      sender,
+		conf:        config.LoadConfig().WebhookInfo,
+	}
+}
+
+func (w *WebhookUseCase) CreateSubscription(form *model.WebhookAddForm) error {
+	// formのバリデーション
+	if err := form.ValidateWebhookAddForm(); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー WebhookAddForm URL : %s", form.URL))
+		return w.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// Insert対象データ作成
+	target := models.WebhookSubscription{
+		URL:        form.URL,
+		Secret:     form.Secret,
+		EventTypes: strings.Join(form.EventTypes, ","),
+		Active:     true,
+	}
+
+	// 購読登録処理実行
+	subscription, err := w.webhookRepo.InsertSubscription(&target)
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return w.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	return w.op.OutputSubscription(model.WebhookSubscriptionResponseFromDomainModel(subscription))
+}
+
+func (w *WebhookUseCase) SelectSubscriptions() error {
+	// 全購読取得
+	subscriptions, err := w.webhookRepo.SelectSubscriptions()
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return w.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	// response用に詰め替え
+	response := make([]*model.WebhookSubscriptionResponse, 0)
+	for i := range subscriptions {
+		response = append(response, model.WebhookSubscriptionResponseFromDomainModel(&subscriptions[i]))
+	}
+
+	return w.op.OutputSubscriptions(response)
+}
+
+func (w *WebhookUseCase) DeleteSubscription(id string) error {
+	// idのバリデーション
+	if err := validation.Validate(id, validation.Required, is.Digit); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー 購読ID : %s", id))
+		return w.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// 購読取得
+	subscription, err := w.webhookRepo.SelectSubscriptionById(common.StringToUint(id))
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return w.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	// 配信履歴を残すため無効化のみ行う
+	subscription.Active = false
+	if _, err := w.webhookRepo.UpdateSubscription(subscription); err != nil {
+		log.Error().Stack().Err(err)
+		return w.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	return w.op.OutputSubscription(model.WebhookSubscriptionResponseFromDomainModel(subscription))
+}
+
+func (w *WebhookUseCase) SelectDeliveriesBySubscriptionId(id string) error {
+	// idのバリデーション
+	if err := validation.Validate(id, validation.Required, is.Digit); err != nil {
+		log.Log().Msg(fmt.Sprintf("バリデーションエラー 購読ID : %s", id))
+		return w.op.OutputError(model.CreateErrorResponse(http.StatusBadRequest, err.Error()), err)
+	}
+
+	// 購読に紐づく配信履歴取得
+	deliveries, err := w.webhookRepo.SelectDeliveriesBySubscriptionId(common.StringToUint(id))
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return w.op.OutputError(model.CreateServerErrorResponse(err), err)
+	}
+
+	// response用に詰め替え
+	response := make([]*model.WebhookDeliveryResponse, 0)
+	for i := range deliveries {
+		response = append(response, model.WebhookDeliveryResponseFromDomainModel(&deliveries[i]))
+	}
+
+	return w.op.OutputDeliveries(response)
+}
+
+func (w *WebhookUseCase) DispatchEvents(ctx context.Context) (int, error) {
+	// 未配信イベント取得
+	events, err := w.outboxRepo.SelectUndispatched(w.conf.BatchSize)
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return 0, err
+	}
+
+	dispatched := 0
+	now := time.Now()
+	for i := range events {
+		ev := &events[i]
+
+		// 内部イベントは配信を作成せず配信済みとする
+		if event.IsInternal(ev.EventType) {
+			if _, err := w.tranRepo.DoInTx(ctx, w.CreateDeliveriesAndMarkDispatched(ev, nil, now)); err != nil {
+				log.Error().Stack().Err(err).Send()
+				return dispatched, err
+			}
+			dispatched++
+			continue
+		}
+
+		// ドメインイベントをWebhookのイベントへ変換
+		e, err := event.Decode(ev.EventType, []byte(ev.Payload))
+		if err != nil {
+			log.Error().Stack().Err(err).Send()
+			return dispatched, err
+		}
+
+		// 購読中の送信先ごとに配信を作成
+		deliveries := make([]*models.WebhookDelivery, 0)
+		for _, we := range model.WebhookEventsFromDomainEvent(e, model.CoinOf(ev.TenantId)) {
+			subscriptions, err := w.webhookRepo.SelectActiveSubscriptionsByEventType(ev.TenantId, we.EventType)
+			if err != nil {
+				log.Error().Stack().Err(err).Send()
+				return dispatched, err
+			}
+			payload, err := json.Marshal(&model.WebhookPayload{
+				EventId:    ev.ID,
+				EventType:  we.EventType,
+				OccurredAt: ev.CreatedAt,
+				Data:       json.RawMessage(common.CreateJsonString(we.Data)),
+			})
+			if err != nil {
+				log.Error().Stack().Err(err).Send()
+				return dispatched, err
+			}
+			for _, s := range subscriptions {
+				deliveries = append(deliveries, &models.WebhookDelivery{
+					SubscriptionId: s.ID,
+					EventId:        ev.ID,
+					EventType:      we.EventType,
+					Payload:        string(payload),
+					Status:         string(enum.DELIVERY_PENDING),
+					NextAttemptAt:  now,
+				})
+			}
+		}
+
+		// 同一transaction内で配信の作成とイベントの配信済み更新を実行
+		if _, err := w.tranRepo.DoInTx(ctx, w.CreateDeliveriesAndMarkDispatched(ev, deliveries, now)); err != nil {
+			log.Error().Stack().Err(err).Send()
+			return dispatched, err
+		}
+		dispatched++
+	}
+
+	return dispatched, nil
+}
+
+func (w *WebhookUseCase) CreateDeliveriesAndMarkDispatched(ev *models.OutboxEvent, deliveries []*models.WebhookDelivery, now time.Time) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		// 配信一括登録(購読がない場合は登録しない)
+		if len(deliveries) > 0 {
+			if _, err := w.webhookRepo.BatchInsertDeliveries(ctx, deliveries); err != nil {
+				log.Error().Err(err).Send()
+				return nil, err
+			}
+		}
+
+		// イベント配信済み更新
+		if _, err := w.outboxRepo.MarkDispatched(ctx, ev, now); err != nil {
+			log.Error().Err(err).Send()
+			return nil, err
+		}
+		return nil, nil
+	}
+}
+
+func (w *WebhookUseCase) DeliverPending(ctx context.Context) (int, error) {
+	// 送信予定日時を過ぎた配信取得
+	now := time.Now()
+	deliveries, err := w.webhookRepo.SelectDueDeliveries(now, w.conf.BatchSize)
+	if err != nil {
+		log.Error().Stack().Err(err)
+		return 0, err
+	}
+
+	delivered := 0
+	subscriptions := make(map[uint]*models.WebhookSubscription)
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		// 送信先の取得
+		subscription, ok := subscriptions[delivery.SubscriptionId]
+		if !ok {
+			subscription, err = w.webhookRepo.SelectSubscriptionById(delivery.SubscriptionId)
+			if err != nil {
+				log.Error().Stack().Err(err).Send()
+				continue
+			}
+			subscriptions[delivery.SubscriptionId] = subscription
+		}
+
+		// 無効化された購読への配信は失敗として終了
+		if !subscription.Active {
+			delivery.Status = string(enum.DELIVERY_FAILED)
+			delivery.LastError = "購読が無効化されています"
+			if _, err := w.webhookRepo.UpdateDelivery(delivery); err != nil {
+				log.Error().Stack().Err(err).Send()
+			}
+			continue
+		}
+
+		// 署名付きで送信
+		status, sendErr := w.sender.Send(ctx, &ports.WebhookRequest{
+			URL:        subscription.URL,
+			Secret:     subscription.Secret,
+			EventType:  delivery.EventType,
+			DeliveryId: delivery.ID,
+			Payload:    []byte(delivery.Payload),
+		})
+		delivery.Attempts++
+		delivery.ResponseStatus = status
+		if sendErr == nil {
+			delivery.Status = string(enum.DELIVERY_SUCCEEDED)
+			delivery.LastError = ""
+			delivered++
+		} else if delivery.Attempts >= w.conf.MaxAttempts {
+			// 最大試行回数に達した場合は失敗として終了
+			delivery.Status = string(enum.DELIVERY_FAILED)
+			delivery.LastError = sendErr.Error()
+			log.Warn().Msg(fmt.Sprintf("Webhook配信失敗 配信ID : %d 理由 : %s", delivery.ID, sendErr.Error()))
+		} else {
+			// 指数バックオフで再送を予約
+			delivery.LastError = sendErr.Error()
+			delivery.NextAttemptAt = now.Add(w.backoff(delivery.Attempts))
+		}
+
+		if _, err := w.webhookRepo.UpdateDelivery(delivery); err != nil {
+			log.Error().Stack().Err(err).Send()
+		}
+	}
+
+	return delivered, nil
+}
+
+// backoff 試行回数に応じた再送待ち時間(基準時間 * 2^(試行回数-1)、上限あり)
+func (w *WebhookUseCase) backoff(attempts int) time.Duration {
+	d := w.conf.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= w.conf.MaxBackoff {
+			return w.conf.MaxBackoff
+		}
+	}
+	return d
+}
@@ -0,0 +1,328 @@
+package interactor
+
+import (
+	"coin-api/adapters/gateways/webhook"
+	"coin-api/common"
+	"coin-api/common/enum"
+	"coin-api/config"
+	"coin-api/domain/event"
+	models "coin-api/domain/model"
+	"coin-api/domain/repository"
+	"coin-api/usecase/model"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTxRepository fをそのまま実行するITxRepository
+type fakeTxRepository struct{}
+
+func (fakeTxRepository) DoInTx(ctx context.Context, f func(ctx context.Context) (interface{}, error), opts ...repository.TxOption) (interface{}, error) {
+	return f(ctx)
+}
+
+// fakeOutboxRepository メモリ上のoutbox
+type fakeOutboxRepository struct {
+	events []*models.OutboxEvent
+}
+
+func (r *fakeOutboxRepository) SelectUndispatched(limit int) ([]models.OutboxEvent, error) {
+	events := make([]models.OutboxEvent, 0)
+	for _, e := range r.events {
+		if e.DispatchedAt == nil && len(events) < limit {
+			events = append(events, *e)
+		}
+	}
+	return events, nil
+}
+
+func (r *fakeOutboxRepository) SelectUnpublished(limit int) ([]models.OutboxEvent, error) {
+	events := make([]models.OutboxEvent, 0)
+	for _, e := range r.events {
+		if e.PublishedAt == nil && len(events) < limit {
+			events = append(events, *e)
+		}
+	}
+	return events, nil
+}
+
+func (r *fakeOutboxRepository) BatchInsert(ctx context.Context, events []*models.OutboxEvent) ([]*models.OutboxEvent, error) {
+	for _, e := range events {
+		e.ID = uint(len(r.events) + 1)
+		if e.TenantId == "" {
+			e.TenantId = "default"
+		}
+		e.CreatedAt = time.Now()
+		r.events = append(r.events, e)
+	}
+	return events, nil
+}
+
+func (r *fakeOutboxRepository) MarkDispatched(ctx context.Context, ev *models.OutboxEvent, dispatchedAt time.Time) (*models.OutboxEvent, error) {
+	r.events[ev.ID-1].DispatchedAt = &dispatchedAt
+	return ev, nil
+}
+
+func (r *fakeOutboxRepository) MarkPublished(ev *models.OutboxEvent, publishedAt time.Time) (*models.OutboxEvent, error) {
+	r.events[ev.ID-1].PublishedAt = &publishedAt
+	return ev, nil
+}
+
+// fakeWebhookRepository メモリ上の購読・配信
+type fakeWebhookRepository struct {
+	subscriptions []*models.WebhookSubscription
+	deliveries    []*models.WebhookDelivery
+}
+
+func (r *fakeWebhookRepository) SelectSubscriptionById(id uint) (*models.WebhookSubscription, error) {
+	s := *r.subscriptions[id-1]
+	return &s, nil
+}
+
+func (r *fakeWebhookRepository) SelectSubscriptions() ([]models.WebhookSubscription, error) {
+	subscriptions := make([]models.WebhookSubscription, 0)
+	for _, s := range r.subscriptions {
+		subscriptions = append(subscriptions, *s)
+	}
+	return subscriptions, nil
+}
+
+func (r *fakeWebhookRepository) SelectActiveSubscriptionsByEventType(tenantId string, eventType string) ([]models.WebhookSubscription, error) {
+	subscriptions := make([]models.WebhookSubscription, 0)
+	for _, s := range r.subscriptions {
+		if s.Active && s.TenantId == tenantId && strings.Contains(","+s.EventTypes+",", ","+eventType+",") {
+			subscriptions = append(subscriptions, *s)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (r *fakeWebhookRepository) InsertSubscription(s *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	s.ID = uint(len(r.subscriptions) + 1)
+	if s.TenantId == "" {
+		s.TenantId = "default"
+	}
+	r.subscriptions = append(r.subscriptions, s)
+	return s, nil
+}
+
+func (r *fakeWebhookRepository) UpdateSubscription(s *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	r.subscriptions[s.ID-1] = s
+	return s, nil
+}
+
+func (r *fakeWebhookRepository) SelectDeliveriesBySubscriptionId(id uint) ([]models.WebhookDelivery, error) {
+	deliveries := make([]models.WebhookDelivery, 0)
+	for _, d := range r.deliveries {
+		if d.SubscriptionId == id {
+			deliveries = append(deliveries, *d)
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *fakeWebhookRepository) SelectDueDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	deliveries := make([]models.WebhookDelivery, 0)
+	for _, d := range r.deliveries {
+		if d.Status == string(enum.DELIVERY_PENDING) && !d.NextAttemptAt.After(now) && len(deliveries) < limit {
+			deliveries = append(deliveries, *d)
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *fakeWebhookRepository) BatchInsertDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) ([]*models.WebhookDelivery, error) {
+	for _, d := range deliveries {
+		d.ID = uint(len(r.deliveries) + 1)
+		r.deliveries = append(r.deliveries, d)
+	}
+	return deliveries, nil
+}
+
+func (r *fakeWebhookRepository) UpdateDelivery(d *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	updated := *d
+	r.deliveries[d.ID-1] = &updated
+	return d, nil
+}
+
+// webhookReceiver 署名を検証し、指定した順にステータスを返却するWebhookの受信先
+type webhookReceiver struct {
+	t        *testing.T
+	secret   string
+	statuses []int
+
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	want := "sha256=" + webhook.Sign(rc.secret, r.Header.Get("X-Coin-Timestamp"), body)
+	if got := r.Header.Get("X-Coin-Signature"); got != want {
+		rc.t.Errorf("X-Coin-Signature = %s, want %s", got, want)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	status := http.StatusOK
+	if n := len(rc.requests); n <= len(rc.statuses) {
+		status = rc.statuses[n-1]
+	}
+	w.WriteHeader(status)
+}
+
+func newWebhookTestUseCase(t *testing.T, rc *webhookReceiver, maxAttempts int) (*WebhookUseCase, *fakeWebhookRepository, *fakeOutboxRepository, *httptest.Server) {
+	t.Helper()
+	server := httptest.NewServer(rc)
+	t.Cleanup(server.Close)
+
+	wr := &fakeWebhookRepository{}
+	obr := &fakeOutboxRepository{}
+	w := &WebhookUseCase{
+		webhookRepo: wr,
+		outboxRepo:  obr,
+		tranRepo:    fakeTxRepository{},
+		sender:      webhook.NewHTTPSender(time.Second),
+		conf: &config.WebhookInfo{
+			BatchSize:   10,
+			MaxAttempts: maxAttempts,
+			BaseBackoff: time.Minute,
+			MaxBackoff:  time.Hour,
+		},
+	}
+	return w, wr, obr, server
+}
+
+func TestWebhookDispatchAndRetry(t *testing.T) {
+	rc := &webhookReceiver{t: t, secret: "0123456789abcdef", statuses: []int{http.StatusInternalServerError}}
+	w, wr, obr, server := newWebhookTestUseCase(t, rc, 3)
+	ctx := context.Background()
+
+	// 受取のみ購読(送金者側のcoin.sentは配信しない)
+	_, _ = wr.InsertSubscription(&models.WebhookSubscription{URL: server.URL, Secret: rc.secret, EventTypes: string(enum.COIN_RECEIVED), Active: true})
+	_, _ = obr.BatchInsert(ctx, []*models.OutboxEvent{newOutboxEvent(&event.CoinTransferred{Sender: 1, Receiver: 2, Amount: 150, SenderBalance: 50, ReceiverBalance: 300})})
+
+	// 購読中のイベント種別のみ配信を作成
+	if n, err := w.DispatchEvents(ctx); err != nil || n != 1 {
+		t.Fatalf("DispatchEvents() = %d, %v, want 1, nil", n, err)
+	}
+	if obr.events[0].DispatchedAt == nil {
+		t.Error("event is not marked dispatched")
+	}
+	if len(wr.deliveries) != 1 || wr.deliveries[0].EventType != string(enum.COIN_RECEIVED) {
+		t.Fatalf("deliveries = %s, want one coin.received", common.CreateJsonString(wr.deliveries))
+	}
+
+	// 1回目は5xxのため指数バックオフで再送を予約
+	if n, err := w.DeliverPending(ctx); err != nil || n != 0 {
+		t.Fatalf("DeliverPending() = %d, %v, want 0, nil", n, err)
+	}
+	d := wr.deliveries[0]
+	if d.Status != string(enum.DELIVERY_PENDING) || d.Attempts != 1 || d.ResponseStatus != http.StatusInternalServerError || d.LastError == "" {
+		t.Fatalf("delivery after failure = %s", common.CreateJsonString(d))
+	}
+	if wait := time.Until(d.NextAttemptAt); wait < 50*time.Second || wait > time.Minute {
+		t.Errorf("next attempt in %s, want about %s", wait, time.Minute)
+	}
+
+	// 再送予定前は送信しない
+	if _, err := w.DeliverPending(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(rc.requests) != 1 {
+		t.Fatalf("requests = %d before backoff elapsed, want 1", len(rc.requests))
+	}
+
+	// 再送予定日時の経過後に再送し、2xxで成功
+	d.NextAttemptAt = time.Now().Add(-time.Second)
+	if n, err := w.DeliverPending(ctx); err != nil || n != 1 {
+		t.Fatalf("DeliverPending() = %d, %v, want 1, nil", n, err)
+	}
+	d = wr.deliveries[0]
+	if d.Status != string(enum.DELIVERY_SUCCEEDED) || d.Attempts != 2 || d.LastError != "" {
+		t.Fatalf("delivery after retry = %s", common.CreateJsonString(d))
+	}
+
+	// 再送時も同じ配信ID・ペイロードで送信
+	if len(rc.requests) != 2 {
+		t.Fatalf("requests = %d, want 2", len(rc.requests))
+	}
+	for i, r := range rc.requests {
+		if r.Header.Get("X-Coin-Event") != string(enum.COIN_RECEIVED) || r.Header.Get("X-Coin-Delivery") != "1" {
+			t.Errorf("request %d headers = %v", i, r.Header)
+		}
+	}
+	var payload model.WebhookPayload
+	if err := json.Unmarshal(rc.bodies[1], &payload); err != nil {
+		t.Fatal(err)
+	}
+	sender := uint(1)
+	coin := model.CoinOf("default")
+	want := common.CreateJsonString(&model.CoinEventData{UserId: 2, Operation: string(enum.RECEIVE), Amount: coin.Decimal(150), Balance: coin.Decimal(300), Counterparty: &sender})
+	if payload.EventId != 1 || payload.EventType != string(enum.COIN_RECEIVED) || string(payload.Data) != want {
+		t.Errorf("payload = %s, want data %s", rc.bodies[1], want)
+	}
+}
+
+func TestWebhookDeliveryFailsAfterMaxAttempts(t *testing.T) {
+	rc := &webhookReceiver{t: t, secret: "0123456789abcdef", statuses: []int{http.StatusBadGateway, http.StatusBadGateway}}
+	w, wr, _, server := newWebhookTestUseCase(t, rc, 2)
+	ctx := context.Background()
+
+	_, _ = wr.InsertSubscription(&models.WebhookSubscription{URL: server.URL, Secret: rc.secret, EventTypes: string(enum.COIN_ADDED), Active: true})
+	_, _ = wr.BatchInsertDeliveries(ctx, []*models.WebhookDelivery{{SubscriptionId: 1, EventId: 1, EventType: string(enum.COIN_ADDED), Payload: `{}`, Status: string(enum.DELIVERY_PENDING), NextAttemptAt: time.Now()}})
+
+	for i := 0; i < 2; i++ {
+		wr.deliveries[0].NextAttemptAt = time.Now().Add(-time.Second)
+		if _, err := w.DeliverPending(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d := wr.deliveries[0]
+	if d.Status != string(enum.DELIVERY_FAILED) || d.Attempts != 2 || d.ResponseStatus != http.StatusBadGateway {
+		t.Fatalf("delivery = %s, want FAILED after 2 attempts", common.CreateJsonString(d))
+	}
+
+	// 失敗として終了した配信は再送しない
+	if _, err := w.DeliverPending(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(rc.requests) != 2 {
+		t.Errorf("requests = %d, want 2", len(rc.requests))
+	}
+}
+
+func TestWebhookSignatureRejectsOtherSecret(t *testing.T) {
+	payload := []byte(`{"event_id":1}`)
+	if webhook.Sign("0123456789abcdef", "1700000000", payload) == webhook.Sign("fedcba9876543210", "1700000000", payload) {
+		t.Error("signatures with different secrets must differ")
+	}
+	if webhook.Sign("0123456789abcdef", "1700000000", payload) == webhook.Sign("0123456789abcdef", "1700000001", payload) {
+		t.Error("signatures with different timestamps must differ")
+	}
+}
+
+func TestWebhookDispatchSkipsInternalEvents(t *testing.T) {
+	rc := &webhookReceiver{t: t, secret: "0123456789abcdef"}
+	w, wr, obr, server := newWebhookTestUseCase(t, rc, 3)
+	ctx := context.Background()
+
+	_, _ = wr.InsertSubscription(&models.WebhookSubscription{URL: server.URL, Secret: rc.secret, EventTypes: string(enum.USER_CREATED), Active: true})
+	_, _ = obr.BatchInsert(ctx, []*models.OutboxEvent{newOutboxEvent(&event.PasswordResetRequested{UserId: 1, Username: "alice"})})
+
+	if n, err := w.DispatchEvents(ctx); err != nil || n != 1 {
+		t.Fatalf("DispatchEvents() = %d, %v, want 1, nil", n, err)
+	}
+	if obr.events[0].DispatchedAt == nil || len(wr.deliveries) != 0 {
+		t.Errorf("internal event: dispatched_at = %v, deliveries = %d, want marked without deliveries", obr.events[0].DispatchedAt, len(wr.deliveries))
+	}
+}
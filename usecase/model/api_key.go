@@ -0,0 +1,87 @@
+package model
+
+import (
+	"coin-api/common/enum"
+	"coin-api/domain/model"
+	"errors"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+	"strings"
+	"time"
+)
+
+type ApiKeyAddForm struct {
+	Name   string        `json:"name"`
+	Scopes []string      `json:"scopes"`
+	UserId NumericString `json:"userid"`
+}
+
+type ApiKeyResponse struct {
+	ApiKeyId   uint       `json:"api_key_id"`
+	TenantId   string     `json:"tenant_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	UserId     *uint      `json:"userid,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// ApiKeyCreatedResponse 発行時のみAPIキー自体を返却
+type ApiKeyCreatedResponse struct {
+	ApiKeyResponse
+	Key string `json:"key"`
+}
+
+var apiKeyScopes = []interface{}{
+	string(enum.SCOPE_COIN_ADD),
+	string(enum.SCOPE_COIN_USE),
+	string(enum.SCOPE_COIN_SEND),
+	string(enum.SCOPE_COIN_READ),
+	string(enum.SCOPE_USER_READ),
+	string(enum.SCOPE_ADMIN),
+}
+
+// errAdminKeyUser 管理者スコープのAPIキーをユーザーに発行しようとした場合のエラー
+var errAdminKeyUser = errors.New("管理者スコープのAPIキーはユーザーに発行できません")
+
+// adminKeyUserRule 管理者スコープを含む場合は発行対象のユーザーを指定していないこと
+func adminKeyUserRule(scopes []string) validation.RuleFunc {
+	return func(v interface{}) error {
+		s, err := validation.EnsureString(v)
+		if err != nil {
+			return err
+		}
+		for _, scope := range scopes {
+			if s != "" && scope == string(enum.SCOPE_ADMIN) {
+				return errAdminKeyUser
+			}
+		}
+		return nil
+	}
+}
+
+func (a ApiKeyAddForm) ValidateApiKeyAddForm() error {
+	return validation.ValidateStruct(&a,
+		validation.Field(&a.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&a.Scopes, validation.Required, validation.Each(validation.In(apiKeyScopes...))),
+		validation.Field(&a.UserId, validation.By(adminKeyUserRule(a.Scopes)), is.Digit, idRule),
+	)
+}
+
+func ApiKeyResponseFromDomainModel(k *model.ApiKey) *ApiKeyResponse {
+	h := &ApiKeyResponse{
+		ApiKeyId:   k.ID,
+		TenantId:   k.TenantId,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     strings.Split(k.Scopes, ","),
+		UserId:     k.UserId,
+		CreatedAt:  k.CreatedAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+	}
+
+	return h
+}
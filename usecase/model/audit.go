@@ -0,0 +1,67 @@
+package model
+
+import (
+	"coin-api/common/enum"
+	"coin-api/domain/model"
+	"encoding/json"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+	"time"
+)
+
+type AuditSearchForm struct {
+	Actor     string `form:"actor" json:"actor"`
+	Endpoint  string `form:"endpoint" json:"endpoint"`
+	Result    string `form:"result" json:"result"`
+	RequestId string `form:"request_id" json:"request_id"`
+	From      string `form:"from" json:"from"`
+	To        string `form:"to" json:"to"`
+	Limit     string `form:"limit" json:"limit"`
+}
+
+type AuditLogResponse struct {
+	AuditId      uint            `json:"audit_id"`
+	RequestId    string          `json:"request_id"`
+	ActorType    string          `json:"actor_type"`
+	Actor        string          `json:"actor,omitempty"`
+	Method       string          `json:"method"`
+	Endpoint     string          `json:"endpoint"`
+	Payload      json.RawMessage `json:"payload"`
+	Result       string          `json:"result"`
+	StatusCode   int             `json:"status_code"`
+	ErrorMessage string          `json:"error_message,omitempty"`
+	ClientIP     string          `json:"client_ip"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+func (a AuditSearchForm) ValidateAuditSearchForm() error {
+	return validation.ValidateStruct(&a,
+		validation.Field(&a.Result, validation.In(string(enum.AUDIT_SUCCESS), string(enum.AUDIT_FAILURE))),
+		validation.Field(&a.From, validation.Date(time.RFC3339)),
+		validation.Field(&a.To, validation.Date(time.RFC3339)),
+		validation.Field(&a.Limit, is.Digit),
+	)
+}
+
+func AuditLogResponseFromDomainModel(a *model.AuditLog) *AuditLogResponse {
+	payload := a.Payload
+	if payload == "" {
+		payload = "null"
+	}
+	h := &AuditLogResponse{
+		AuditId:      a.ID,
+		RequestId:    a.RequestId,
+		ActorType:    a.ActorType,
+		Actor:        a.Actor,
+		Method:       a.Method,
+		Endpoint:     a.Endpoint,
+		Payload:      json.RawMessage(payload),
+		Result:       a.Result,
+		StatusCode:   a.StatusCode,
+		ErrorMessage: a.ErrorMessage,
+		ClientIP:     a.ClientIP,
+		CreatedAt:    a.CreatedAt,
+	}
+
+	return h
+}
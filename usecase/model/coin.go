@@ -9,80 +9,168 @@ import (
 )
 
 type CoinAddUseForm struct {
-	UserId    string `json:"userid"`
-	Operation string `json:"operation"`
-	Amount    string `json:"amount"`
+	UserId    NumericString `json:"userid"`
+	Operation string        `json:"operation"`
+	Amount    NumericString `json:"amount"`
 }
 
 type CoinSendForm struct {
-	Sender   string `json:"sender"`
-	Receiver string `json:"receiver"`
-	Amount   string `json:"amount"`
+	Sender            NumericString `json:"sender"`
+	Receiver          NumericString `json:"receiver"`
+	ReceiverUsername  string        `json:"receiver_username"`
+	Amount            NumericString `json:"amount"`
+	RequireAcceptance bool          `json:"require_acceptance"`
+	ScheduleId        *uint         `json:"-"`
+}
+
+type TransferResolveForm struct {
+	UserId string `json:"userid"`
+}
+
+type CoinReverseForm struct {
+	Reason string `json:"reason"`
 }
 
 type CoinResponse struct {
-	UserId    uint   `json:"userid"`
-	Operation string `json:"operation"`
-	Amount    int    `json:"amount"`
-	Balance   int    `json:"balance"`
+	UserId    uint          `json:"userid"`
+	Operation string        `json:"operation"`
+	Amount    model.Decimal `json:"amount"`
+	Balance   model.Decimal `json:"balance"`
 }
 
 type CoinHistoryResponse struct {
-	Operation          string    `json:"operation"`
-	OperationTimestamp time.Time `json:"operation_timestamp"`
-	Amount             int       `json:"amount"`
+	HistoryId          uint          `json:"history_id"`
+	Operation          string        `json:"operation"`
+	OperationTimestamp time.Time     `json:"operation_timestamp"`
+	Amount             model.Decimal `json:"amount"`
+	Counterparty       *uint         `json:"counterparty,omitempty"`
+	ReversalOf         *uint         `json:"reversal_of,omitempty"`
+	Reason             string        `json:"reason,omitempty"`
+	ActorType          string        `json:"actor_type,omitempty"`
+	Actor              string        `json:"actor,omitempty"`
+}
+
+type ChainVerificationResponse struct {
+	UserId          uint   `json:"userid"`
+	Verified        bool   `json:"verified"`
+	HistoryCount    int    `json:"history_count"`
+	BrokenHistoryId *uint  `json:"broken_history_id,omitempty"`
+	Reason          string `json:"reason,omitempty"`
+}
+
+type CoinReversalResponse struct {
+	OriginalId uint            `json:"original_id"`
+	Reason     string          `json:"reason"`
+	Entries    []*CoinResponse `json:"entries"`
 }
 
 type CoinSendResponse struct {
-	Sender        uint `json:"sender"`
-	Receiver      uint `json:"receiver"`
-	Amount        int  `json:"amount"`
-	SenderBalance int  `json:"sender_balance"`
+	Sender        uint          `json:"sender"`
+	Receiver      uint          `json:"receiver"`
+	Amount        model.Decimal `json:"amount"`
+	SenderBalance model.Decimal `json:"sender_balance"`
+}
+
+type CoinTransferResponse struct {
+	TransferId uint          `json:"transfer_id"`
+	Sender     uint          `json:"sender"`
+	Receiver   uint          `json:"receiver"`
+	Amount     model.Decimal `json:"amount"`
+	Status     string        `json:"status"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+	ResolvedAt *time.Time    `json:"resolved_at"`
 }
 
-func (c CoinAddUseForm) ValidateCoinAddUseForm() error {
+func (c CoinAddUseForm) ValidateCoinAddUseForm(coin model.Coin) error {
 	return validation.ValidateStruct(&c,
-		validation.Field(&c.UserId, validation.Required, is.Digit),
+		validation.Field(&c.UserId, validation.Required, is.Digit, idRule),
 		validation.Field(&c.Operation, validation.Required, validation.In(string(enum.ADD), string(enum.USE))),
-		validation.Field(&c.Amount, validation.Required, is.Digit),
+		validation.Field(&c.Amount, validation.Required, amountRule(coin)),
+	)
+}
+
+func (c CoinSendForm) ValidateCoinSendForm(coin model.Coin) error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Sender, validation.Required, is.Digit, idRule),
+		validation.Field(&c.Receiver, validation.By(receiverRule(c.ReceiverUsername)), is.Digit, idRule, notSameUserRule(c.Sender)),
+		validation.Field(&c.ReceiverUsername, validation.Length(1, 20)),
+		validation.Field(&c.Amount, validation.Required, amountRule(coin)),
 	)
 }
 
-func (c CoinSendForm) ValidateCoinSendForm() error {
+func (t TransferResolveForm) ValidateTransferResolveForm() error {
+	return validation.ValidateStruct(&t,
+		validation.Field(&t.UserId, validation.Required, is.Digit),
+	)
+}
+
+func (c CoinReverseForm) ValidateCoinReverseForm() error {
 	return validation.ValidateStruct(&c,
-		validation.Field(&c.Sender, validation.Required, is.Digit),
-		validation.Field(&c.Receiver, validation.Required, is.Digit),
-		validation.Field(&c.Amount, validation.Required, is.Digit),
+		validation.Field(&c.Reason, validation.Required, validation.Length(1, 255)),
 	)
 }
 
-func CoinResponseFromDomainModel(c *model.CoinHistory, balance int) *CoinResponse {
+func CoinResponseFromDomainModel(c *model.CoinHistory, balance int, coin model.Coin) *CoinResponse {
 	h := &CoinResponse{
 		UserId:    c.UserId,
 		Operation: c.Operation,
-		Amount:    c.Amount,
-		Balance:   balance,
+		Amount:    coin.Decimal(c.Amount),
+		Balance:   coin.Decimal(balance),
 	}
 
 	return h
 }
 
-func CoinSendResponseFromDomainModel(sender uint, receiver uint, amount int, balance int) *CoinSendResponse {
+func CoinSendResponseFromDomainModel(sender uint, receiver uint, amount int, balance int, coin model.Coin) *CoinSendResponse {
 	h := &CoinSendResponse{
 		Sender:        sender,
 		Receiver:      receiver,
-		Amount:        amount,
-		SenderBalance: balance,
+		Amount:        coin.Decimal(amount),
+		SenderBalance: coin.Decimal(balance),
 	}
 
 	return h
 }
 
-func CoinHistoryResponseFromDomainModel(c *model.CoinHistory) *CoinHistoryResponse {
+func CoinHistoryResponseFromDomainModel(c *model.CoinHistory, coin model.Coin) *CoinHistoryResponse {
 	h := &CoinHistoryResponse{
+		HistoryId:          c.ID,
 		Operation:          c.Operation,
 		OperationTimestamp: c.OperationTimestamp,
-		Amount:             c.Amount,
+		Amount:             coin.Decimal(c.Amount),
+		Counterparty:       c.Counterparty,
+		ReversalOf:         c.ReversalOf,
+		Reason:             c.Reason,
+		ActorType:          c.ActorType,
+		Actor:              c.Actor,
+	}
+
+	return h
+}
+
+func CoinTransferResponseFromDomainModel(t *model.Transfer, coin model.Coin) *CoinTransferResponse {
+	h := &CoinTransferResponse{
+		TransferId: t.ID,
+		Sender:     t.Sender,
+		Receiver:   t.Receiver,
+		Amount:     coin.Decimal(t.Amount),
+		Status:     t.Status,
+		ExpiresAt:  t.ExpiresAt,
+		ResolvedAt: t.ResolvedAt,
+	}
+
+	return h
+}
+
+func ChainVerificationResponseFromDomainModel(uid uint, count int, broken *model.ChainBreak) *ChainVerificationResponse {
+	h := &ChainVerificationResponse{
+		UserId:       uid,
+		Verified:     broken == nil,
+		HistoryCount: count,
+	}
+	if broken != nil {
+		h.BrokenHistoryId = &broken.HistoryId
+		h.Reason = broken.Reason
 	}
 
 	return h
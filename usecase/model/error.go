@@ -1,8 +1,21 @@
 package model
 
+import (
+	"coin-api/domain/repository"
+	"errors"
+	"net/http"
+)
+
+// CodeTransferRuleViolation 送金ルール違反のエラーコード(残高の上限超過等の422と区別する)
+const CodeTransferRuleViolation = "TRANSFER_RULE_VIOLATION"
+
 type ErrorResponse struct {
 	ErrorCode int    `json:"error_code"`
 	Message   string `json:"message"`
+	// Code 同じerror_codeのエラーを区別する場合のみ設定
+	Code string `json:"code,omitempty"`
+	// Rule 違反した送金ルール(送金ルール違反の場合のみ)
+	Rule string `json:"rule,omitempty"`
 }
 
 func CreateErrorResponse(code int, msg string) *ErrorResponse {
@@ -12,3 +25,19 @@ func CreateErrorResponse(code int, msg string) *ErrorResponse {
 	}
 	return e
 }
+
+// CreateServerErrorResponse 処理中に発生したエラーのレスポンス(DBへの接続を遮断している場合は503、それ以外は500)
+func CreateServerErrorResponse(err error) *ErrorResponse {
+	if errors.Is(err, repository.ErrUnavailable) {
+		return CreateErrorResponse(http.StatusServiceUnavailable, repository.ErrUnavailable.Error())
+	}
+	return CreateErrorResponse(http.StatusInternalServerError, err.Error())
+}
+
+// CreateRuleViolationResponse 送金ルール違反のレスポンス(422)
+func CreateRuleViolationResponse(rule string, msg string) *ErrorResponse {
+	e := CreateErrorResponse(http.StatusUnprocessableEntity, msg)
+	e.Code = CodeTransferRuleViolation
+	e.Rule = rule
+	return e
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"coin-api/domain/model"
+	"encoding/json"
+	"time"
+)
+
+// EventEnvelope メッセージバスへ発行するイベントの共通形式
+type EventEnvelope struct {
+	EventId     uint            `json:"event_id"`
+	TenantId    string          `json:"tenant_id"`
+	EventName   string          `json:"event_name"`
+	AggregateId uint            `json:"aggregate_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+func EventEnvelopeFromDomainModel(e *model.OutboxEvent) *EventEnvelope {
+	h := &EventEnvelope{
+		EventId:     e.ID,
+		TenantId:    e.TenantId,
+		EventName:   e.EventType,
+		AggregateId: e.UserId,
+		OccurredAt:  e.CreatedAt,
+		Payload:     json.RawMessage(e.Payload),
+	}
+
+	return h
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"coin-api/domain/model"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"time"
+)
+
+type ExportForm struct {
+	From string `form:"from" json:"from"`
+	To   string `form:"to" json:"to"`
+}
+
+type HistoryExportResponse struct {
+	HistoryId          uint          `json:"history_id"`
+	UserId             uint          `json:"userid"`
+	Operation          string        `json:"operation"`
+	OperationTimestamp time.Time     `json:"operation_timestamp"`
+	Amount             model.Decimal `json:"amount"`
+	Counterparty       *uint         `json:"counterparty,omitempty"`
+	ReversalOf         *uint         `json:"reversal_of,omitempty"`
+	Reason             string        `json:"reason,omitempty"`
+	ScheduleId         *uint         `json:"schedule_id,omitempty"`
+	BalanceAfter       model.Decimal `json:"balance_after"`
+}
+
+func (e ExportForm) ValidateExportForm() error {
+	if err := validation.ValidateStruct(&e,
+		validation.Field(&e.From, validation.By(timestampRule)),
+		validation.Field(&e.To, validation.By(timestampRule)),
+	); err != nil {
+		return err
+	}
+	from, to := e.FromTime(), e.ToTime()
+	if from != nil && to != nil && from.After(*to) {
+		return errStatementPeriod
+	}
+	return nil
+}
+
+// FromTime 開始日時(日付のみの場合はその日の始まり、未指定の場合はnil)
+func (e ExportForm) FromTime() *time.Time {
+	return optionalTimestamp(e.From, false)
+}
+
+// ToTime 終了日時(日付のみの場合はその日の終わり、未指定の場合はnil)
+func (e ExportForm) ToTime() *time.Time {
+	return optionalTimestamp(e.To, true)
+}
+
+func HistoryExportResponseFromDomainModel(r *model.HistoryExportRow, coin model.Coin) *HistoryExportResponse {
+	h := &HistoryExportResponse{
+		HistoryId:          r.ID,
+		UserId:             r.UserId,
+		Operation:          r.Operation,
+		OperationTimestamp: r.OperationTimestamp,
+		Amount:             coin.Decimal(r.Amount),
+		Counterparty:       r.Counterparty,
+		ReversalOf:         r.ReversalOf,
+		Reason:             r.Reason,
+		ScheduleId:         r.ScheduleId,
+		BalanceAfter:       coin.Decimal(r.BalanceAfter),
+	}
+
+	return h
+}
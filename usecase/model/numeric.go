@@ -0,0 +1,103 @@
+package model
+
+import (
+	"bytes"
+	"coin-api/domain/model"
+	"encoding/json"
+	"errors"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"strconv"
+)
+
+// NumericString JSONの数値と数値文字列のどちらも受け付ける文字列
+type NumericString string
+
+func (n *NumericString) UnmarshalJSON(b []byte) error {
+	// 文字列の場合はそのまま
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		*n = NumericString(s)
+		return nil
+	}
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+
+	// 数値の場合は表記のまま保持(桁あふれ・小数は後続のバリデーションで検出)
+	var num json.Number
+	if err := json.Unmarshal(b, &num); err != nil {
+		return err
+	}
+	*n = NumericString(num)
+	return nil
+}
+
+var (
+	errInvalidId       = errors.New("IDの値が不正です")
+	errReceiverMissing = errors.New("receiverまたはreceiver_usernameを指定してください")
+	errReceiverBoth    = errors.New("receiverとreceiver_usernameは同時に指定できません")
+	ErrSelfSend        = errors.New("送金者と受取人に同一ユーザーは指定できません")
+)
+
+// idRule uintに収まるIDであること(未指定はRequiredで判定)
+var idRule = validation.By(func(v interface{}) error {
+	s, err := validation.EnsureString(v)
+	if err != nil {
+		return err
+	}
+	if s == "" {
+		return nil
+	}
+	if _, err := strconv.ParseUint(s, 10, 64); err != nil {
+		return errInvalidId
+	}
+	return nil
+})
+
+// amountRule コインのAmountとして有効な金額であること
+func amountRule(coin model.Coin) validation.Rule {
+	return validation.By(func(v interface{}) error {
+		s, err := validation.EnsureString(v)
+		if err != nil {
+			return err
+		}
+		_, err = coin.ParseAmount(s)
+		return err
+	})
+}
+
+// notSameUserRule 指定ユーザーと異なるIDであること
+func notSameUserRule(other NumericString) validation.Rule {
+	return validation.By(func(v interface{}) error {
+		s, err := validation.EnsureString(v)
+		if err != nil {
+			return err
+		}
+		a, errA := strconv.ParseUint(s, 10, 64)
+		b, errB := strconv.ParseUint(string(other), 10, 64)
+		if errA == nil && errB == nil && a == b {
+			return ErrSelfSend
+		}
+		return nil
+	})
+}
+
+// receiverRule 受取人をIDまたはユーザー名のいずれか一方で指定していること
+func receiverRule(username string) validation.RuleFunc {
+	return func(v interface{}) error {
+		s, err := validation.EnsureString(v)
+		if err != nil {
+			return err
+		}
+		if s == "" && username == "" {
+			return errReceiverMissing
+		}
+		if s != "" && username != "" {
+			return errReceiverBoth
+		}
+		return nil
+	}
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"coin-api/domain/model"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestNumericStringUnmarshalJSON(t *testing.T) {
+	coin := model.Coin{Code: "COIN", Precision: 2}
+	tests := []struct {
+		body    string
+		want    NumericString
+		wantErr error
+	}{
+		// 数値・数値文字列のどちらも表記のまま保持
+		{body: `{"amount": 1.5}`, want: "1.5"},
+		{body: `{"amount": "1.5"}`, want: "1.5"},
+		{body: `{"amount": null}`, want: ""},
+
+		// 0・負数・指数表記・桁数超過・上限超過はバリデーションで検出
+		{body: `{"amount": 0}`, want: "0", wantErr: model.ErrAmountNotPositive},
+		{body: `{"amount": -5}`, want: "-5", wantErr: model.ErrAmountNotPositive},
+		{body: `{"amount": 1e3}`, want: "1e3", wantErr: model.ErrAmountInvalid},
+		{body: `{"amount": "1e3"}`, want: "1e3", wantErr: model.ErrAmountInvalid},
+		{body: `{"amount": 0.001}`, want: "0.001", wantErr: model.ErrDecimalPrecision},
+		{body: `{"amount": 1000000000}`, want: "1000000000"},
+		{body: `{"amount": 1000000000.01}`, want: "1000000000.01", wantErr: model.ErrAmountTooLarge},
+		{body: `{"amount": 100000000000000000000}`, want: "100000000000000000000", wantErr: model.ErrAmountTooLarge},
+	}
+	for _, tt := range tests {
+		var form struct {
+			Amount NumericString `json:"amount"`
+		}
+		if err := json.Unmarshal([]byte(tt.body), &form); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", tt.body, err)
+		}
+		if form.Amount != tt.want {
+			t.Errorf("Unmarshal(%s) = %q, want %q", tt.body, form.Amount, tt.want)
+		}
+		if tt.want == "" {
+			continue
+		}
+		if err := amountRule(coin).Validate(form.Amount); !errors.Is(err, tt.wantErr) {
+			t.Errorf("amountRule(%q) error = %v, want %v", form.Amount, err, tt.wantErr)
+		}
+	}
+
+	// 数値以外のJSONはエラー
+	var n NumericString
+	if err := json.Unmarshal([]byte(`true`), &n); err == nil {
+		t.Errorf("Unmarshal(true) = %q, want error", n)
+	}
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"coin-api/common/enum"
+	"coin-api/domain/model"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+	"time"
+)
+
+type ScheduleAddForm struct {
+	Sender   NumericString `json:"sender"`
+	Receiver NumericString `json:"receiver"`
+	Amount   NumericString `json:"amount"`
+	Interval string        `json:"interval"`
+	StartAt  string        `json:"start_at"`
+}
+
+type ScheduleResponse struct {
+	ScheduleId uint          `json:"schedule_id"`
+	Sender     uint          `json:"sender"`
+	Receiver   uint          `json:"receiver"`
+	Amount     model.Decimal `json:"amount"`
+	Interval   string        `json:"interval"`
+	Status     string        `json:"status"`
+	NextRunAt  time.Time     `json:"next_run_at"`
+	LastRunAt  *time.Time    `json:"last_run_at"`
+}
+
+type ScheduleExecutionResponse struct {
+	ExecutedAt time.Time `json:"executed_at"`
+	Status     string    `json:"status"`
+	Message    string    `json:"message,omitempty"`
+}
+
+func (s ScheduleAddForm) ValidateScheduleAddForm(coin model.Coin) error {
+	return validation.ValidateStruct(&s,
+		validation.Field(&s.Sender, validation.Required, is.Digit, idRule),
+		validation.Field(&s.Receiver, validation.Required, is.Digit, idRule, notSameUserRule(s.Sender)),
+		validation.Field(&s.Amount, validation.Required, amountRule(coin)),
+		validation.Field(&s.Interval, validation.Required, validation.In(string(enum.DAILY), string(enum.WEEKLY), string(enum.MONTHLY))),
+		validation.Field(&s.StartAt, validation.Date(time.RFC3339)),
+	)
+}
+
+func ScheduleResponseFromDomainModel(s *model.Schedule, coin model.Coin) *ScheduleResponse {
+	h := &ScheduleResponse{
+		ScheduleId: s.ID,
+		Sender:     s.Sender,
+		Receiver:   s.Receiver,
+		Amount:     coin.Decimal(s.Amount),
+		Interval:   s.Interval,
+		Status:     s.Status,
+		NextRunAt:  s.NextRunAt,
+		LastRunAt:  s.LastRunAt,
+	}
+
+	return h
+}
+
+func ScheduleExecutionResponseFromDomainModel(e *model.ScheduleExecution) *ScheduleExecutionResponse {
+	h := &ScheduleExecutionResponse{
+		ExecutedAt: e.ExecutedAt,
+		Status:     e.Status,
+		Message:    e.Message,
+	}
+
+	return h
+}
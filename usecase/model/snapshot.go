@@ -0,0 +1,65 @@
+package model
+
+import (
+	"coin-api/domain/model"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+	"time"
+)
+
+type SnapshotVerifyForm struct {
+	UserId string `form:"userid" json:"userid"`
+	From   string `form:"from" json:"from"`
+	To     string `form:"to" json:"to"`
+}
+
+type SnapshotMismatchResponse struct {
+	UserId  uint          `json:"userid"`
+	AsOf    time.Time     `json:"as_of"`
+	Balance model.Decimal `json:"balance"`
+	Actual  model.Decimal `json:"actual"`
+}
+
+type SnapshotVerificationResponse struct {
+	Checked    int64                       `json:"checked"`
+	Verified   bool                        `json:"verified"`
+	Mismatches []*SnapshotMismatchResponse `json:"mismatches"`
+}
+
+func (s SnapshotVerifyForm) ValidateSnapshotVerifyForm() error {
+	return validation.ValidateStruct(&s,
+		validation.Field(&s.UserId, is.Digit),
+		validation.Field(&s.From, validation.By(timestampRule)),
+		validation.Field(&s.To, validation.By(timestampRule)),
+	)
+}
+
+// FromTime 開始日時(日付のみの場合はその日の始まり、未指定の場合はnil)
+func (s SnapshotVerifyForm) FromTime() *time.Time {
+	return optionalTimestamp(s.From, false)
+}
+
+// ToTime 終了日時(日付のみの場合はその日の終わり、未指定の場合はnil)
+func (s SnapshotVerifyForm) ToTime() *time.Time {
+	return optionalTimestamp(s.To, true)
+}
+
+func SnapshotVerificationResponseFromDomainModel(checked int64, mismatches []model.SnapshotMismatch) *SnapshotVerificationResponse {
+	r := &SnapshotVerificationResponse{
+		Checked:    checked,
+		Verified:   len(mismatches) == 0,
+		Mismatches: make([]*SnapshotMismatchResponse, 0, len(mismatches)),
+	}
+	for _, m := range mismatches {
+		// ユーザーのテナントで扱うコインの桁数で表記
+		coin := CoinOf(m.TenantId)
+		r.Mismatches = append(r.Mismatches, &SnapshotMismatchResponse{
+			UserId:  m.UserId,
+			AsOf:    m.AsOf,
+			Balance: coin.Decimal(m.Balance),
+			Actual:  coin.Decimal(m.Actual),
+		})
+	}
+
+	return r
+}
@@ -0,0 +1,166 @@
+package model
+
+import (
+	"coin-api/domain/model"
+	"errors"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"time"
+)
+
+const (
+	// dateLayout 日付のみの指定(タイムゾーンはサーバーの設定に従う)
+	dateLayout = "2006-01-02"
+	// timestampResolution DBに保存される操作日時の精度
+	timestampResolution = time.Microsecond
+)
+
+var (
+	errTimestamp       = errors.New("日時はRFC3339形式またはYYYY-MM-DD形式で指定してください")
+	errStatementPeriod = errors.New("fromにはto以前の日時を指定してください")
+)
+
+type BalanceAtForm struct {
+	At string `form:"at" json:"at"`
+}
+
+type StatementForm struct {
+	From string `form:"from" json:"from"`
+	To   string `form:"to" json:"to"`
+}
+
+type BalanceAtResponse struct {
+	UserId  uint          `json:"userid"`
+	At      time.Time     `json:"at"`
+	Balance model.Decimal `json:"balance"`
+}
+
+type StatementEntryResponse struct {
+	HistoryId          uint          `json:"history_id"`
+	Operation          string        `json:"operation"`
+	OperationTimestamp time.Time     `json:"operation_timestamp"`
+	Amount             model.Decimal `json:"amount"`
+	Counterparty       *uint         `json:"counterparty,omitempty"`
+	Balance            model.Decimal `json:"balance"`
+}
+
+type StatementResponse struct {
+	UserId         uint                      `json:"userid"`
+	From           time.Time                 `json:"from"`
+	To             time.Time                 `json:"to"`
+	OpeningBalance model.Decimal             `json:"opening_balance"`
+	ClosingBalance model.Decimal             `json:"closing_balance"`
+	Transactions   []*StatementEntryResponse `json:"transactions"`
+}
+
+func (b BalanceAtForm) ValidateBalanceAtForm() error {
+	return validation.ValidateStruct(&b,
+		validation.Field(&b.At, validation.By(timestampRule)),
+	)
+}
+
+// AtTime 残高を算出する日時(未指定の場合は現在、日付のみの場合はその日の終わり)
+func (b BalanceAtForm) AtTime(now time.Time) time.Time {
+	if b.At == "" {
+		return now
+	}
+	return parseTimestamp(b.At, true)
+}
+
+func (s StatementForm) ValidateStatementForm() error {
+	if err := validation.ValidateStruct(&s,
+		validation.Field(&s.From, validation.Required, validation.By(timestampRule)),
+		validation.Field(&s.To, validation.By(timestampRule)),
+	); err != nil {
+		return err
+	}
+	if s.FromTime().After(s.ToTime(time.Now())) {
+		return errStatementPeriod
+	}
+	return nil
+}
+
+// FromTime 明細の開始日時(日付のみの場合はその日の始まり)
+func (s StatementForm) FromTime() time.Time {
+	return parseTimestamp(s.From, false)
+}
+
+// ToTime 明細の終了日時(未指定の場合は現在、日付のみの場合はその日の終わり)
+func (s StatementForm) ToTime(now time.Time) time.Time {
+	if s.To == "" {
+		return now
+	}
+	return parseTimestamp(s.To, true)
+}
+
+func BalanceAtResponseFromDomainModel(uid uint, at time.Time, balance int, coin model.Coin) *BalanceAtResponse {
+	b := &BalanceAtResponse{
+		UserId:  uid,
+		At:      at,
+		Balance: coin.Decimal(balance),
+	}
+
+	return b
+}
+
+// StatementResponseFromDomainModel 期首残高に各履歴を順に加算した取引明細を作成
+func StatementResponseFromDomainModel(uid uint, from time.Time, to time.Time, opening int, histories []model.CoinHistory, coin model.Coin) *StatementResponse {
+	s := &StatementResponse{
+		UserId:         uid,
+		From:           from,
+		To:             to,
+		OpeningBalance: coin.Decimal(opening),
+		Transactions:   make([]*StatementEntryResponse, 0, len(histories)),
+	}
+
+	balance := opening
+	for i := range histories {
+		balance += histories[i].Amount
+		s.Transactions = append(s.Transactions, &StatementEntryResponse{
+			HistoryId:          histories[i].ID,
+			Operation:          histories[i].Operation,
+			OperationTimestamp: histories[i].OperationTimestamp,
+			Amount:             coin.Decimal(histories[i].Amount),
+			Counterparty:       histories[i].Counterparty,
+			Balance:            coin.Decimal(balance),
+		})
+	}
+	s.ClosingBalance = coin.Decimal(balance)
+
+	return s
+}
+
+// timestampRule RFC3339形式またはYYYY-MM-DD形式の日時(未指定は許容)
+func timestampRule(value interface{}) error {
+	s, _ := value.(string)
+	if s == "" {
+		return nil
+	}
+	if _, err := time.Parse(time.RFC3339, s); err == nil {
+		return nil
+	}
+	if _, err := time.ParseInLocation(dateLayout, s, time.Local); err == nil {
+		return nil
+	}
+	return errTimestamp
+}
+
+// optionalTimestamp 検証済みの日時を変換(未指定の場合はnil)
+func optionalTimestamp(s string, endOfDay bool) *time.Time {
+	if s == "" {
+		return nil
+	}
+	t := parseTimestamp(s, endOfDay)
+	return &t
+}
+
+// parseTimestamp 検証済みの日時を変換(日付のみの場合はその日の始まり、endOfDayの場合はその日の最後の時刻)
+func parseTimestamp(s string, endOfDay bool) time.Time {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t
+	}
+	d, _ := time.ParseInLocation(dateLayout, s, time.Local)
+	if endOfDay {
+		return d.AddDate(0, 0, 1).Add(-timestampResolution)
+	}
+	return d
+}
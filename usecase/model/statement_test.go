@@ -0,0 +1,79 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		s        string
+		endOfDay bool
+		want     time.Time
+	}{
+		// 日付のみの場合はその日の始まり、終わりの場合はDBの精度での最後の時刻
+		{s: "2023-03-01", want: time.Date(2023, 3, 1, 0, 0, 0, 0, time.Local)},
+		{s: "2023-03-01", endOfDay: true, want: time.Date(2023, 3, 1, 23, 59, 59, 999999000, time.Local)},
+		// 月末・閏年の翌日への繰り上がり
+		{s: "2023-03-31", endOfDay: true, want: time.Date(2023, 3, 31, 23, 59, 59, 999999000, time.Local)},
+		{s: "2024-02-29", endOfDay: true, want: time.Date(2024, 2, 29, 23, 59, 59, 999999000, time.Local)},
+		{s: "2023-12-31", endOfDay: true, want: time.Date(2023, 12, 31, 23, 59, 59, 999999000, time.Local)},
+		// RFC3339の場合は指定した時刻のまま
+		{s: "2023-03-01T10:00:00+09:00", want: time.Date(2023, 3, 1, 1, 0, 0, 0, time.UTC)},
+		{s: "2023-03-01T10:00:00+09:00", endOfDay: true, want: time.Date(2023, 3, 1, 1, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got := parseTimestamp(tt.s, tt.endOfDay)
+		if !got.Equal(tt.want) {
+			t.Errorf("parseTimestamp(%q, %v) = %v, want %v", tt.s, tt.endOfDay, got, tt.want)
+		}
+	}
+
+	// 終わりの時刻の次の精度の時刻は翌日の始まり
+	end := parseTimestamp("2023-03-01", true)
+	if next := parseTimestamp("2023-03-02", false); !end.Add(timestampResolution).Equal(next) {
+		t.Errorf("end of day + %v = %v, want %v", timestampResolution, end.Add(timestampResolution), next)
+	}
+}
+
+func TestValidateStatementForm(t *testing.T) {
+	tests := []struct {
+		name    string
+		form    StatementForm
+		wantErr bool
+		period  bool
+	}{
+		{name: "same day", form: StatementForm{From: "2023-03-01", To: "2023-03-01"}},
+		{name: "from before to", form: StatementForm{From: "2023-03-01", To: "2023-03-31"}},
+		{name: "from equals to", form: StatementForm{From: "2023-03-01T10:00:00+09:00", To: "2023-03-01T10:00:00+09:00"}},
+		{name: "to omitted", form: StatementForm{From: "2023-03-01"}},
+		{name: "from after to", form: StatementForm{From: "2023-03-02", To: "2023-03-01"}, wantErr: true, period: true},
+		{name: "from after to by a second", form: StatementForm{From: "2023-03-01T10:00:01+09:00", To: "2023-03-01T10:00:00+09:00"}, wantErr: true, period: true},
+		{name: "from in the future", form: StatementForm{From: time.Now().AddDate(0, 0, 2).Format(dateLayout)}, wantErr: true, period: true},
+		{name: "from missing", form: StatementForm{To: "2023-03-01"}, wantErr: true},
+		{name: "invalid from", form: StatementForm{From: "2023/03/01"}, wantErr: true},
+		{name: "invalid to", form: StatementForm{From: "2023-03-01", To: "2023-03-01 10:00"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		err := tt.form.ValidateStatementForm()
+		if (err != nil) != tt.wantErr || errors.Is(err, errStatementPeriod) != tt.period {
+			t.Errorf("%s: ValidateStatementForm() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestBalanceAtFormAtTime(t *testing.T) {
+	now := time.Date(2023, 4, 15, 10, 30, 0, 0, time.UTC)
+
+	// 未指定の場合は現在、日付のみの場合はその日の終わり
+	if got := (BalanceAtForm{}).AtTime(now); !got.Equal(now) {
+		t.Errorf("AtTime() = %v, want %v", got, now)
+	}
+	if got, want := (BalanceAtForm{At: "2023-03-01"}).AtTime(now), time.Date(2023, 3, 1, 23, 59, 59, 999999000, time.Local); !got.Equal(want) {
+		t.Errorf("AtTime() = %v, want %v", got, want)
+	}
+	if err := (BalanceAtForm{At: "2023-03-01T10:00"}).ValidateBalanceAtForm(); err == nil {
+		t.Error("ValidateBalanceAtForm() error = nil, want error")
+	}
+}
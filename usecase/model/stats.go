@@ -0,0 +1,139 @@
+package model
+
+import (
+	"coin-api/domain/model"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+	"time"
+)
+
+// 集計の時間区切り
+const (
+	StatsIntervalHour  = "hour"
+	StatsIntervalDay   = "day"
+	StatsIntervalWeek  = "week"
+	StatsIntervalMonth = "month"
+)
+
+// ランキングの対象
+const (
+	TopSenders   = "senders"
+	TopReceivers = "receivers"
+)
+
+type StatsForm struct {
+	Interval string `form:"interval" json:"interval"`
+	From     string `form:"from" json:"from"`
+	To       string `form:"to" json:"to"`
+}
+
+type TopUsersForm struct {
+	Direction string `form:"direction" json:"direction"`
+	From      string `form:"from" json:"from"`
+	To        string `form:"to" json:"to"`
+	Limit     string `form:"limit" json:"limit"`
+}
+
+type CirculationResponse struct {
+	Users     int64         `json:"users"`
+	Total     model.Decimal `json:"total"`
+	Available model.Decimal `json:"available"`
+	Held      model.Decimal `json:"held"`
+}
+
+type SupplyStatsResponse struct {
+	Bucket time.Time     `json:"bucket"`
+	Minted model.Decimal `json:"minted"`
+	Burned model.Decimal `json:"burned"`
+	Net    model.Decimal `json:"net"`
+}
+
+type TransferStatsResponse struct {
+	Bucket time.Time     `json:"bucket"`
+	Volume model.Decimal `json:"volume"`
+	Count  int           `json:"count"`
+}
+
+type ActiveUsersResponse struct {
+	Bucket time.Time `json:"bucket"`
+	Users  int       `json:"users"`
+}
+
+type TopUserResponse struct {
+	UserId uint          `json:"userid"`
+	Amount model.Decimal `json:"amount"`
+}
+
+func (s StatsForm) ValidateStatsForm() error {
+	return validation.ValidateStruct(&s,
+		validation.Field(&s.Interval, validation.In(StatsIntervalHour, StatsIntervalDay, StatsIntervalWeek, StatsIntervalMonth)),
+		validation.Field(&s.From, validation.By(timestampRule)),
+		validation.Field(&s.To, validation.By(timestampRule)),
+	)
+}
+
+// IntervalOrDefault 時間区切り(未指定の場合は日単位)
+func (s StatsForm) IntervalOrDefault() string {
+	if s.Interval == "" {
+		return StatsIntervalDay
+	}
+	return s.Interval
+}
+
+// FromTime 開始日時(日付のみの場合はその日の始まり、未指定の場合はnil)
+func (s StatsForm) FromTime() *time.Time {
+	return optionalTimestamp(s.From, false)
+}
+
+// ToTime 終了日時(日付のみの場合はその日の終わり、未指定の場合はnil)
+func (s StatsForm) ToTime() *time.Time {
+	return optionalTimestamp(s.To, true)
+}
+
+func (t TopUsersForm) ValidateTopUsersForm() error {
+	return validation.ValidateStruct(&t,
+		validation.Field(&t.Direction, validation.Required, validation.In(TopSenders, TopReceivers)),
+		validation.Field(&t.From, validation.By(timestampRule)),
+		validation.Field(&t.To, validation.By(timestampRule)),
+		validation.Field(&t.Limit, is.Digit),
+	)
+}
+
+// FromTime 開始日時(日付のみの場合はその日の始まり、未指定の場合はnil)
+func (t TopUsersForm) FromTime() *time.Time {
+	return optionalTimestamp(t.From, false)
+}
+
+// ToTime 終了日時(日付のみの場合はその日の終わり、未指定の場合はnil)
+func (t TopUsersForm) ToTime() *time.Time {
+	return optionalTimestamp(t.To, true)
+}
+
+func CirculationResponseFromDomainModel(c *model.Circulation, coin model.Coin) *CirculationResponse {
+	r := &CirculationResponse{
+		Users:     c.Users,
+		Total:     coin.Decimal(c.Available + c.Held),
+		Available: coin.Decimal(c.Available),
+		Held:      coin.Decimal(c.Held),
+	}
+
+	return r
+}
+
+func ActiveUsersResponseFromDomainModel(b *model.ActiveUsersBucket, loc *time.Location) *ActiveUsersResponse {
+	r := &ActiveUsersResponse{
+		Bucket: b.Bucket.In(loc),
+		Users:  b.Users,
+	}
+
+	return r
+}
+
+func TopUserResponseFromDomainModel(t *model.UserTotal, coin model.Coin) *TopUserResponse {
+	r := &TopUserResponse{
+		UserId: t.UserId,
+		Amount: coin.Decimal(t.Amount),
+	}
+
+	return r
+}
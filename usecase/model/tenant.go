@@ -0,0 +1,54 @@
+package model
+
+import (
+	"coin-api/config"
+	"coin-api/domain/model"
+)
+
+type TenantResponse struct {
+	TenantId     string                `json:"tenant_id"`
+	Name         string                `json:"name"`
+	CoinCode     string                `json:"coin_code"`
+	Precision    int                   `json:"precision"`
+	TransferRule *TransferRuleResponse `json:"transfer_rule"`
+}
+
+// TransferRuleResponse 送金ルールの設定値(金額はコイン単位、0の場合は無効)
+type TransferRuleResponse struct {
+	MaxAmount       model.Decimal `json:"max_amount"`
+	DailyCap        model.Decimal `json:"daily_cap"`
+	MonthlyCap      model.Decimal `json:"monthly_cap"`
+	MaxCountPerHour int           `json:"max_count_per_hour"`
+	MinAccountAge   string        `json:"min_account_age"`
+}
+
+// CoinOf テナントで扱うコイン(テナント未指定・未登録の場合は既定のテナントのコイン)
+func CoinOf(tenantId string) model.Coin {
+	info := config.LoadConfig().CoinOf(tenantId)
+	return model.Coin{Code: info.Code, Precision: info.Precision}
+}
+
+func TenantResponseFromConfig(t *config.TenantInfo, defaultRule *config.TransferRuleInfo, coin model.Coin) *TenantResponse {
+	// テナントに送金ルールの設定がない場合は共通の設定値
+	r := t.TransferRule
+	if r == nil {
+		r = defaultRule
+	}
+	scale := int(coin.Scale())
+
+	h := &TenantResponse{
+		TenantId:  t.Id,
+		Name:      t.Name,
+		CoinCode:  t.CoinCode,
+		Precision: coin.Precision,
+		TransferRule: &TransferRuleResponse{
+			MaxAmount:       coin.Decimal(r.MaxAmount * scale),
+			DailyCap:        coin.Decimal(r.DailyCap * scale),
+			MonthlyCap:      coin.Decimal(r.MonthlyCap * scale),
+			MaxCountPerHour: r.MaxCountPerHour,
+			MinAccountAge:   r.MinAccountAge.String(),
+		},
+	}
+
+	return h
+}
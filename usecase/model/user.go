@@ -1,20 +1,31 @@
 package model
 
 import (
+	"coin-api/domain/event"
 	"coin-api/domain/model"
+	"errors"
 	validation "github.com/go-ozzo/ozzo-validation"
 )
 
+var errNoProfileChange = errors.New("usernameまたはpasswordを指定してください")
+
 type UserResponse struct {
-	UserId   uint   `json:"userid"`
-	Name     string `json:"username"`
-	Password string `json:"password"`
-	Balance  int    `json:"balance"`
+	UserId  uint          `json:"userid"`
+	Name    string        `json:"username"`
+	Balance model.Decimal `json:"balance"`
 }
 
 type UserBalanceResponse struct {
-	UserId  uint `json:"userid"`
-	Balance int  `json:"balance"`
+	UserId      uint          `json:"userid"`
+	Balance     model.Decimal `json:"balance"`
+	HeldBalance model.Decimal `json:"held_balance"`
+}
+
+type BalanceEventResponse struct {
+	UserId      uint                 `json:"userid"`
+	Balance     model.Decimal        `json:"balance"`
+	HeldBalance model.Decimal        `json:"held_balance"`
+	History     *CoinHistoryResponse `json:"history,omitempty"`
 }
 
 type UserAddForm struct {
@@ -22,28 +33,133 @@ type UserAddForm struct {
 	Password string `json:"password"`
 }
 
+type UserLookupForm struct {
+	UserName string `form:"username" json:"username"`
+}
+
+type UserUpdateForm struct {
+	UserName    string `json:"username"`
+	Password    string `json:"password"`
+	OldPassword string `json:"old_password"`
+}
+
+type UserLoginForm struct {
+	UserName string `json:"username"`
+	Password string `json:"password"`
+}
+
+type PasswordResetRequestForm struct {
+	UserName string `json:"username"`
+}
+
+type PasswordResetConfirmForm struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type UserProfileResponse struct {
+	UserId uint   `json:"userid"`
+	Name   string `json:"username"`
+}
+
 func (u UserAddForm) ValidateUserAddForm() error {
 	return validation.ValidateStruct(&u,
 		validation.Field(&u.UserName, validation.Required, validation.Length(1, 20)),
-		validation.Field(&u.Password, validation.Required, validation.Length(1, 20)),
+		validation.Field(&u.Password, validation.Required),
+	)
+}
+
+func (u UserLookupForm) ValidateUserLookupForm() error {
+	return validation.ValidateStruct(&u,
+		validation.Field(&u.UserName, validation.Required, validation.Length(1, 20)),
+	)
+}
+
+func (u UserUpdateForm) ValidateUserUpdateForm() error {
+	if err := validation.ValidateStruct(&u,
+		validation.Field(&u.UserName, validation.Length(1, 20)),
+		validation.Field(&u.OldPassword, validation.Required),
+	); err != nil {
+		return err
+	}
+	if u.UserName == "" && u.Password == "" {
+		return errNoProfileChange
+	}
+	return nil
+}
+
+func (u UserLoginForm) ValidateUserLoginForm() error {
+	return validation.ValidateStruct(&u,
+		validation.Field(&u.UserName, validation.Required, validation.Length(1, 20)),
+		validation.Field(&u.Password, validation.Required),
+	)
+}
+
+func (p PasswordResetRequestForm) ValidatePasswordResetRequestForm() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.UserName, validation.Required, validation.Length(1, 20)),
 	)
 }
 
-func UserFromDomainModel(m *model.User) *UserResponse {
+func (p PasswordResetConfirmForm) ValidatePasswordResetConfirmForm() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Token, validation.Required),
+		validation.Field(&p.Password, validation.Required),
+	)
+}
+
+func UserFromDomainModel(m *model.User, coin model.Coin) *UserResponse {
 	u := &UserResponse{
-		UserId:   m.ID,
-		Name:     m.Username,
-		Password: m.Password,
-		Balance:  *m.CoinBalance,
+		UserId:  m.ID,
+		Name:    m.Username,
+		Balance: coin.Decimal(*m.CoinBalance),
 	}
 
 	return u
 }
 
-func UserBalanceFromDomainModel(m *model.User) *UserBalanceResponse {
+func UserBalanceFromDomainModel(m *model.User, coin model.Coin) *UserBalanceResponse {
 	u := &UserBalanceResponse{
-		UserId:  m.ID,
-		Balance: *m.CoinBalance,
+		UserId:      m.ID,
+		Balance:     coin.Decimal(*m.CoinBalance),
+		HeldBalance: coin.Decimal(*m.HeldBalance),
+	}
+
+	return u
+}
+
+func BalanceEventFromDomainModel(m *model.User, coin model.Coin) *BalanceEventResponse {
+	u := &BalanceEventResponse{
+		UserId:      m.ID,
+		Balance:     coin.Decimal(*m.CoinBalance),
+		HeldBalance: coin.Decimal(*m.HeldBalance),
+	}
+
+	return u
+}
+
+func BalanceEventFromDomainEvent(e *event.BalanceChanged, coin model.Coin) *BalanceEventResponse {
+	u := &BalanceEventResponse{
+		UserId:      e.UserId,
+		Balance:     coin.Decimal(e.Balance),
+		HeldBalance: coin.Decimal(e.HeldBalance),
+	}
+	if e.OperationTimestamp != nil {
+		u.History = &CoinHistoryResponse{
+			HistoryId:          e.HistoryId,
+			Operation:          e.Operation,
+			OperationTimestamp: *e.OperationTimestamp,
+			Amount:             coin.Decimal(e.Amount),
+		}
+	}
+
+	return u
+}
+
+func UserProfileFromDomainModel(m *model.User) *UserProfileResponse {
+	u := &UserProfileResponse{
+		UserId: m.ID,
+		Name:   m.Username,
 	}
 
 	return u
@@ -0,0 +1,163 @@
+package model
+
+import (
+	"coin-api/common/enum"
+	"coin-api/domain/event"
+	"coin-api/domain/model"
+	"encoding/json"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+	"strings"
+	"time"
+)
+
+type WebhookAddForm struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+}
+
+type WebhookSubscriptionResponse struct {
+	SubscriptionId uint     `json:"subscription_id"`
+	URL            string   `json:"url"`
+	EventTypes     []string `json:"event_types"`
+	Active         bool     `json:"active"`
+}
+
+type WebhookDeliveryResponse struct {
+	DeliveryId     uint      `json:"delivery_id"`
+	EventId        uint      `json:"event_id"`
+	EventType      string    `json:"event_type"`
+	Status         string    `json:"status"`
+	Attempts       int       `json:"attempts"`
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	ResponseStatus int       `json:"response_status"`
+	LastError      string    `json:"last_error,omitempty"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// WebhookPayload Webhook送信時のリクエストボディ
+type WebhookPayload struct {
+	EventId    uint            `json:"event_id"`
+	EventType  string          `json:"event_type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// CoinEventData コインイベントの内容
+type CoinEventData struct {
+	UserId       uint          `json:"userid"`
+	Operation    string        `json:"operation"`
+	Amount       model.Decimal `json:"amount"`
+	Balance      model.Decimal `json:"balance"`
+	Counterparty *uint         `json:"counterparty,omitempty"`
+}
+
+// ScheduleEventData 定期送金イベントの内容
+type ScheduleEventData struct {
+	ScheduleId uint          `json:"schedule_id"`
+	UserId     uint          `json:"userid"`
+	Receiver   uint          `json:"receiver"`
+	Amount     model.Decimal `json:"amount"`
+	Reason     string        `json:"reason"`
+	NextRunAt  time.Time     `json:"next_run_at"`
+}
+
+// UserEventData ユーザーイベントの内容
+type UserEventData struct {
+	UserId   uint   `json:"userid"`
+	Username string `json:"username"`
+}
+
+var webhookEventTypes = []interface{}{
+	string(enum.COIN_ADDED),
+	string(enum.COIN_USED),
+	string(enum.COIN_SENT),
+	string(enum.COIN_RECEIVED),
+	string(enum.COIN_HELD),
+	string(enum.COIN_REFUNDED),
+	string(enum.COIN_REVERSED),
+	string(enum.SCHEDULE_SKIPPED),
+	string(enum.USER_CREATED),
+}
+
+func (w WebhookAddForm) ValidateWebhookAddForm() error {
+	return validation.ValidateStruct(&w,
+		validation.Field(&w.URL, validation.Required, is.URL),
+		validation.Field(&w.Secret, validation.Required, validation.Length(16, 255)),
+		validation.Field(&w.EventTypes, validation.Required, validation.Each(validation.In(webhookEventTypes...))),
+	)
+}
+
+func WebhookSubscriptionResponseFromDomainModel(w *model.WebhookSubscription) *WebhookSubscriptionResponse {
+	h := &WebhookSubscriptionResponse{
+		SubscriptionId: w.ID,
+		URL:            w.URL,
+		EventTypes:     strings.Split(w.EventTypes, ","),
+		Active:         w.Active,
+	}
+
+	return h
+}
+
+func WebhookDeliveryResponseFromDomainModel(d *model.WebhookDelivery) *WebhookDeliveryResponse {
+	h := &WebhookDeliveryResponse{
+		DeliveryId:     d.ID,
+		EventId:        d.EventId,
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		UpdatedAt:      d.UpdatedAt,
+	}
+
+	return h
+}
+
+// WebhookEvent ドメインイベントから変換したWebhookのイベント種別と内容
+type WebhookEvent struct {
+	EventType string
+	Data      interface{}
+}
+
+// WebhookEventsFromDomainEvent ドメインイベントをWebhookのイベントへ変換(送金は送金者、受取人の2件)
+func WebhookEventsFromDomainEvent(e event.DomainEvent, coin model.Coin) []*WebhookEvent {
+	switch v := e.(type) {
+	case *event.CoinAdded:
+		return []*WebhookEvent{
+			{EventType: string(enum.COIN_ADDED), Data: &CoinEventData{UserId: v.UserId, Operation: string(enum.ADD), Amount: coin.Decimal(v.Amount), Balance: coin.Decimal(v.Balance)}},
+		}
+	case *event.CoinUsed:
+		return []*WebhookEvent{
+			{EventType: string(enum.COIN_USED), Data: &CoinEventData{UserId: v.UserId, Operation: string(enum.USE), Amount: coin.Decimal(-v.Amount), Balance: coin.Decimal(v.Balance)}},
+		}
+	case *event.CoinTransferred:
+		return []*WebhookEvent{
+			{EventType: string(enum.COIN_SENT), Data: &CoinEventData{UserId: v.Sender, Operation: string(enum.SEND), Amount: coin.Decimal(-v.Amount), Balance: coin.Decimal(v.SenderBalance), Counterparty: &v.Receiver}},
+			{EventType: string(enum.COIN_RECEIVED), Data: &CoinEventData{UserId: v.Receiver, Operation: string(enum.RECEIVE), Amount: coin.Decimal(v.Amount), Balance: coin.Decimal(v.ReceiverBalance), Counterparty: &v.Sender}},
+		}
+	case *event.CoinHeld:
+		return []*WebhookEvent{
+			{EventType: string(enum.COIN_HELD), Data: &CoinEventData{UserId: v.Sender, Operation: string(enum.HOLD), Amount: coin.Decimal(-v.Amount), Balance: coin.Decimal(v.SenderBalance), Counterparty: &v.Receiver}},
+		}
+	case *event.CoinRefunded:
+		return []*WebhookEvent{
+			{EventType: string(enum.COIN_REFUNDED), Data: &CoinEventData{UserId: v.Sender, Operation: string(enum.REFUND), Amount: coin.Decimal(v.Amount), Balance: coin.Decimal(v.SenderBalance), Counterparty: &v.Receiver}},
+		}
+	case *event.CoinReversed:
+		return []*WebhookEvent{
+			{EventType: string(enum.COIN_REVERSED), Data: &CoinEventData{UserId: v.UserId, Operation: string(enum.REVERSAL), Amount: coin.Decimal(v.Amount), Balance: coin.Decimal(v.Balance), Counterparty: v.Counterparty}},
+		}
+	case *event.ScheduleSkipped:
+		return []*WebhookEvent{
+			{EventType: string(enum.SCHEDULE_SKIPPED), Data: &ScheduleEventData{ScheduleId: v.ScheduleId, UserId: v.Sender, Receiver: v.Receiver, Amount: coin.Decimal(v.Amount), Reason: v.Reason, NextRunAt: v.NextRunAt}},
+		}
+	case *event.UserCreated:
+		return []*WebhookEvent{
+			{EventType: string(enum.USER_CREATED), Data: &UserEventData{UserId: v.UserId, Username: v.Username}},
+		}
+	}
+	return nil
+}
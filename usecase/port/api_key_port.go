@@ -0,0 +1,18 @@
+package ports
+
+import (
+	"coin-api/usecase/model"
+)
+
+type ApiKeyInputPort interface {
+	CreateApiKey(form *model.ApiKeyAddForm) error
+	SelectApiKeys() error
+	RevokeApiKey(id string) error
+}
+
+type ApiKeyOutputPort interface {
+	OutputApiKeyCreated(key *model.ApiKeyCreatedResponse) error
+	OutputApiKey(key *model.ApiKeyResponse) error
+	OutputApiKeys(keys []*model.ApiKeyResponse) error
+	OutputError(res *model.ErrorResponse, err error) error
+}
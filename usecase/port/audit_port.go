@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"coin-api/usecase/model"
+)
+
+type AuditInputPort interface {
+	SelectAuditLogs(form *model.AuditSearchForm) error
+}
+
+type AuditOutputPort interface {
+	OutputAuditLogs(entries []*model.AuditLogResponse) error
+	OutputError(res *model.ErrorResponse, err error) error
+}
@@ -6,14 +6,22 @@ import (
 )
 
 type CoinInputPort interface {
-	SelectHistoriesByUserId(uid string) error
+	SelectHistoriesByUserId(ctx context.Context, uid string) error
+	VerifyHistoryChain(ctx context.Context, uid string) error
 	AddUseCoin(ctx context.Context, form *model.CoinAddUseForm) error
 	SendCoin(ctx context.Context, form *model.CoinSendForm) error
+	AcceptTransfer(ctx context.Context, id string, form *model.TransferResolveForm) error
+	RejectTransfer(ctx context.Context, id string, form *model.TransferResolveForm) error
+	ExpirePendingTransfers(ctx context.Context) (int, error)
+	ReverseHistory(ctx context.Context, id string, form *model.CoinReverseForm) error
 }
 
 type CoinOutputPort interface {
 	OutputCoin(coin *model.CoinResponse) error
 	OutputCoinSend(coin *model.CoinSendResponse) error
+	OutputCoinTransfer(transfer *model.CoinTransferResponse) error
+	OutputCoinReversal(reversal *model.CoinReversalResponse) error
 	OutputCoinHistory(histories []*model.CoinHistoryResponse) error
+	OutputChainVerification(verification *model.ChainVerificationResponse) error
 	OutputError(res *model.ErrorResponse, err error) error
 }
@@ -0,0 +1,22 @@
+package ports
+
+import (
+	"context"
+)
+
+type EventRelayInputPort interface {
+	RelayEvents(ctx context.Context) (int, error)
+}
+
+// EventMessage メッセージバスへ発行するイベント
+type EventMessage struct {
+	Name string
+	Key  string
+	Body []byte
+}
+
+// EventPublisher メッセージバスへの発行処理
+type EventPublisher interface {
+	Publish(ctx context.Context, msg *EventMessage) error
+	Close() error
+}
@@ -0,0 +1,19 @@
+package ports
+
+import (
+	"coin-api/usecase/model"
+	"context"
+)
+
+type ExportInputPort interface {
+	ExportUserHistories(ctx context.Context, uid string, form *model.ExportForm) error
+	ExportAllHistories(ctx context.Context, form *model.ExportForm) error
+}
+
+// ExportOutputPort 1行ずつ出力するストリーミング出力(OutputExportStart → OutputExportRow × n → OutputExportEnd)
+type ExportOutputPort interface {
+	OutputExportStart(name string) error
+	OutputExportRow(row *model.HistoryExportResponse) error
+	OutputExportEnd() error
+	OutputError(res *model.ErrorResponse, err error) error
+}
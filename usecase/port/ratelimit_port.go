@@ -0,0 +1,25 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimit トークンバケットの設定(Rateは1分あたりの補充数、Burstはバケット容量)
+type RateLimit struct {
+	Rate  int
+	Burst int
+}
+
+// RateLimitResult トークン取得結果
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+	Reset      time.Duration
+}
+
+// RateLimitStore キーごとのトークンバケットの保存先
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit RateLimit, now time.Time) (*RateLimitResult, error)
+}
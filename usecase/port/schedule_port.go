@@ -0,0 +1,24 @@
+package ports
+
+import (
+	"coin-api/usecase/model"
+	"context"
+)
+
+// CoinInputPortFactory スケジュール実行時の送金処理生成
+type CoinInputPortFactory func(CoinOutputPort) CoinInputPort
+
+type ScheduleInputPort interface {
+	CreateSchedule(ctx context.Context, form *model.ScheduleAddForm) error
+	SelectSchedulesByUserId(ctx context.Context, uid string) error
+	SelectExecutionsByScheduleId(id string) error
+	CancelSchedule(ctx context.Context, id string, uid string) error
+	RunDueSchedules(ctx context.Context, coinInput CoinInputPortFactory) (int, error)
+}
+
+type ScheduleOutputPort interface {
+	OutputSchedule(schedule *model.ScheduleResponse) error
+	OutputSchedules(schedules []*model.ScheduleResponse) error
+	OutputScheduleExecutions(executions []*model.ScheduleExecutionResponse) error
+	OutputError(res *model.ErrorResponse, err error) error
+}
@@ -0,0 +1,16 @@
+package ports
+
+import (
+	"coin-api/usecase/model"
+	"context"
+)
+
+type SnapshotInputPort interface {
+	TakeSnapshots(ctx context.Context) (int64, error)
+	VerifySnapshots(form *model.SnapshotVerifyForm) error
+}
+
+type SnapshotOutputPort interface {
+	OutputSnapshotVerification(verification *model.SnapshotVerificationResponse) error
+	OutputError(res *model.ErrorResponse, err error) error
+}
@@ -0,0 +1,17 @@
+package ports
+
+import (
+	"coin-api/usecase/model"
+	"context"
+)
+
+type StatementInputPort interface {
+	GetBalanceAt(ctx context.Context, uid string, form *model.BalanceAtForm) error
+	GetStatement(ctx context.Context, uid string, form *model.StatementForm) error
+}
+
+type StatementOutputPort interface {
+	OutputBalanceAt(balance *model.BalanceAtResponse) error
+	OutputStatement(statement *model.StatementResponse) error
+	OutputError(res *model.ErrorResponse, err error) error
+}
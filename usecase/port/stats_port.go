@@ -0,0 +1,24 @@
+package ports
+
+import (
+	"coin-api/usecase/model"
+	"context"
+)
+
+type StatsInputPort interface {
+	GetCirculation(ctx context.Context) error
+	GetSupply(ctx context.Context, form *model.StatsForm) error
+	GetTransferVolume(ctx context.Context, form *model.StatsForm) error
+	GetActiveUsers(ctx context.Context, form *model.StatsForm) error
+	GetTopUsers(ctx context.Context, form *model.TopUsersForm) error
+	RefreshRollups(ctx context.Context) (int64, error)
+}
+
+type StatsOutputPort interface {
+	OutputCirculation(circulation *model.CirculationResponse) error
+	OutputSupply(buckets []*model.SupplyStatsResponse) error
+	OutputTransferVolume(buckets []*model.TransferStatsResponse) error
+	OutputActiveUsers(buckets []*model.ActiveUsersResponse) error
+	OutputTopUsers(users []*model.TopUserResponse) error
+	OutputError(res *model.ErrorResponse, err error) error
+}
@@ -0,0 +1,15 @@
+package ports
+
+import (
+	"coin-api/usecase/model"
+	"context"
+)
+
+type TenantInputPort interface {
+	GetTenant(ctx context.Context) error
+}
+
+type TenantOutputPort interface {
+	OutputTenant(tenant *model.TenantResponse) error
+	OutputError(res *model.ErrorResponse, err error) error
+}
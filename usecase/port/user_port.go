@@ -1,16 +1,47 @@
 package ports
 
 import (
+	"coin-api/domain/event"
 	"coin-api/usecase/model"
+	"context"
+	"time"
 )
 
 type UserInputPort interface {
-	RegisterUser(user *model.UserAddForm) error
+	RegisterUser(ctx context.Context, user *model.UserAddForm) error
 	GetBalanceByUserId(uid string) error
+	LookupUser(form *model.UserLookupForm) error
+	UpdateUser(ctx context.Context, uid string, form *model.UserUpdateForm) error
+	Login(ctx context.Context, form *model.UserLoginForm) error
+	RequestPasswordReset(ctx context.Context, form *model.PasswordResetRequestForm) error
+	ResetPassword(ctx context.Context, form *model.PasswordResetConfirmForm) error
+	StreamEvents(ctx context.Context, uid string) error
 }
 
 type UserOutputPort interface {
 	OutputUser(user *model.UserResponse) error
 	OutputUserBalance(balance *model.UserBalanceResponse) error
+	OutputUserProfile(profile *model.UserProfileResponse) error
+	OutputPasswordResetRequested() error
+	OutputBalanceEvent(e *model.BalanceEventResponse) error
+	OutputKeepAlive() error
 	OutputError(res *model.ErrorResponse, err error) error
 }
+
+// BalanceSubscriber ユーザーごとの残高変更通知の購読
+type BalanceSubscriber interface {
+	Subscribe(uid uint) (<-chan *event.BalanceChanged, func())
+}
+
+// PasswordResetNotification ユーザーへ通知するパスワード再設定トークン
+type PasswordResetNotification struct {
+	UserId    uint      `json:"userid"`
+	Username  string    `json:"username"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PasswordResetNotifier パスワード再設定トークンの通知(トークンを含むためoutbox・イベントのリレーを経由しない)
+type PasswordResetNotifier interface {
+	NotifyPasswordReset(ctx context.Context, n *PasswordResetNotification) error
+}
@@ -0,0 +1,36 @@
+package ports
+
+import (
+	"coin-api/usecase/model"
+	"context"
+)
+
+type WebhookInputPort interface {
+	CreateSubscription(form *model.WebhookAddForm) error
+	SelectSubscriptions() error
+	DeleteSubscription(id string) error
+	SelectDeliveriesBySubscriptionId(id string) error
+	DispatchEvents(ctx context.Context) (int, error)
+	DeliverPending(ctx context.Context) (int, error)
+}
+
+type WebhookOutputPort interface {
+	OutputSubscription(subscription *model.WebhookSubscriptionResponse) error
+	OutputSubscriptions(subscriptions []*model.WebhookSubscriptionResponse) error
+	OutputDeliveries(deliveries []*model.WebhookDeliveryResponse) error
+	OutputError(res *model.ErrorResponse, err error) error
+}
+
+// WebhookRequest Webhook送信内容
+type WebhookRequest struct {
+	URL        string
+	Secret     string
+	EventType  string
+	DeliveryId uint
+	Payload    []byte
+}
+
+// WebhookSender Webhook送信先への送信処理
+type WebhookSender interface {
+	Send(ctx context.Context, req *WebhookRequest) (int, error)
+}
@@ -0,0 +1,38 @@
+package presenter
+
+import (
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type ApiKeyPresenter struct {
+	ctx *gin.Context
+}
+
+func NewApiKeyOutputPort(context *gin.Context) ports.ApiKeyOutputPort {
+	return &ApiKeyPresenter{
+		ctx: context,
+	}
+}
+
+func (a *ApiKeyPresenter) OutputApiKeyCreated(key *model.ApiKeyCreatedResponse) error {
+	a.ctx.JSON(http.StatusOK, key)
+	return nil
+}
+
+func (a *ApiKeyPresenter) OutputApiKey(key *model.ApiKeyResponse) error {
+	a.ctx.JSON(http.StatusOK, key)
+	return nil
+}
+
+func (a *ApiKeyPresenter) OutputApiKeys(keys []*model.ApiKeyResponse) error {
+	a.ctx.JSON(http.StatusOK, keys)
+	return nil
+}
+
+func (a *ApiKeyPresenter) OutputError(res *model.ErrorResponse, err error) error {
+	a.ctx.JSON(res.ErrorCode, res)
+	return err
+}
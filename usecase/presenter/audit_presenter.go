@@ -0,0 +1,28 @@
+package presenter
+
+import (
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type AuditPresenter struct {
+	ctx *gin.Context
+}
+
+func NewAuditOutputPort(context *gin.Context) ports.AuditOutputPort {
+	return &AuditPresenter{
+		ctx: context,
+	}
+}
+
+func (a *AuditPresenter) OutputAuditLogs(entries []*model.AuditLogResponse) error {
+	a.ctx.JSON(http.StatusOK, entries)
+	return nil
+}
+
+func (a *AuditPresenter) OutputError(res *model.ErrorResponse, err error) error {
+	a.ctx.JSON(res.ErrorCode, res)
+	return err
+}
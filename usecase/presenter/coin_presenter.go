@@ -22,6 +22,11 @@ func (c *CoinPresenter) OutputCoinHistory(histories []*model.CoinHistoryResponse
 	return nil
 }
 
+func (c *CoinPresenter) OutputChainVerification(verification *model.ChainVerificationResponse) error {
+	c.ctx.JSON(http.StatusOK, verification)
+	return nil
+}
+
 func (c *CoinPresenter) OutputCoin(coin *model.CoinResponse) error {
 	c.ctx.JSON(http.StatusOK, coin)
 	return nil
@@ -32,6 +37,16 @@ func (c *CoinPresenter) OutputCoinSend(coin *model.CoinSendResponse) error {
 	return nil
 }
 
+func (c *CoinPresenter) OutputCoinTransfer(transfer *model.CoinTransferResponse) error {
+	c.ctx.JSON(http.StatusOK, transfer)
+	return nil
+}
+
+func (c *CoinPresenter) OutputCoinReversal(reversal *model.CoinReversalResponse) error {
+	c.ctx.JSON(http.StatusOK, reversal)
+	return nil
+}
+
 func (c *CoinPresenter) OutputError(res *model.ErrorResponse, err error) error {
 	c.ctx.JSON(res.ErrorCode, res)
 	return err
@@ -0,0 +1,133 @@
+package presenter
+
+import (
+	"bufio"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	mimeNDJSON = "application/x-ndjson"
+	// exportFlushRows 出力をクライアントへ送信する行数の間隔
+	exportFlushRows = 500
+)
+
+var errExportFormat = errors.New("text/csvまたはapplication/x-ndjsonを指定してください")
+
+// exportFormats formatクエリで指定できる出力形式
+var exportFormats = map[string]string{
+	"csv":    mimeCSV,
+	"ndjson": mimeNDJSON,
+}
+
+var exportColumns = []string{"history_id", "userid", "operation", "operation_timestamp", "amount", "counterparty", "reversal_of", "reason", "schedule_id", "balance_after"}
+
+type ExportPresenter struct {
+	ctx    *gin.Context
+	format string
+	buf    *bufio.Writer
+	csv    *csv.Writer
+	json   *json.Encoder
+	rows   int
+}
+
+func NewExportOutputPort(context *gin.Context) ports.ExportOutputPort {
+	return &ExportPresenter{
+		ctx: context,
+	}
+}
+
+func (e *ExportPresenter) OutputExportStart(name string) error {
+	// 出力形式はformatクエリを優先し、未指定の場合はAcceptヘッダーから決定
+	format, ok := exportFormats[e.ctx.Query("format")]
+	if !ok {
+		format = e.ctx.NegotiateFormat(mimeCSV, mimeNDJSON)
+	}
+	if format == "" {
+		return e.OutputError(model.CreateErrorResponse(http.StatusNotAcceptable, errExportFormat.Error()), errExportFormat)
+	}
+	e.format = format
+
+	// ヘッダーは最初の送信時に確定するため、送信前のエラーはJSONで返却できる
+	e.ctx.Header("Content-Type", format+"; charset=utf-8")
+	if format == mimeCSV {
+		e.ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.csv", name))
+	} else {
+		e.ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.ndjson", name))
+	}
+	e.ctx.Status(http.StatusOK)
+	e.buf = bufio.NewWriter(e.ctx.Writer)
+	if format == mimeCSV {
+		e.csv = csv.NewWriter(e.buf)
+		return e.csv.Write(exportColumns)
+	}
+	e.json = json.NewEncoder(e.buf)
+	return nil
+}
+
+func (e *ExportPresenter) OutputExportRow(row *model.HistoryExportResponse) error {
+	var err error
+	if e.csv != nil {
+		err = e.csv.Write([]string{
+			strconv.FormatUint(uint64(row.HistoryId), 10),
+			strconv.FormatUint(uint64(row.UserId), 10),
+			row.Operation,
+			row.OperationTimestamp.Format(time.RFC3339Nano),
+			row.Amount.String(),
+			optionalUintString(row.Counterparty),
+			optionalUintString(row.ReversalOf),
+			row.Reason,
+			optionalUintString(row.ScheduleId),
+			row.BalanceAfter.String(),
+		})
+	} else {
+		err = e.json.Encode(row)
+	}
+	if err != nil {
+		return err
+	}
+
+	// 一定行数ごとにクライアントへ送信
+	e.rows++
+	if e.rows%exportFlushRows == 0 {
+		return e.flush()
+	}
+	return nil
+}
+
+func (e *ExportPresenter) OutputExportEnd() error {
+	return e.flush()
+}
+
+func (e *ExportPresenter) OutputError(res *model.ErrorResponse, err error) error {
+	// 送信開始後はステータスを変更できないため出力を打ち切る
+	if e.ctx.Writer.Written() {
+		return err
+	}
+	e.ctx.Writer.Header().Del("Content-Type")
+	e.ctx.Writer.Header().Del("Content-Disposition")
+	e.ctx.JSON(res.ErrorCode, res)
+	return err
+}
+
+func (e *ExportPresenter) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if err := e.buf.Flush(); err != nil {
+		return err
+	}
+	e.ctx.Writer.Flush()
+	return nil
+}
@@ -0,0 +1,116 @@
+package presenter
+
+import (
+	"coin-api/adapters/grpc/pb"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GrpcCoinPresenter gRPCのレスポンスを保持するCoinOutputPort
+type GrpcCoinPresenter struct {
+	Coin      *pb.CoinResponse
+	Send      *pb.SendCoinResponse
+	Transfer  *pb.CoinTransferResponse
+	Reversal  *pb.CoinReversalResponse
+	Histories *pb.CoinHistoriesResponse
+	Err       error
+}
+
+func NewGrpcCoinOutputPort() *GrpcCoinPresenter {
+	return &GrpcCoinPresenter{}
+}
+
+var _ ports.CoinOutputPort = (*GrpcCoinPresenter)(nil)
+
+func (c *GrpcCoinPresenter) OutputCoinHistory(histories []*model.CoinHistoryResponse) error {
+	res := &pb.CoinHistoriesResponse{Histories: make([]*pb.CoinHistory, 0, len(histories))}
+	for _, h := range histories {
+		history := &pb.CoinHistory{
+			HistoryId:          uint64(h.HistoryId),
+			Operation:          h.Operation,
+			OperationTimestamp: timestamppb.New(h.OperationTimestamp),
+			Amount:             h.Amount.String(),
+			Reason:             h.Reason,
+		}
+		if h.Counterparty != nil {
+			counterparty := uint64(*h.Counterparty)
+			history.Counterparty = &counterparty
+		}
+		if h.ReversalOf != nil {
+			reversalOf := uint64(*h.ReversalOf)
+			history.ReversalOf = &reversalOf
+		}
+		res.Histories = append(res.Histories, history)
+	}
+	c.Histories = res
+	return nil
+}
+
+func (c *GrpcCoinPresenter) OutputChainVerification(verification *model.ChainVerificationResponse) error {
+	// ハッシュチェーン検証はREST APIのみ提供
+	return nil
+}
+
+func (c *GrpcCoinPresenter) OutputCoin(coin *model.CoinResponse) error {
+	c.Coin = coinResponseToProto(coin)
+	return nil
+}
+
+func (c *GrpcCoinPresenter) OutputCoinSend(coin *model.CoinSendResponse) error {
+	c.Send = &pb.SendCoinResponse{
+		Result: &pb.SendCoinResponse_Sent{
+			Sent: &pb.CoinSendResponse{
+				Sender:        uint64(coin.Sender),
+				Receiver:      uint64(coin.Receiver),
+				Amount:        coin.Amount.String(),
+				SenderBalance: coin.SenderBalance.String(),
+			},
+		},
+	}
+	return nil
+}
+
+func (c *GrpcCoinPresenter) OutputCoinTransfer(transfer *model.CoinTransferResponse) error {
+	c.Transfer = &pb.CoinTransferResponse{
+		TransferId: uint64(transfer.TransferId),
+		Sender:     uint64(transfer.Sender),
+		Receiver:   uint64(transfer.Receiver),
+		Amount:     transfer.Amount.String(),
+		Status:     transfer.Status,
+		ExpiresAt:  timestamppb.New(transfer.ExpiresAt),
+		ResolvedAt: timestampOrNil(transfer.ResolvedAt),
+	}
+	// 承認要の送金ではSendCoinの結果としても返却
+	c.Send = &pb.SendCoinResponse{
+		Result: &pb.SendCoinResponse_Transfer{Transfer: c.Transfer},
+	}
+	return nil
+}
+
+func (c *GrpcCoinPresenter) OutputCoinReversal(reversal *model.CoinReversalResponse) error {
+	entries := make([]*pb.CoinResponse, 0, len(reversal.Entries))
+	for _, e := range reversal.Entries {
+		entries = append(entries, coinResponseToProto(e))
+	}
+	c.Reversal = &pb.CoinReversalResponse{
+		OriginalId: uint64(reversal.OriginalId),
+		Reason:     reversal.Reason,
+		Entries:    entries,
+	}
+	return nil
+}
+
+func (c *GrpcCoinPresenter) OutputError(res *model.ErrorResponse, err error) error {
+	c.Err = grpcStatusFromErrorResponse(res)
+	return err
+}
+
+func coinResponseToProto(coin *model.CoinResponse) *pb.CoinResponse {
+	return &pb.CoinResponse{
+		Userid:    uint64(coin.UserId),
+		Operation: coin.Operation,
+		Amount:    coin.Amount.String(),
+		Balance:   coin.Balance.String(),
+	}
+}
@@ -0,0 +1,44 @@
+package presenter
+
+import (
+	"coin-api/usecase/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"net/http"
+	"time"
+)
+
+// grpcStatusFromErrorResponse ErrorResponseのHTTPステータスをgRPCのステータスへ変換
+func grpcStatusFromErrorResponse(res *model.ErrorResponse) error {
+	var code codes.Code
+	switch res.ErrorCode {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.Aborted
+	case http.StatusUnprocessableEntity, http.StatusLocked:
+		code = codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	default:
+		code = codes.Internal
+	}
+	return status.Error(code, res.Message)
+}
+
+// timestampOrNil nilの場合はnilのままTimestampへ変換
+func timestampOrNil(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
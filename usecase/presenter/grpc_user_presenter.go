@@ -0,0 +1,68 @@
+package presenter
+
+import (
+	"coin-api/adapters/grpc/pb"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GrpcUserPresenter gRPCのレスポンスを保持するUserOutputPort
+type GrpcUserPresenter struct {
+	User    *pb.UserResponse
+	Balance *pb.UserBalanceResponse
+	Profile *pb.UserProfileResponse
+	Err     error
+}
+
+func NewGrpcUserOutputPort() *GrpcUserPresenter {
+	return &GrpcUserPresenter{}
+}
+
+var _ ports.UserOutputPort = (*GrpcUserPresenter)(nil)
+
+func (u *GrpcUserPresenter) OutputUser(user *model.UserResponse) error {
+	u.User = &pb.UserResponse{
+		Userid:   uint64(user.UserId),
+		Username: user.Name,
+		Balance:  user.Balance.String(),
+	}
+	return nil
+}
+
+func (u *GrpcUserPresenter) OutputUserBalance(balance *model.UserBalanceResponse) error {
+	u.Balance = &pb.UserBalanceResponse{
+		Userid:      uint64(balance.UserId),
+		Balance:     balance.Balance.String(),
+		HeldBalance: balance.HeldBalance.String(),
+	}
+	return nil
+}
+
+func (u *GrpcUserPresenter) OutputUserProfile(profile *model.UserProfileResponse) error {
+	u.Profile = &pb.UserProfileResponse{
+		Userid:   uint64(profile.UserId),
+		Username: profile.Name,
+	}
+	return nil
+}
+
+func (u *GrpcUserPresenter) OutputPasswordResetRequested() error {
+	// パスワード再設定はREST APIのみ提供
+	return status.Error(codes.Unimplemented, "パスワード再設定はgRPC未対応です")
+}
+
+func (u *GrpcUserPresenter) OutputBalanceEvent(e *model.BalanceEventResponse) error {
+	// 残高変更ストリームはSSEのみ提供
+	return status.Error(codes.Unimplemented, "残高変更ストリームはgRPC未対応です")
+}
+
+func (u *GrpcUserPresenter) OutputKeepAlive() error {
+	return status.Error(codes.Unimplemented, "残高変更ストリームはgRPC未対応です")
+}
+
+func (u *GrpcUserPresenter) OutputError(res *model.ErrorResponse, err error) error {
+	u.Err = grpcStatusFromErrorResponse(res)
+	return err
+}
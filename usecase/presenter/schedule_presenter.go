@@ -0,0 +1,38 @@
+package presenter
+
+import (
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type SchedulePresenter struct {
+	ctx *gin.Context
+}
+
+func NewScheduleOutputPort(context *gin.Context) ports.ScheduleOutputPort {
+	return &SchedulePresenter{
+		ctx: context,
+	}
+}
+
+func (s *SchedulePresenter) OutputSchedule(schedule *model.ScheduleResponse) error {
+	s.ctx.JSON(http.StatusOK, schedule)
+	return nil
+}
+
+func (s *SchedulePresenter) OutputSchedules(schedules []*model.ScheduleResponse) error {
+	s.ctx.JSON(http.StatusOK, schedules)
+	return nil
+}
+
+func (s *SchedulePresenter) OutputScheduleExecutions(executions []*model.ScheduleExecutionResponse) error {
+	s.ctx.JSON(http.StatusOK, executions)
+	return nil
+}
+
+func (s *SchedulePresenter) OutputError(res *model.ErrorResponse, err error) error {
+	s.ctx.JSON(res.ErrorCode, res)
+	return err
+}
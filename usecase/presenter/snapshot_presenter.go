@@ -0,0 +1,28 @@
+package presenter
+
+import (
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type SnapshotPresenter struct {
+	ctx *gin.Context
+}
+
+func NewSnapshotOutputPort(context *gin.Context) ports.SnapshotOutputPort {
+	return &SnapshotPresenter{
+		ctx: context,
+	}
+}
+
+func (s *SnapshotPresenter) OutputSnapshotVerification(verification *model.SnapshotVerificationResponse) error {
+	s.ctx.JSON(http.StatusOK, verification)
+	return nil
+}
+
+func (s *SnapshotPresenter) OutputError(res *model.ErrorResponse, err error) error {
+	s.ctx.JSON(res.ErrorCode, res)
+	return err
+}
@@ -0,0 +1,127 @@
+package presenter
+
+import (
+	"bytes"
+	"coin-api/common/pdf"
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"encoding/csv"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	mimeCSV = "text/csv"
+	mimePDF = "application/pdf"
+)
+
+// statementFormats formatクエリで指定できる出力形式
+var statementFormats = map[string]string{
+	"json": gin.MIMEJSON,
+	"csv":  mimeCSV,
+	"pdf":  mimePDF,
+}
+
+type StatementPresenter struct {
+	ctx *gin.Context
+}
+
+func NewStatementOutputPort(context *gin.Context) ports.StatementOutputPort {
+	return &StatementPresenter{
+		ctx: context,
+	}
+}
+
+func (s *StatementPresenter) OutputBalanceAt(balance *model.BalanceAtResponse) error {
+	s.ctx.JSON(http.StatusOK, balance)
+	return nil
+}
+
+func (s *StatementPresenter) OutputStatement(statement *model.StatementResponse) error {
+	// 出力形式はformatクエリを優先し、未指定の場合はAcceptヘッダーから決定
+	format, ok := statementFormats[s.ctx.Query("format")]
+	if !ok {
+		format = s.ctx.NegotiateFormat(gin.MIMEJSON, mimeCSV, mimePDF)
+	}
+
+	filename := fmt.Sprintf("statement_%d_%s_%s", statement.UserId, statement.From.Format("20060102"), statement.To.Format("20060102"))
+	switch format {
+	case mimeCSV:
+		body, err := statementCSV(statement)
+		if err != nil {
+			return err
+		}
+		s.ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.csv", filename))
+		s.ctx.Data(http.StatusOK, mimeCSV+"; charset=utf-8", body)
+	case mimePDF:
+		s.ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.pdf", filename))
+		s.ctx.Data(http.StatusOK, mimePDF, statementPDF(statement))
+	default:
+		s.ctx.JSON(http.StatusOK, statement)
+	}
+	return nil
+}
+
+func (s *StatementPresenter) OutputError(res *model.ErrorResponse, err error) error {
+	s.ctx.JSON(res.ErrorCode, res)
+	return err
+}
+
+// statementCSV 期首残高、各取引、期末残高の順に出力
+func statementCSV(statement *model.StatementResponse) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	records := [][]string{
+		{"history_id", "operation", "operation_timestamp", "amount", "counterparty", "balance"},
+		{"", "OPENING", statement.From.Format(time.RFC3339), "", "", statement.OpeningBalance.String()},
+	}
+	for _, t := range statement.Transactions {
+		records = append(records, []string{
+			strconv.FormatUint(uint64(t.HistoryId), 10),
+			t.Operation,
+			t.OperationTimestamp.Format(time.RFC3339),
+			t.Amount.String(),
+			optionalUintString(t.Counterparty),
+			t.Balance.String(),
+		})
+	}
+	records = append(records, []string{"", "CLOSING", statement.To.Format(time.RFC3339), "", "", statement.ClosingBalance.String()})
+	if err := w.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// statementPDF 取引明細を固定幅の表としてPDF出力
+func statementPDF(statement *model.StatementResponse) []byte {
+	const row = "%-10s %-9s %-25s %16s %12s %16s"
+	doc := pdf.NewDocument()
+	doc.AddLine(fmt.Sprintf("Statement  user: %d", statement.UserId))
+	doc.AddLine(fmt.Sprintf("Period     %s - %s", statement.From.Format(time.RFC3339), statement.To.Format(time.RFC3339)))
+	doc.AddLine(fmt.Sprintf("Opening    %s", statement.OpeningBalance.String()))
+	doc.AddLine("")
+	doc.AddLine(fmt.Sprintf(row, "ID", "OPERATION", "TIMESTAMP", "AMOUNT", "COUNTERPARTY", "BALANCE"))
+	for _, t := range statement.Transactions {
+		doc.AddLine(fmt.Sprintf(row,
+			strconv.FormatUint(uint64(t.HistoryId), 10),
+			t.Operation,
+			t.OperationTimestamp.Format(time.RFC3339),
+			t.Amount.String(),
+			optionalUintString(t.Counterparty),
+			t.Balance.String(),
+		))
+	}
+	doc.AddLine("")
+	doc.AddLine(fmt.Sprintf("Closing    %s", statement.ClosingBalance.String()))
+	return doc.Bytes()
+}
+
+func optionalUintString(v *uint) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*v), 10)
+}
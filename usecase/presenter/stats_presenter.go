@@ -0,0 +1,48 @@
+package presenter
+
+import (
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type StatsPresenter struct {
+	ctx *gin.Context
+}
+
+func NewStatsOutputPort(context *gin.Context) ports.StatsOutputPort {
+	return &StatsPresenter{
+		ctx: context,
+	}
+}
+
+func (s *StatsPresenter) OutputCirculation(circulation *model.CirculationResponse) error {
+	s.ctx.JSON(http.StatusOK, circulation)
+	return nil
+}
+
+func (s *StatsPresenter) OutputSupply(buckets []*model.SupplyStatsResponse) error {
+	s.ctx.JSON(http.StatusOK, buckets)
+	return nil
+}
+
+func (s *StatsPresenter) OutputTransferVolume(buckets []*model.TransferStatsResponse) error {
+	s.ctx.JSON(http.StatusOK, buckets)
+	return nil
+}
+
+func (s *StatsPresenter) OutputActiveUsers(buckets []*model.ActiveUsersResponse) error {
+	s.ctx.JSON(http.StatusOK, buckets)
+	return nil
+}
+
+func (s *StatsPresenter) OutputTopUsers(users []*model.TopUserResponse) error {
+	s.ctx.JSON(http.StatusOK, users)
+	return nil
+}
+
+func (s *StatsPresenter) OutputError(res *model.ErrorResponse, err error) error {
+	s.ctx.JSON(res.ErrorCode, res)
+	return err
+}
@@ -0,0 +1,28 @@
+package presenter
+
+import (
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type TenantPresenter struct {
+	ctx *gin.Context
+}
+
+func NewTenantOutputPort(context *gin.Context) ports.TenantOutputPort {
+	return &TenantPresenter{
+		ctx: context,
+	}
+}
+
+func (t *TenantPresenter) OutputTenant(tenant *model.TenantResponse) error {
+	t.ctx.JSON(http.StatusOK, tenant)
+	return nil
+}
+
+func (t *TenantPresenter) OutputError(res *model.ErrorResponse, err error) error {
+	t.ctx.JSON(res.ErrorCode, res)
+	return err
+}
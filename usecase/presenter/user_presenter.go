@@ -27,6 +27,32 @@ func (u *UserPresenter) OutputUserBalance(balance *model.UserBalanceResponse) er
 	return nil
 }
 
+func (u *UserPresenter) OutputUserProfile(profile *model.UserProfileResponse) error {
+	u.ctx.JSON(http.StatusOK, profile)
+	return nil
+}
+
+func (u *UserPresenter) OutputPasswordResetRequested() error {
+	// ユーザーの存在有無によらず受付のみ返却
+	u.ctx.Status(http.StatusAccepted)
+	return nil
+}
+
+func (u *UserPresenter) OutputBalanceEvent(e *model.BalanceEventResponse) error {
+	u.ctx.SSEvent("balance", e)
+	u.ctx.Writer.Flush()
+	return nil
+}
+
+func (u *UserPresenter) OutputKeepAlive() error {
+	// SSEのコメント行で接続を維持
+	if _, err := u.ctx.Writer.WriteString(": keepalive\n\n"); err != nil {
+		return err
+	}
+	u.ctx.Writer.Flush()
+	return nil
+}
+
 func (u *UserPresenter) OutputError(res *model.ErrorResponse, err error) error {
 	u.ctx.JSON(res.ErrorCode, res)
 	return err
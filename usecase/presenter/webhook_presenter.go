@@ -0,0 +1,38 @@
+package presenter
+
+import (
+	"coin-api/usecase/model"
+	"coin-api/usecase/port"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type WebhookPresenter struct {
+	ctx *gin.Context
+}
+
+func NewWebhookOutputPort(context *gin.Context) ports.WebhookOutputPort {
+	return &WebhookPresenter{
+		ctx: context,
+	}
+}
+
+func (w *WebhookPresenter) OutputSubscription(subscription *model.WebhookSubscriptionResponse) error {
+	w.ctx.JSON(http.StatusOK, subscription)
+	return nil
+}
+
+func (w *WebhookPresenter) OutputSubscriptions(subscriptions []*model.WebhookSubscriptionResponse) error {
+	w.ctx.JSON(http.StatusOK, subscriptions)
+	return nil
+}
+
+func (w *WebhookPresenter) OutputDeliveries(deliveries []*model.WebhookDeliveryResponse) error {
+	w.ctx.JSON(http.StatusOK, deliveries)
+	return nil
+}
+
+func (w *WebhookPresenter) OutputError(res *model.ErrorResponse, err error) error {
+	w.ctx.JSON(res.ErrorCode, res)
+	return err
+}
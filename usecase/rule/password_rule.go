@@ -0,0 +1,117 @@
+package rule
+
+import (
+	"bufio"
+	"coin-api/config"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PasswordViolation パスワードの強度要件違反
+type PasswordViolation struct {
+	Rule    string
+	Message string
+}
+
+func (v *PasswordViolation) Error() string {
+	return fmt.Sprintf("パスワード要件違反 [%s] : %s", v.Rule, v.Message)
+}
+
+// PasswordPolicy パスワードの強度要件
+type PasswordPolicy struct {
+	minLength      int
+	maxLength      int
+	minCharClasses int
+	breached       map[string]struct{}
+}
+
+var (
+	breachedOnce sync.Once
+	breachedSet  map[string]struct{}
+)
+
+func NewPasswordPolicy(conf *config.PasswordInfo) *PasswordPolicy {
+	// 漏洩パスワード一覧は初回のみ読込
+	breachedOnce.Do(func() {
+		breachedSet = loadBreachedPasswords(conf.BreachedList)
+	})
+
+	return &PasswordPolicy{
+		minLength:      conf.MinLength,
+		maxLength:      conf.MaxLength,
+		minCharClasses: conf.MinCharClasses,
+		breached:       breachedSet,
+	}
+}
+
+// Check パスワードが要件を満たすか判定(満たさない場合は*PasswordViolation)
+func (p *PasswordPolicy) Check(password string) error {
+	if utf8.RuneCountInString(password) < p.minLength {
+		return &PasswordViolation{Rule: "min_length", Message: fmt.Sprintf("パスワードは%d文字以上で指定してください", p.minLength)}
+	}
+	// bcryptは先頭72バイトのみを使用するためバイト数で判定
+	if p.maxLength > 0 && len(password) > p.maxLength {
+		return &PasswordViolation{Rule: "max_length", Message: fmt.Sprintf("パスワードは%dバイト以下で指定してください", p.maxLength)}
+	}
+	if classes := charClasses(password); classes < p.minCharClasses {
+		return &PasswordViolation{Rule: "char_classes", Message: fmt.Sprintf("パスワードには英大文字・英小文字・数字・記号のうち%d種類以上を含めてください", p.minCharClasses)}
+	}
+	if _, ok := p.breached[strings.ToLower(password)]; ok {
+		return &PasswordViolation{Rule: "breached", Message: "漏洩が確認されているパスワードは使用できません"}
+	}
+	return nil
+}
+
+// charClasses 英大文字・英小文字・数字・記号のうち含まれる種類数
+func charClasses(password string) int {
+	var upper, lower, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return upper + lower + digit + symbol
+}
+
+// loadBreachedPasswords 漏洩パスワード一覧(1行1件、#以降はコメント)を大文字小文字を区別せずに読込
+func loadBreachedPasswords(path string) map[string]struct{} {
+	set := make(map[string]struct{})
+	if path == "" {
+		return set
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		// 読込できない場合は確認せずに継続
+		log.Warn().Msg(fmt.Sprintf("漏洩パスワード一覧を読込できません : %s", path))
+		return set
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		set[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Warn().Msg(fmt.Sprintf("漏洩パスワード一覧の読込でエラー発生 : %s", path))
+	}
+	log.Info().Msg(fmt.Sprintf("漏洩パスワード一覧を読込 件数 : %d", len(set)))
+
+	return set
+}
@@ -0,0 +1,191 @@
+package rule
+
+import (
+	"coin-api/common/enum"
+	"coin-api/config"
+	"coin-api/domain/model"
+	"coin-api/domain/repository"
+	"context"
+	"fmt"
+	"time"
+)
+
+// TransferRequest 送金ルール判定対象
+type TransferRequest struct {
+	Sender   *model.User
+	Receiver *model.User
+	Amount   int
+	Now      time.Time
+}
+
+// Violation 送金ルール違反
+type Violation struct {
+	Rule    string
+	Message string
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("送金ルール違反 [%s] : %s", v.Rule, v.Message)
+}
+
+type TransferRule interface {
+	Evaluate(ctx context.Context, req *TransferRequest) error
+}
+
+type TransferRuleEngine struct {
+	rules   []TransferRule
+	tenants map[string][]TransferRule
+}
+
+func NewTransferRuleEngine(conf *config.AppConfig, cr repository.ICoinRepository) *TransferRuleEngine {
+	// テナントごとにルールを生成(送金ルールを個別に設定したテナントはテナントの設定値、金額はテナントのコインの桁数へ変換)
+	tenantRules := make(map[string][]TransferRule)
+	for _, t := range conf.TenantsInfo.Tenants {
+		rule := conf.TransferRuleInfo
+		if t.TransferRule != nil {
+			rule = t.TransferRule
+		}
+		tenantRules[t.Id] = newTransferRules(rule, coinOf(conf, t.Id), cr)
+	}
+
+	return &TransferRuleEngine{
+		rules:   newTransferRules(conf.TransferRuleInfo, coinOf(conf, conf.TenantsInfo.DefaultId), cr),
+		tenants: tenantRules,
+	}
+}
+
+// coinOf 設定のテナントで扱うコイン
+func coinOf(conf *config.AppConfig, tenantId string) model.Coin {
+	info := conf.CoinOf(tenantId)
+	return model.Coin{Code: info.Code, Precision: info.Precision}
+}
+
+func newTransferRules(conf *config.TransferRuleInfo, coin model.Coin, cr repository.ICoinRepository) []TransferRule {
+	// 設定値はコイン単位のため最小単位へ変換
+	scale := int(coin.Scale())
+
+	// 設定値が0の場合はルールを登録しない
+	rules := make([]TransferRule, 0)
+	if len(conf.BlockedPairs) > 0 {
+		rules = append(rules, &blockedPairRule{pairs: conf.BlockedPairs})
+	}
+	if conf.MinAccountAge > 0 {
+		rules = append(rules, &minAccountAgeRule{age: conf.MinAccountAge})
+	}
+	if conf.MaxAmount > 0 {
+		rules = append(rules, &maxAmountRule{max: conf.MaxAmount * scale, coin: coin})
+	}
+	if conf.MaxCountPerHour > 0 {
+		rules = append(rules, &hourlyCountRule{max: conf.MaxCountPerHour, cr: cr})
+	}
+	if conf.DailyCap > 0 {
+		rules = append(rules, &periodCapRule{name: "daily_cap", cap: conf.DailyCap * scale, coin: coin, from: startOfDay, cr: cr})
+	}
+	if conf.MonthlyCap > 0 {
+		rules = append(rules, &periodCapRule{name: "monthly_cap", cap: conf.MonthlyCap * scale, coin: coin, from: startOfMonth, cr: cr})
+	}
+
+	return rules
+}
+
+// Evaluate テナント間の送金でないことを確認後、送金者のテナントのルールを登録順に評価し、最初の違反を返却
+// 上限の集計は送金者の行をロックしたトランザクション内で呼び出し、並行する送金を含めて評価すること
+func (e *TransferRuleEngine) Evaluate(ctx context.Context, req *TransferRequest) error {
+	if req.Sender.TenantId != req.Receiver.TenantId {
+		return &Violation{Rule: "cross_tenant", Message: "異なるテナントのユーザーへは送金できません"}
+	}
+
+	rules, ok := e.tenants[req.Sender.TenantId]
+	if !ok {
+		rules = e.rules
+	}
+	for _, r := range rules {
+		if err := r.Evaluate(ctx, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type blockedPairRule struct {
+	pairs []config.BlockedPair
+}
+
+func (r *blockedPairRule) Evaluate(ctx context.Context, req *TransferRequest) error {
+	for _, p := range r.pairs {
+		if p.Sender == req.Sender.ID && p.Receiver == req.Receiver.ID {
+			return &Violation{Rule: "blocked_pair", Message: fmt.Sprintf("ユーザー%dからユーザー%dへの送金は禁止されています", p.Sender, p.Receiver)}
+		}
+	}
+	return nil
+}
+
+type minAccountAgeRule struct {
+	age time.Duration
+}
+
+func (r *minAccountAgeRule) Evaluate(ctx context.Context, req *TransferRequest) error {
+	if req.Now.Sub(req.Sender.CreatedAt) < r.age {
+		return &Violation{Rule: "min_account_age", Message: fmt.Sprintf("アカウント作成から%s経過していないため送金できません", r.age)}
+	}
+	return nil
+}
+
+type maxAmountRule struct {
+	max  int
+	coin model.Coin
+}
+
+func (r *maxAmountRule) Evaluate(ctx context.Context, req *TransferRequest) error {
+	if req.Amount > r.max {
+		return &Violation{Rule: "max_amount", Message: fmt.Sprintf("1回の送金上限(%s)を超えています", r.coin.Decimal(r.max))}
+	}
+	return nil
+}
+
+type hourlyCountRule struct {
+	max int
+	cr  repository.ICoinRepository
+}
+
+func (r *hourlyCountRule) Evaluate(ctx context.Context, req *TransferRequest) error {
+	// 直近1時間の送金回数を取得
+	count, err := r.cr.CountByUserIdSince(ctx, req.Sender.ID, req.Now.Add(-time.Hour), string(enum.SEND), string(enum.HOLD))
+	if err != nil {
+		return err
+	}
+	if count >= r.max {
+		return &Violation{Rule: "max_count_per_hour", Message: fmt.Sprintf("1時間あたりの送金回数上限(%d)を超えています", r.max)}
+	}
+	return nil
+}
+
+type periodCapRule struct {
+	name string
+	cap  int
+	coin model.Coin
+	from func(time.Time) time.Time
+	cr   repository.ICoinRepository
+}
+
+func (r *periodCapRule) Evaluate(ctx context.Context, req *TransferRequest) error {
+	// 期間内の送金合計を取得(SEND/HOLD履歴は負数で保存)
+	sum, err := r.cr.SumAmountByUserIdSince(ctx, req.Sender.ID, r.from(req.Now), string(enum.SEND), string(enum.HOLD))
+	if err != nil {
+		return err
+	}
+	if -sum+req.Amount > r.cap {
+		return &Violation{Rule: r.name, Message: fmt.Sprintf("期間内の送金上限(%s)を超えています", r.coin.Decimal(r.cap))}
+	}
+	return nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+func startOfMonth(t time.Time) time.Time {
+	y, m, _ := t.Date()
+	return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+}
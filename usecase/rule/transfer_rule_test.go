@@ -0,0 +1,163 @@
+package rule
+
+import (
+	"coin-api/config"
+	"coin-api/domain/model"
+	"coin-api/domain/repository"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeCoinRepository 集計結果を返却し、集計開始日時を記録するICoinRepository
+type fakeCoinRepository struct {
+	repository.ICoinRepository
+	count int
+	sum   int
+	since time.Time
+}
+
+func (f *fakeCoinRepository) CountByUserIdSince(_ context.Context, _ uint, since time.Time, _ ...string) (int, error) {
+	f.since = since
+	return f.count, nil
+}
+
+func (f *fakeCoinRepository) SumAmountByUserIdSince(_ context.Context, _ uint, since time.Time, _ ...string) (int, error) {
+	f.since = since
+	return f.sum, nil
+}
+
+var (
+	testCoin = model.Coin{Code: "COIN", Precision: 2}
+	testNow  = time.Date(2023, 4, 15, 10, 30, 0, 0, time.UTC)
+)
+
+func testUser(id uint, tenantId string, createdAt time.Time) *model.User {
+	u := &model.User{TenantId: tenantId}
+	u.ID = id
+	u.CreatedAt = createdAt
+	return u
+}
+
+// violationRule 違反したルール名(違反なしの場合は空文字)
+func violationRule(t *testing.T, err error) string {
+	t.Helper()
+	if err == nil {
+		return ""
+	}
+	var v *Violation
+	if !errors.As(err, &v) {
+		t.Fatalf("Evaluate() error = %v, want *Violation", err)
+	}
+	return v.Rule
+}
+
+func TestTransferRules(t *testing.T) {
+	old := testNow.Add(-365 * 24 * time.Hour)
+	tests := []struct {
+		name      string
+		conf      config.TransferRuleInfo
+		sender    *model.User
+		receiver  *model.User
+		amount    int
+		count     int
+		sum       int
+		want      string
+		wantSince time.Time
+	}{
+		// 送金禁止ペアは送金者→受取人の向きのみ
+		{name: "blocked pair", conf: config.TransferRuleInfo{BlockedPairs: []config.BlockedPair{{Sender: 1, Receiver: 2}}},
+			sender: testUser(1, "default", old), receiver: testUser(2, "default", old), amount: 100, want: "blocked_pair"},
+		{name: "blocked pair reversed", conf: config.TransferRuleInfo{BlockedPairs: []config.BlockedPair{{Sender: 1, Receiver: 2}}},
+			sender: testUser(2, "default", old), receiver: testUser(1, "default", old), amount: 100},
+		{name: "blocked pair other receiver", conf: config.TransferRuleInfo{BlockedPairs: []config.BlockedPair{{Sender: 1, Receiver: 2}}},
+			sender: testUser(1, "default", old), receiver: testUser(3, "default", old), amount: 100},
+
+		// アカウント作成からの経過時間は境界を含めて許可
+		{name: "min account age reached", conf: config.TransferRuleInfo{MinAccountAge: 24 * time.Hour},
+			sender: testUser(1, "default", testNow.Add(-24*time.Hour)), receiver: testUser(2, "default", old), amount: 100},
+		{name: "min account age not reached", conf: config.TransferRuleInfo{MinAccountAge: 24 * time.Hour},
+			sender: testUser(1, "default", testNow.Add(-24*time.Hour+time.Second)), receiver: testUser(2, "default", old), amount: 100, want: "min_account_age"},
+
+		// 1回の上限額(設定値はコイン単位、100 = 10000最小単位)
+		{name: "max amount at limit", conf: config.TransferRuleInfo{MaxAmount: 100},
+			sender: testUser(1, "default", old), receiver: testUser(2, "default", old), amount: 10000},
+		{name: "max amount over limit", conf: config.TransferRuleInfo{MaxAmount: 100},
+			sender: testUser(1, "default", old), receiver: testUser(2, "default", old), amount: 10001, want: "max_amount"},
+
+		// 直近1時間の送金回数(今回の送金で上限に達する場合は許可)
+		{name: "count per hour below limit", conf: config.TransferRuleInfo{MaxCountPerHour: 3},
+			sender: testUser(1, "default", old), receiver: testUser(2, "default", old), amount: 100, count: 2, wantSince: testNow.Add(-time.Hour)},
+		{name: "count per hour at limit", conf: config.TransferRuleInfo{MaxCountPerHour: 3},
+			sender: testUser(1, "default", old), receiver: testUser(2, "default", old), amount: 100, count: 3, want: "max_count_per_hour", wantSince: testNow.Add(-time.Hour)},
+
+		// 日次/月次の送金上限(送金履歴は負数、今回の送金額を含めて上限以下は許可)
+		{name: "daily cap at limit", conf: config.TransferRuleInfo{DailyCap: 100},
+			sender: testUser(1, "default", old), receiver: testUser(2, "default", old), amount: 4000, sum: -6000, wantSince: time.Date(2023, 4, 15, 0, 0, 0, 0, time.UTC)},
+		{name: "daily cap over limit", conf: config.TransferRuleInfo{DailyCap: 100},
+			sender: testUser(1, "default", old), receiver: testUser(2, "default", old), amount: 4001, sum: -6000, want: "daily_cap", wantSince: time.Date(2023, 4, 15, 0, 0, 0, 0, time.UTC)},
+		{name: "monthly cap at limit", conf: config.TransferRuleInfo{MonthlyCap: 1000},
+			sender: testUser(1, "default", old), receiver: testUser(2, "default", old), amount: 1, sum: -99999, wantSince: time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "monthly cap over limit", conf: config.TransferRuleInfo{MonthlyCap: 1000},
+			sender: testUser(1, "default", old), receiver: testUser(2, "default", old), amount: 2, sum: -99999, want: "monthly_cap", wantSince: time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)},
+
+		// 設定値が0のルールは評価しない
+		{name: "no rules", sender: testUser(1, "default", testNow), receiver: testUser(2, "default", testNow), amount: 1 << 40, count: 1000, sum: -(1 << 40)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := &fakeCoinRepository{count: tt.count, sum: tt.sum}
+			e := &TransferRuleEngine{rules: newTransferRules(&tt.conf, testCoin, cr)}
+			err := e.Evaluate(context.Background(), &TransferRequest{Sender: tt.sender, Receiver: tt.receiver, Amount: tt.amount, Now: testNow})
+			if got := violationRule(t, err); got != tt.want {
+				t.Errorf("Evaluate() rule = %q, want %q", got, tt.want)
+			}
+			if !cr.since.Equal(tt.wantSince) {
+				t.Errorf("since = %v, want %v", cr.since, tt.wantSince)
+			}
+		})
+	}
+}
+
+func TestTransferRulesCrossTenant(t *testing.T) {
+	old := testNow.Add(-365 * 24 * time.Hour)
+	e := &TransferRuleEngine{rules: newTransferRules(&config.TransferRuleInfo{}, testCoin, &fakeCoinRepository{})}
+
+	// 異なるテナントのユーザーへの送金はルールの設定によらず違反
+	err := e.Evaluate(context.Background(), &TransferRequest{Sender: testUser(1, "default", old), Receiver: testUser(2, "shop", old), Amount: 100, Now: testNow})
+	if got := violationRule(t, err); got != "cross_tenant" {
+		t.Errorf("Evaluate() rule = %q, want cross_tenant", got)
+	}
+	err = e.Evaluate(context.Background(), &TransferRequest{Sender: testUser(1, "shop", old), Receiver: testUser(2, "shop", old), Amount: 100, Now: testNow})
+	if got := violationRule(t, err); got != "" {
+		t.Errorf("Evaluate() rule = %q, want none", got)
+	}
+}
+
+func TestTransferRulesPerTenant(t *testing.T) {
+	old := testNow.Add(-365 * 24 * time.Hour)
+	cr := &fakeCoinRepository{}
+	e := &TransferRuleEngine{
+		rules:   newTransferRules(&config.TransferRuleInfo{MaxAmount: 100}, testCoin, cr),
+		tenants: map[string][]TransferRule{"shop": newTransferRules(&config.TransferRuleInfo{MaxAmount: 10}, model.Coin{Code: "POINT", Precision: 0}, cr)},
+	}
+
+	// 送金者のテナントのルールと桁数で評価(設定のないテナントは共通のルール)
+	tests := []struct {
+		tenantId string
+		amount   int
+		want     string
+	}{
+		{tenantId: "shop", amount: 10},
+		{tenantId: "shop", amount: 11, want: "max_amount"},
+		{tenantId: "other", amount: 10000},
+		{tenantId: "other", amount: 10001, want: "max_amount"},
+	}
+	for _, tt := range tests {
+		err := e.Evaluate(context.Background(), &TransferRequest{Sender: testUser(1, tt.tenantId, old), Receiver: testUser(2, tt.tenantId, old), Amount: tt.amount, Now: testNow})
+		if got := violationRule(t, err); got != tt.want {
+			t.Errorf("tenant %s amount %d: rule = %q, want %q", tt.tenantId, tt.amount, got, tt.want)
+		}
+	}
+}